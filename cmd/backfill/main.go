@@ -17,11 +17,13 @@ func main() {
 	}
 
 	// Initialize DB
-	config.InitDB()
-	db := config.PostgresDB
+	cfg := config.MustLoad()
+	stores := config.MustConnect(cfg)
+	defer stores.Close(context.Background())
+	db := stores.Postgres
 
 	repo := repository.NewCourseRepository(db)
-	embedder := ai.NewEmbedder()
+	embedder := ai.NewEmbedder(cfg.LLM)
 
 	log.Println("Starting backfill for course embeddings...")
 
@@ -40,7 +42,7 @@ func main() {
 			continue
 		}
 
-		embedding, err := embedder.GenerateEmbedding(course.Description)
+		embedding, err := embedder.GenerateEmbedding(context.Background(), course.Description)
 		if err != nil {
 			log.Printf("Failed to generate embedding for %s: %v", course.CourseID, err)
 			continue
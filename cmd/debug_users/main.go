@@ -1,10 +1,10 @@
 package main
 
 import (
+	"academ_aide/internal/config"
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/joho/godotenv"
@@ -15,12 +15,7 @@ func main() {
 		log.Println("No .env file found, defaults may fail if not localhost")
 	}
 
-	dsn := os.Getenv("POSTGRES_DSN")
-	if dsn == "" {
-		dsn = "host=localhost user=postgres password=postgres dbname=academ_aide port=5432 sslmode=disable"
-	}
-
-	db, err := sql.Open("pgx", dsn)
+	db, err := sql.Open("pgx", config.MustLoad().Postgres.DSN)
 	if err != nil {
 		log.Fatal(err)
 	}
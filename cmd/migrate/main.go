@@ -2,33 +2,89 @@ package main
 
 import (
 	"academ_aide/internal/config"
+	"academ_aide/internal/migrate"
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
 
+// Schema migrations (internal/migrate/migrations).
+//
+//	go run ./cmd/migrate up         apply every pending migration
+//	go run ./cmd/migrate down [n]   revert the newest n migrations (default 1)
+//	go run ./cmd/migrate status     list migrations and when they were applied
+//	go run ./cmd/migrate redo       revert and re-apply the newest migration
 func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: migrate up | down [n] | status | redo")
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	// Load env
 	if err := godotenv.Load(); err != nil {
 		log.Println("Warning: No .env file found")
 	}
+	stores := config.MustConnect(config.MustLoad())
+	ctx := context.Background()
+	defer stores.Close(ctx)
 
-	config.InitDB()
-	db := config.PostgresDB
-
-	// Read schema file
-	schemaBytes, err := os.ReadFile("database/schema.sql")
+	m, err := migrate.New(stores.Postgres)
 	if err != nil {
-		log.Fatal("Failed to read database/schema.sql:", err)
+		log.Fatal("Loading migrations failed: ", err)
 	}
 
-	log.Println("Applying schema...")
-	// Execute schema
-	_, err = db.Exec(string(schemaBytes))
-	if err != nil {
-		log.Fatal("Failed to apply schema:", err)
+	switch cmd := flag.Arg(0); cmd {
+	case "up":
+		applied, err := m.Up(ctx)
+		if err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		log.Printf("Applied %d migration(s)", len(applied))
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			if steps, err = strconv.Atoi(flag.Arg(1)); err != nil || steps < 1 {
+				log.Fatalf("down: %q is not a positive number of steps", flag.Arg(1))
+			}
+		}
+		reverted, err := m.Down(ctx, steps)
+		if err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		log.Printf("Reverted %d migration(s)", len(reverted))
+	case "redo":
+		mig, err := m.Redo(ctx)
+		if err != nil {
+			log.Fatal("Migration failed: ", err)
+		}
+		log.Printf("Redid %04d_%s", mig.Version, mig.Name)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal("Reading migration status failed: ", err)
+		}
+		for _, st := range statuses {
+			state := "pending"
+			if st.AppliedAt != nil {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			if st.Modified {
+				state += " (MODIFIED since applied)"
+			}
+			fmt.Printf("%04d  %-40s %s\n", st.Version, st.Name, state)
+		}
+	default:
+		log.Printf("Unknown command %q", cmd)
+		flag.Usage()
+		os.Exit(2)
 	}
-
-	log.Println("Schema applied successfully!")
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"academ_aide/internal/config"
+	"academ_aide/internal/services"
+	"bufio"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+)
+
+// Sets the login password of an admin, counsellor or teacher account. The password is read from
+// the first line of stdin, so it stays out of the shell history:
+//
+//	go run ./cmd/passwd -role admin -id A001
+//	go run ./cmd/passwd -role counsellor -id C001
+//	go run ./cmd/passwd -role teacher -id F1001
+func main() {
+	role := flag.String("role", "admin", "Account role: admin, counsellor or teacher")
+	id := flag.String("id", "", "Account ID")
+	flag.Parse()
+
+	if *id == "" || !services.HasPassword(*role) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Load env
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: No .env file found")
+	}
+	stores := config.MustConnect(config.MustLoad())
+	ctx := context.Background()
+	defer stores.Close(ctx)
+
+	log.Printf("Password for %s %s: ", *role, *id)
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		log.Fatal("Reading password failed: ", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	accounts := services.NewAccountService(stores.Postgres, stores.Redis, nil, nil)
+	if err := accounts.SetPassword(ctx, *role, *id, password); err != nil {
+		log.Fatal("Setting password failed: ", err)
+	}
+	log.Printf("Password set for %s %s", *role, *id)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"academ_aide/internal/config"
+	"academ_aide/internal/services"
+	"context"
+	"log"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// One-off risk scoring run, for cron or when the server's scheduler is disabled
+// (RISK_SCORING_INTERVAL=0).
+func main() {
+	// Load env
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: No .env file found")
+	}
+
+	// Initialize DB
+	stores := config.MustConnect(config.MustLoad())
+	defer stores.Close(context.Background())
+	db := stores.Postgres
+
+	log.Println("Scoring student risk...")
+	start := time.Now()
+	n, err := services.NewRiskService(db, stores.Mongo, services.NewAlertService(db)).ComputeAll(context.Background())
+	if err != nil {
+		log.Fatalf("Risk scoring failed: %v", err)
+	}
+	log.Printf("Scored %d enrollment(s) in %s", n, time.Since(start).Round(time.Millisecond))
+}
@@ -15,11 +15,12 @@ func main() {
 	}
 
 	// Initialize DBs
-	config.InitDB()
+	stores := config.MustConnect(config.MustLoad())
+	db := stores.Postgres
 
 	// 1. Insert Student
 	studentID := "2024CS123"
-	_, err := config.PostgresDB.Exec(`
+	_, err := db.Exec(`
 		INSERT INTO STUDENT (student_id, s_first_name, s_last_name, s_email, s_phone_no, semester, year_of_joining, dept_id)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (student_id) DO NOTHING
@@ -34,7 +35,7 @@ func main() {
 	// Enroll in CS101, CS102
 	courses := []string{"CS101", "CS102"}
 	for _, cid := range courses {
-		_, err := config.PostgresDB.Exec(`
+		_, err := db.Exec(`
 			INSERT INTO ENROLLS_IN (student_id, course_id, status)
 			VALUES ($1, $2, 'Enrolled')
 			ON CONFLICT (student_id, course_id) DO NOTHING
@@ -47,7 +48,7 @@ func main() {
 		}
 	}
 
-	// 3. Ensure Schedule Exists for these courses (Already in schema.sql, but good to be safe if schema wasn't run fully)
+	// 3. Ensure Schedule Exists for these courses (in database/sample_data.sql)
 	// We rely on existing seed data for schedules.
 
 	fmt.Println("Seeding complete!")
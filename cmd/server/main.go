@@ -1,10 +1,20 @@
 package main
 
 import (
+	"academ_aide/internal/app"
 	"academ_aide/internal/config"
-	"academ_aide/internal/handlers"
+	"academ_aide/internal/health"
+	"academ_aide/internal/logging"
+	"academ_aide/internal/metrics"
 	"academ_aide/internal/middleware"
-	"log"
+	"academ_aide/internal/migrate"
+	"academ_aide/internal/tracing"
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -13,51 +23,120 @@ import (
 func main() {
 	// Load env
 	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: No .env file found")
+		slog.Warn("No .env file found")
 	}
 
-	// Initialize DBs
-	config.InitDB()
+	// Load and validate configuration; refuse to start misconfigured
+	cfg := config.MustLoad()
+	logging.Setup(cfg.Log)
 
-	// Setup Router
-	r := gin.Default()
+	// SIGINT or SIGTERM stops the jobs and drains the server; a second one kills it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Traces go to TRACING_EXPORTER; buffered spans are flushed on exit
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Tracing setup failed", err)
+	}
+
+	// Initialize DBs and wire the app once. A store that is down does not stop the
+	// server: /readyz reports it until it is back.
+	stores := config.MustOpen(cfg)
+	if cfg.Jobs.AutoMigrate {
+		migrateOnStart(stores)
+	}
+	a := app.New(cfg, stores)
+	svc, h := &a.Services, &a.Handlers
+	logReadiness(a)
+
+	// Mongo indexes (idempotent); a MongoDB that is down does not hold up the start
+	indexCtx, cancelIndexes := context.WithTimeout(ctx, indexTimeout)
+	if err := svc.Threads.EnsureIndexes(indexCtx); err != nil {
+		slog.Warn("Creating chat indexes failed", "error", err)
+	}
+	if err := svc.Safety.EnsureIndexes(indexCtx); err != nil {
+		slog.Warn("Creating safety incident indexes failed", "error", err)
+	}
+	cancelIndexes()
+
+	// Background Jobs (an interval of 0 disables a job)
+	var jobs []<-chan struct{}
+	if interval := cfg.Jobs.RiskScoringInterval; interval > 0 {
+		jobs = append(jobs, svc.Risk.StartScheduler(ctx, interval))
+	}
+	if interval := cfg.Jobs.AlertCheckInterval; interval > 0 {
+		jobs = append(jobs, svc.Alerts.StartScheduler(ctx, interval))
+	}
+
+	// Setup Router: every request gets a trace, an ID and one log line, and panics become 500s
+	if cfg.Production() {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	r := gin.New()
+	r.Use(middleware.Tracing(cfg.Tracing.ServiceName), middleware.RequestID(), middleware.RequestLogger(), middleware.Metrics(), middleware.Recovery())
 
 	// Apply CORS Middleware
 	r.Use(middleware.CORSMiddleware())
 
+	// Every protected route checks tokens against the configured secret
+	auth := middleware.AuthMiddleware(cfg.Auth.JWTSecret)
+
+	// Request deadlines: routes that wait for the model get the longer one
+	short := middleware.Timeout(cfg.HTTP.RequestTimeout)
+	long := middleware.Timeout(cfg.HTTP.ModelRequestTimeout)
+
+	// Probes and metrics: no auth or deadline; the readiness checks bound themselves
+	r.GET("/healthz", h.Health.Live)
+	r.GET("/readyz", h.Health.Ready)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// Routes
-	r.POST("/login", handlers.LoginHandler)
+	r.POST("/login", short, h.Auth.Login)
 
 	studentGroup := r.Group("/student")
-	studentGroup.Use(middleware.AuthMiddleware())
+	studentGroup.Use(short, auth)
 	{
-		studentGroup.GET("/profile", handlers.GetStudentProfile)
-		studentGroup.GET("/timetable", handlers.GetStudentTimetable)
-		studentGroup.GET("/resources", handlers.GetStudentResources)
-		studentGroup.GET("/announcements", handlers.GetAnnouncements)
-		studentGroup.GET("/courses", handlers.GetStudentCourses)
-		studentGroup.GET("/teachers", handlers.GetTeachers)
+		studentGroup.GET("/profile", h.Student.GetProfile)
+		studentGroup.GET("/timetable", h.Student.GetTimetable)
+		studentGroup.GET("/resources", h.Student.GetResources)
+		studentGroup.GET("/announcements", h.Student.GetAnnouncements)
+		studentGroup.GET("/courses", h.Student.GetCourses)
+		studentGroup.GET("/teachers", h.Student.GetTeachers)
+		studentGroup.GET("/grades", h.Student.GetGrades)
+		studentGroup.POST("/questions", h.Student.AskQuestion)
+		studentGroup.GET("/questions", h.Student.GetMyQuestions)
 	}
 
 	chatGroup := r.Group("/chat")
-	chatGroup.Use(middleware.AuthMiddleware())
+	chatGroup.Use(auth)
+	chatGroup.POST("/message", long, h.Chat.SendMessage)
+	chatData := chatGroup.Group("", short)
 	{
-		chatGroup.POST("/message", handlers.ChatHandler)
-		chatGroup.DELETE("/history", handlers.ClearChatHandler)
+		chatData.GET("/agents", h.Chat.ListAgents)
+		chatData.DELETE("/history", h.Chat.ClearHistory)
+
+		// Conversation threads
+		chatData.GET("/threads", h.Chat.ListThreads)
+		chatData.POST("/threads", h.Chat.CreateThread)
+		chatData.PUT("/threads/:id", h.Chat.RenameThread)
+		chatData.DELETE("/threads/:id", h.Chat.DeleteThread)
+		chatData.GET("/threads/:id/messages", h.Chat.GetThreadMessages)
+		chatData.DELETE("/threads/:id/messages", h.Chat.ClearThread)
+		chatData.POST("/messages/:id/feedback", h.Chat.SubmitFeedback)
 	}
 
 	// OAuth Routes
-	r.GET("/auth/google/login", handlers.GoogleLogin)
-	r.GET("/auth/google/callback", handlers.GoogleCallback)
-	r.POST("/auth/complete-registration", handlers.CompleteRegistration)
+	r.GET("/auth/google/login", short, h.Auth.GoogleLogin)
+	r.GET("/auth/google/callback", short, h.Auth.GoogleCallback)
+	r.POST("/auth/complete-registration", short, h.Auth.CompleteRegistration)
 
 	// Feature: AI Quizzes
-	r.POST("/quiz/generate", middleware.AuthMiddleware(), handlers.GenerateQuiz)
+	r.POST("/quiz/generate", long, auth, h.Quiz.GenerateQuiz)
 
 	// Feature: AI Academic Intelligence
-	aiHandler := handlers.NewAIHandler()
-	aiGroup := r.Group("/ai")
-	// aiGroup.Use(middleware.AuthMiddleware()) // Optional: Enable auth if needed
+	aiHandler := h.AI
+	aiGroup := r.Group("/ai", long, auth)
 	{
 		aiGroup.GET("/insights", aiHandler.GetInsights)
 		aiGroup.POST("/what-if", aiHandler.CalculateWhatIf)
@@ -65,24 +144,161 @@ func main() {
 	}
 
 	// Feature: Teacher Dashboard
-	teacherHandler := handlers.NewTeacherHandler()
+	teacherHandler := h.Teacher
 	teacherGroup := r.Group("/teacher")
-	teacherGroup.Use(middleware.AuthMiddleware())
+	teacherGroup.Use(short, auth)
 	teacherGroup.Use(middleware.RoleMiddleware("teacher"))
 	{
 		teacherGroup.GET("/class-health", teacherHandler.GetClassHealth)
 		teacherGroup.GET("/at-risk", teacherHandler.GetAtRiskStudents)
 		teacherGroup.GET("/alerts", teacherHandler.GetAlerts)
+		teacherGroup.POST("/alerts/read-all", teacherHandler.MarkAllAlertsRead)
+		teacherGroup.POST("/alerts/:id/read", teacherHandler.MarkAlertRead)
+		teacherGroup.POST("/alerts/:id/dismiss", teacherHandler.DismissAlert)
 		teacherGroup.GET("/courses", teacherHandler.GetMyCourses)
 		teacherGroup.GET("/students", teacherHandler.GetEnrolledStudents)
 		teacherGroup.GET("/student-details", teacherHandler.GetStudentDetails)
 		teacherGroup.POST("/announce", teacherHandler.PostAnnouncement)
 		teacherGroup.GET("/profile", teacherHandler.GetProfile)
+
+		// Grade entry and publication
+		teacherGroup.GET("/courses/:course_id/assessment-scheme", teacherHandler.GetAssessmentScheme)
+		teacherGroup.PUT("/courses/:course_id/assessment-scheme", teacherHandler.SetAssessmentScheme)
+		teacherGroup.PUT("/courses/:course_id/components/:component_id/marks", teacherHandler.EnterMarks)
+		teacherGroup.GET("/courses/:course_id/gradebook", teacherHandler.GetGradebook)
+		teacherGroup.POST("/courses/:course_id/grades/publish", teacherHandler.PublishGrades)
+		teacherGroup.POST("/courses/:course_id/grades/unpublish", teacherHandler.UnpublishGrades)
+		teacherGroup.GET("/courses/:course_id/grade-audit", teacherHandler.GetGradeAudit)
+
+		// Alert producer inputs
+		teacherGroup.POST("/courses/:course_id/attendance", teacherHandler.RecordAttendance)
+		teacherGroup.GET("/courses/:course_id/questions", teacherHandler.GetQuestions)
+		teacherGroup.POST("/questions/:id/answer", teacherHandler.AnswerQuestion)
+
+		// Answer quality
+		teacherGroup.GET("/feedback-report", teacherHandler.GetFeedbackReport)
+
+		// Course personas
+		teacherGroup.GET("/courses/:course_id/personas", teacherHandler.GetCoursePersonas)
+		teacherGroup.POST("/courses/:course_id/personas", teacherHandler.CreateCoursePersona)
+		teacherGroup.PUT("/courses/:course_id/personas/:id", teacherHandler.UpdateCoursePersona)
+	}
+
+	// Feature: Confidential Wellbeing Alerts
+	counsellorHandler := h.Counsellor
+	counsellorGroup := r.Group("/counsellor")
+	counsellorGroup.Use(short, auth)
+	counsellorGroup.Use(middleware.RoleMiddleware("counsellor"))
+	{
+		counsellorGroup.GET("/alerts", counsellorHandler.GetWellbeingAlerts)
+		counsellorGroup.GET("/alerts/:id", counsellorHandler.GetWellbeingAlert)
+		counsellorGroup.PUT("/alerts/:id", counsellorHandler.UpdateWellbeingAlert)
+		counsellorGroup.GET("/students/:id/mood", counsellorHandler.GetStudentMood)
+	}
+
+	// Feature: Admin Master Data Management
+	adminHandler := h.Admin
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(short, auth)
+	adminGroup.Use(middleware.RoleMiddleware("admin"))
+	{
+		adminGroup.GET("/departments", adminHandler.ListDepartments)
+		adminGroup.POST("/departments", adminHandler.CreateDepartment)
+		adminGroup.PUT("/departments/:id", adminHandler.UpdateDepartment)
+		adminGroup.DELETE("/departments/:id", adminHandler.DeleteDepartment)
+
+		adminGroup.GET("/faculty", adminHandler.ListFaculty)
+		adminGroup.POST("/faculty", adminHandler.CreateFaculty)
+		adminGroup.PUT("/faculty/:id", adminHandler.UpdateFaculty)
+		adminGroup.DELETE("/faculty/:id", adminHandler.DeleteFaculty)
+
+		adminGroup.GET("/courses", adminHandler.ListCourses)
+		adminGroup.POST("/courses", adminHandler.CreateCourse)
+		adminGroup.PUT("/courses/:id", adminHandler.UpdateCourse)
+		adminGroup.DELETE("/courses/:id", adminHandler.DeleteCourse)
+
+		adminGroup.GET("/sections", adminHandler.ListSections)
+		adminGroup.POST("/sections", adminHandler.CreateSection)
+		adminGroup.PUT("/sections/:name", adminHandler.UpdateSection)
+		adminGroup.DELETE("/sections/:name", adminHandler.DeleteSection)
+
+		adminGroup.GET("/teaches", adminHandler.ListTeaches)
+		adminGroup.POST("/teaches", adminHandler.CreateTeaches)
+		adminGroup.DELETE("/teaches", adminHandler.DeleteTeaches)
+
+		adminGroup.GET("/syllabus-units", adminHandler.ListSyllabusUnits)
+		adminGroup.POST("/syllabus-units", adminHandler.CreateSyllabusUnit)
+		adminGroup.PUT("/syllabus-units/:id", adminHandler.UpdateSyllabusUnit)
+		adminGroup.DELETE("/syllabus-units/:id", adminHandler.DeleteSyllabusUnit)
+
+		adminGroup.GET("/schedules", adminHandler.ListSchedules)
+		adminGroup.POST("/schedules", adminHandler.CreateSchedule)
+		adminGroup.PUT("/schedules/:id", adminHandler.UpdateSchedule)
+		adminGroup.DELETE("/schedules/:id", adminHandler.DeleteSchedule)
+
+		adminGroup.GET("/audit-log", adminHandler.GetAuditLog)
+		adminGroup.GET("/feedback-report", adminHandler.GetFeedbackReport)
+		adminGroup.GET("/safety-incidents", adminHandler.GetSafetyIncidents)
+		adminGroup.GET("/wellbeing-access-log", adminHandler.GetWellbeingAccessLog)
+
+		adminGroup.GET("/personas", adminHandler.ListPersonas)
+		adminGroup.POST("/personas", adminHandler.CreatePersona)
+		adminGroup.PUT("/personas/:id", adminHandler.UpdatePersona)
+		adminGroup.GET("/personas/:id/versions", adminHandler.GetPersonaVersions)
+
+		adminGroup.POST("/import/:dataset", adminHandler.ImportDataset)
+		adminGroup.GET("/export/:dataset", adminHandler.ExportDataset)
+	}
+
+	// Start Server, until a signal drains it
+	if err := serve(ctx, newServer(cfg.HTTP, r), cfg.HTTP); err != nil {
+		fatal("Server start failed", err)
+	}
+	stop()
+
+	// Let the jobs finish their current run, then close every client and flush the
+	// last spans
+	awaitJobs(jobs, cfg.HTTP.ShutdownTimeout)
+	closeCtx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	a.Close(closeCtx)
+	if err := shutdownTracing(closeCtx); err != nil {
+		slog.Warn("Flushing traces failed", "error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// indexTimeout bounds creating the MongoDB indexes at startup.
+const indexTimeout = 10 * time.Second
+
+// closeTimeout bounds closing the database clients and flushing traces at shutdown.
+const closeTimeout = 10 * time.Second
+
+// migrateOnStart applies pending schema migrations, refusing to start on failure.
+func migrateOnStart(stores *config.Stores) {
+	m, err := migrate.New(stores.Postgres)
+	if err != nil {
+		fatal("Loading migrations failed", err)
+	}
+	applied, err := m.Up(context.Background())
+	if err != nil {
+		fatal("Schema migration failed", err)
 	}
+	slog.Info("Schema up to date", "applied", len(applied))
+}
 
-	// Start Server
-	log.Println("Server executing on :8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Server start failed: ", err)
+// logReadiness reports at startup which dependencies are down.
+func logReadiness(a *app.App) {
+	report := a.Health.Run(context.Background())
+	for name, res := range report.Checks {
+		if res.Status != health.StatusOK {
+			slog.Warn("Dependency unavailable at startup", "check", name, "error", res.Error)
+		}
 	}
+	slog.Info("Readiness", "status", report.Status)
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
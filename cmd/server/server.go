@@ -0,0 +1,80 @@
+package main
+
+import (
+	"academ_aide/internal/config"
+	"context"
+	"crypto/tls"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// newServer serves handler with the connection limits of cfg.
+func newServer(cfg config.HTTP, handler http.Handler) *http.Server {
+	srv := &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+	if cfg.TLS() {
+		srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return srv
+}
+
+// serve runs srv until ctx is done, then stops accepting connections and gives the
+// requests in flight, long model calls included, cfg.ShutdownTimeout to finish. Any
+// still running after that are cut off. It returns an error only if srv could not run.
+func serve(ctx context.Context, srv *http.Server, cfg config.HTTP) error {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		return err
+	}
+	failed := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.TLS() {
+			err = srv.ServeTLS(ln, cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			err = srv.Serve(ln)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			failed <- err
+		}
+	}()
+	slog.Info("Server listening", "addr", ln.Addr().String(), "tls", cfg.TLS())
+
+	select {
+	case err := <-failed:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down: draining requests", "grace_period", cfg.ShutdownTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(drainCtx); err != nil {
+		slog.Warn("Requests still running after the grace period were cut off", "error", err)
+		srv.Close()
+	}
+	return nil
+}
+
+// awaitJobs waits up to timeout for the stopped background jobs to end their runs.
+func awaitJobs(jobs []<-chan struct{}, timeout time.Duration) {
+	deadline := time.After(timeout)
+	for _, done := range jobs {
+		select {
+		case <-done:
+		case <-deadline:
+			slog.Warn("Background jobs still running at shutdown")
+			return
+		}
+	}
+}
@@ -43,41 +43,9 @@ def init_db():
         print(f"Error creating DB: {e}")
         return
 
-    # 2. Connect to new DB and run schemas
-    print(f"Connecting to {TARGET_DB}...")
-    try:
-        conn = psycopg2.connect(
-            host=DB_HOST,
-            port=DB_PORT,
-            user=DB_USER,
-            password=DB_PASS,
-            database=TARGET_DB
-        )
-        cur = conn.cursor()
-        
-        # List of files to run in order
-        files = [
-            "database/schema.sql",
-            "database/rag_setup.sql",
-            "database/02_remove_wallet_auth.sql",
-            "database/insert_real_data.sql"
-        ]
-        
-        for fpath in files:
-            if os.path.exists(fpath):
-                print(f"Running {fpath}...")
-                sql = read_sql_file(fpath)
-                cur.execute(sql)
-            else:
-                print(f"Warning: File {fpath} not found.")
-        
-        conn.commit()
-        print("✅ Database initialization complete!")
-        cur.close()
-        conn.close()
-        
-    except Exception as e:
-        print(f"Error running SQL scripts: {e}")
+    # 2. The schema is created by the Go migrations, not by this script
+    print("Next: apply the schema with `go run ./cmd/migrate up` (POSTGRES_DSN port 5435),")
+    print("then optionally load database/insert_real_data.sql.")
 
 if __name__ == "__main__":
     init_db()
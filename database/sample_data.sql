@@ -0,0 +1,55 @@
+-- Small sample dataset (the cmd/seed student enrolls in its CS101 and CS102).
+-- Load after the migrations: psql -d academ_aide -f database/sample_data.sql
+
+INSERT INTO FACULTY (faculty_id, f_first_name, f_last_name, f_email, f_phone_no) VALUES
+('F001', 'Alice', 'Smith', 'alice.smith@univ.edu', '555-0101'),
+('F002', 'Bob', 'Jones', 'bob.jones@univ.edu', '555-0102');
+
+-- Insert Departments
+INSERT INTO DEPARTMENT (dept_id, dept_name, hod_id) VALUES
+('CS', 'Computer Science', 'F001'),
+('EC', 'Electronics', 'F002');
+
+-- Insert Sections
+INSERT INTO SECTION (section_name, dept_id) VALUES
+('CS-A', 'CS'),
+('CS-B', 'CS'),
+('EC-A', 'EC');
+
+-- Insert Students
+INSERT INTO STUDENT (student_id, s_first_name, s_last_name, s_email, s_phone_no, semester, year_of_joining, dept_id) VALUES
+('S1001', 'John', 'Doe', 'john.doe@student.univ.edu', '555-1001', 5, 2023, 'CS'),
+('S1002', 'Jane', 'Roe', 'jane.roe@student.univ.edu', '555-1002', 3, 2024, 'CS');
+
+-- Insert Courses
+INSERT INTO COURSE (course_id, title, credits, dept_id, description) VALUES
+('CS101', 'Intro to Programming', 4, 'CS', 'Fundamental concepts of programming using C++. Covers loops, logic, functions, and basic data structures.'),
+('CS102', 'Data Structures', 4, 'CS', 'Advanced storage and retrieval of data. Topics include arrays, linked lists, trees, graphs, and sorting algorithms.'),
+('CS103', 'Database Systems', 3, 'CS', 'Design and implementation of relational databases. SQL, normalization, indexing, and transaction management.');
+
+-- Insert Teaches
+INSERT INTO TEACHES (faculty_id, course_id, section_name) VALUES
+('F001', 'CS101', 'CS-A'),
+('F002', 'CS102', 'CS-B');
+
+-- Insert Syllabus
+INSERT INTO SYLLABUS_UNIT (unit_no, topic, course_id) VALUES
+(1, 'Introduction to C++', 'CS101'),
+(2, 'Loops and Logic', 'CS101'),
+(1, 'Arrays and Linked Lists', 'CS102');
+
+-- Insert Resources
+INSERT INTO RESOURCE (title, description, type, course_id) VALUES
+('C++ Basics', 'Introductory slides', 'PDF', 'CS101'),
+('Sorting Algos', 'Video lecture on sorting', 'Video', 'CS102');
+
+-- Insert Enrollment
+INSERT INTO ENROLLS_IN (student_id, course_id, grade, status) VALUES
+('S1001', 'CS101', 'A', 'Completed'),
+('S1001', 'CS102', NULL, 'Enrolled'),
+('S1002', 'CS101', NULL, 'Enrolled');
+
+-- Insert Schedule
+INSERT INTO SCHEDULE (course_id, section_name, day_of_week, start_time, end_time, room_number) VALUES
+('CS101', 'CS-A', 'Monday', '09:00:00', '10:00:00', 'Room-101'),
+('CS102', 'CS-B', 'Tuesday', '11:00:00', '12:30:00', 'Room-102');
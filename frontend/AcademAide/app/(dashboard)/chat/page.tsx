@@ -29,6 +29,7 @@ function ChatContent() {
     ])
     const [input, setInput] = useState("")
     const [isLoading, setIsLoading] = useState(false)
+    const [conversationId, setConversationId] = useState<string | undefined>(undefined)
 
     const [selectedAgent, setSelectedAgent] = useState<AgentType>("general")
     const messagesEndRef = useRef<HTMLDivElement>(null)
@@ -99,13 +100,15 @@ function ChatContent() {
                     faculty_id: role === "teacher" ? userId : undefined,
                     role: role,
                     message: newMessage.content,
-                    agent_id: selectedAgent
+                    agent_id: selectedAgent,
+                    conversation_id: conversationId
                 }),
             })
 
             if (!res.ok) throw new Error("Processing failed")
 
             const data = await res.json()
+            if (data.conversation_id) setConversationId(data.conversation_id)
 
             const responseMessage: Message = {
                 id: (Date.now() + 1).toString(),
@@ -144,6 +147,7 @@ function ChatContent() {
 
             if (res.ok) {
                 // Reset to initial state
+                setConversationId(undefined)
                 setMessages([{
                     id: "1",
                     role: "assistant",
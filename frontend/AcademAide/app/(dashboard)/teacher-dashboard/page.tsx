@@ -26,7 +26,7 @@ export default function TeacherDashboard() {
 
     const [health, setHealth] = useState<ClassHealth | null>(null)
     const [atRisk, setAtRisk] = useState<AtRiskStats | null>(null)
-    const [alerts, setAlerts] = useState<string[]>([])
+    const [alerts, setAlerts] = useState<{ alert_id: number; severity: string; title: string; message: string }[]>([])
     const [loading, setLoading] = useState(true)
 
     const [selectedStudent, setSelectedStudent] = useState<any | null>(null)
@@ -195,10 +195,10 @@ export default function TeacherDashboard() {
                     </CardHeader>
                     <CardContent>
                         <ul className="space-y-3">
-                            {alerts.map((alert, idx) => (
-                                <li key={idx} className="flex gap-2 p-2 rounded bg-amber-50 dark:bg-amber-900/10 text-sm text-amber-900 dark:text-amber-200 border border-amber-100 dark:border-amber-800/50">
+                            {alerts.map((alert) => (
+                                <li key={alert.alert_id} className="flex gap-2 p-2 rounded bg-amber-50 dark:bg-amber-900/10 text-sm text-amber-900 dark:text-amber-200 border border-amber-100 dark:border-amber-800/50">
                                     <AlertTriangle className="h-4 w-4 shrink-0 mt-0.5" />
-                                    <span>{alert}</span>
+                                    <span><strong>{alert.title}</strong> {alert.message}</span>
                                 </li>
                             ))}
                             {alerts.length === 0 && <p className="text-sm text-muted-foreground">No active alerts.</p>}
@@ -1,9 +1,12 @@
 "use client"
 
-import { Bot, Brain, Code, BookOpen, ListTodo, Zap, LucideIcon, GraduationCap } from "lucide-react"
+import { useEffect, useState } from "react"
+import { Bot, Brain, Code, BookOpen, ListTodo, Zap, LucideIcon, GraduationCap, Sparkles } from "lucide-react"
+import Cookies from "js-cookie"
 import { cn } from "@/lib/utils"
 
-export type AgentType = "general" | "socratic" | "code_reviewer" | "research" | "exam" | "motivational" | "teacher"
+// Agent IDs come from GET /chat/agents; custom and course personas have their own IDs
+export type AgentType = string
 
 
 
@@ -73,8 +76,40 @@ export interface AgentSelectorProps {
     userRole?: string
 }
 
+interface AvailableAgent {
+    id: string
+    name: string
+    description: string
+    course_id?: string
+}
+
+// Built-in agents keep their icon and color; others get a generic style
+function toOption(agent: AvailableAgent): AgentOption {
+    const known = agents.find(a => a.id === agent.id)
+    return {
+        id: agent.id,
+        label: agent.course_id ? `${agent.name} (${agent.course_id})` : agent.name,
+        icon: known?.icon ?? Sparkles,
+        description: agent.description || known?.description || "",
+        color: known?.color ?? "text-indigo-500 bg-indigo-50 dark:bg-indigo-950/30",
+    }
+}
+
 export function AgentSelector({ selectedAgent, onSelect, userRole = "student" }: AgentSelectorProps) {
-    const filteredAgents = agents.filter(agent => {
+    const [available, setAvailable] = useState<AgentOption[] | null>(null)
+
+    useEffect(() => {
+        const token = Cookies.get("token")
+        if (!token) return
+        fetch("http://localhost:8080/chat/agents", {
+            headers: { "Authorization": `Bearer ${token}` }
+        })
+            .then(res => (res.ok ? res.json() : Promise.reject(res.status)))
+            .then((data: AvailableAgent[]) => setAvailable(data.map(toOption)))
+            .catch(() => setAvailable(null)) // Fall back to the built-in list
+    }, [userRole])
+
+    const filteredAgents = available ?? agents.filter(agent => {
         if (userRole === "teacher") {
             return agent.id === "teacher"
         } else {
@@ -1,18 +1,34 @@
 package ai
 
 import (
+	"academ_aide/internal/config"
+	"academ_aide/internal/metrics"
+	"academ_aide/internal/tracing"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type Embedder struct {
-	Model string
+	Model   string
+	BaseURL string
+	Timeout time.Duration // Per call
+	Breaker *Breaker
 }
 
-func NewEmbedder() *Embedder {
-	return &Embedder{Model: "nomic-embed-text"}
+// NewEmbedder calls Ollama at cfg.OllamaURL with cfg.EmbeddingTimeout. It has its own
+// circuit breaker, so chat can still answer while embeddings are down.
+func NewEmbedder(cfg config.LLM) *Embedder {
+	return &Embedder{
+		Model:   cfg.EmbeddingModel,
+		BaseURL: cfg.OllamaURL,
+		Timeout: cfg.EmbeddingTimeout,
+		Breaker: breakerFor("embeddings "+cfg.OllamaURL, cfg),
+	}
 }
 
 type EmbeddingRequest struct {
@@ -24,7 +40,15 @@ type EmbeddingResponse struct {
 	Embedding []float64 `json:"embedding"` // Ollama returns float64
 }
 
-func (e *Embedder) GenerateEmbedding(text string) ([]float32, error) {
+// GenerateEmbedding embeds text, giving up when ctx is done or after e.Timeout.
+func (e *Embedder) GenerateEmbedding(ctx context.Context, text string) (embedding []float32, err error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "embedding", tracing.Model.String(e.Model))
+	defer func() {
+		metrics.EmbeddingDuration.WithLabelValues(e.Model, metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+		tracing.End(span, err)
+	}()
+
 	reqBody := EmbeddingRequest{
 		Model:  e.Model,
 		Prompt: text,
@@ -35,15 +59,41 @@ func (e *Embedder) GenerateEmbedding(text string) ([]float32, error) {
 		return nil, err
 	}
 
-	resp, err := http.Post("http://localhost:11434/api/embeddings", "application/json", bytes.NewBuffer(jsonData))
+	if e.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.BaseURL+"/api/embeddings", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if ok, wait := e.Breaker.Allow(); !ok {
+		return nil, &UnavailableError{RetryAfter: wait, Cause: fmt.Errorf("embedding circuit open")}
+	}
+	resp, err := HTTPClient.Do(req)
+	if errors.Is(err, context.Canceled) {
+		// The caller went away; that says nothing about the backend
+		e.Breaker.release()
+		return nil, err
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to call ollama: %w", err)
+		e.Breaker.Failure()
+		return nil, &UnavailableError{RetryAfter: e.Breaker.Cooldown, Cause: fmt.Errorf("failed to call ollama: %w", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode >= 500 {
+			e.Breaker.Failure()
+		} else {
+			e.Breaker.release()
+		}
 		return nil, fmt.Errorf("ollama API returned status: %d", resp.StatusCode)
 	}
+	e.Breaker.Success()
 
 	var embeddingResp EmbeddingResponse
 	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
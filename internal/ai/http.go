@@ -0,0 +1,31 @@
+package ai
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// HTTPClient is shared by every call to a model, embedding or moderation endpoint, so
+// connections to a backend are pooled. Deadlines come from each call's context (see
+// LLM_TIMEOUT and EMBEDDING_TIMEOUT); Timeout only stops a call whose context has none.
+// Each call is traced as a child of the span in its context.
+var HTTPClient = &http.Client{
+	Timeout: 5 * time.Minute,
+	Transport: otelhttp.NewTransport(&http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          64,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+	}, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+		return r.Method + " " + r.URL.Path
+	})),
+}
@@ -0,0 +1,346 @@
+package ai
+
+import (
+	"academ_aide/internal/config"
+	"academ_aide/internal/metrics"
+	"academ_aide/internal/tracing"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Message is one turn of a chat in provider-neutral form.
+type Message struct {
+	Role       string // "system", "user", "assistant" or "tool"
+	Content    string
+	ToolCalls  []ToolCall // Set on assistant turns that request tools
+	ToolCallID string     // Set on tool turns: the call being answered
+	ToolName   string     // Set on tool turns
+
+	PromptTokens     int // Reported by the provider on assistant turns
+	CompletionTokens int
+}
+
+// ToolCall is a model's request to run a tool with JSON arguments.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// Tool describes a function the model may call. Parameters is a JSON Schema object.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// ChatRequest is a conversation, the tools on offer and optional per-call settings.
+type ChatRequest struct {
+	Messages    []Message
+	Tools       []Tool
+	Model       string   // Overrides the client's model when set
+	Temperature *float64 // Provider default when nil
+	JSON        bool     // Ask for a JSON object reply
+	Agent       string   // Who is asking (a persona ID, "intent", ...), for metrics
+}
+
+// ChatClient sends a conversation to a chat model and returns the assistant's next turn.
+type ChatClient interface {
+	Chat(ctx context.Context, req ChatRequest) (*Message, error)
+}
+
+// NewChatClient builds the client for cfg.Provider: "ollama" (OllamaURL) or "openai"
+// for any OpenAI-compatible endpoint (BaseURL, APIKey), using cfg.Model. Calls go
+// through a ResilientClient.
+func NewChatClient(cfg config.LLM) ChatClient {
+	return NewChatClientWithModel(cfg, cfg.Model)
+}
+
+// NewChatClientWithModel is NewChatClient with a different model on the same
+// provider, e.g. a small model for classification.
+func NewChatClientWithModel(cfg config.LLM, model string) ChatClient {
+	var client ChatClient
+	switch cfg.Provider {
+	case "openai":
+		client = &OpenAIClient{BaseURL: cfg.BaseURL, APIKey: cfg.APIKey, Model: model}
+	default:
+		client = &OllamaClient{BaseURL: cfg.OllamaURL, Model: model}
+	}
+	return NewResilientClient(client, chatBackend(cfg), cfg)
+}
+
+// chatBackend names the chat backend for its circuit breaker.
+func chatBackend(cfg config.LLM) string {
+	if cfg.Provider == "openai" {
+		return "openai " + cfg.BaseURL
+	}
+	return "ollama " + cfg.OllamaURL
+}
+
+type functionSpec struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+type toolSpec struct {
+	Type     string       `json:"type"` // Always "function"
+	Function functionSpec `json:"function"`
+}
+
+func toolSpecs(tools []Tool) []toolSpec {
+	if len(tools) == 0 {
+		return nil
+	}
+	specs := make([]toolSpec, len(tools))
+	for i, t := range tools {
+		specs[i] = toolSpec{Type: "function", Function: functionSpec{Name: t.Name, Description: t.Description, Parameters: t.Parameters}}
+	}
+	return specs
+}
+
+// chatCall is one chat model call, traced and measured.
+type chatCall struct {
+	agent, model string
+	start        time.Time
+	span         trace.Span
+}
+
+func startChat(ctx context.Context, provider, agent, model string) (context.Context, *chatCall) {
+	if agent == "" {
+		agent = "other"
+	}
+	ctx, span := tracing.Start(ctx, "llm.chat",
+		tracing.Provider.String(provider), tracing.AgentID.String(agent), tracing.Model.String(model))
+	return ctx, &chatCall{agent: agent, model: model, start: time.Now(), span: span}
+}
+
+// finish records the call's latency and, if it succeeded, the tokens the model reported.
+func (c *chatCall) finish(err error, promptTokens, completionTokens, toolCalls int) {
+	metrics.LLMRequestDuration.WithLabelValues(c.agent, c.model, metrics.Outcome(err)).Observe(time.Since(c.start).Seconds())
+	if err == nil {
+		metrics.LLMTokens.WithLabelValues(c.agent, c.model, "prompt").Add(float64(promptTokens))
+		metrics.LLMTokens.WithLabelValues(c.agent, c.model, "completion").Add(float64(completionTokens))
+		c.span.SetAttributes(tracing.PromptTokens.Int(promptTokens), tracing.CompletionTokens.Int(completionTokens),
+			tracing.ToolCalls.Int(toolCalls))
+	}
+	tracing.End(c.span, err)
+}
+
+// postJSON sends body to url and decodes a 200 response into out.
+func postJSON(ctx context.Context, url string, headers map[string]string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{URL: url, Code: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// --- Ollama (/api/chat) ---
+
+type OllamaClient struct {
+	BaseURL string
+	Model   string
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"` // A JSON object, not a string
+	} `json:"function"`
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaChatRequest struct {
+	Model    string                 `json:"model"`
+	Messages []ollamaMessage        `json:"messages"`
+	Tools    []toolSpec             `json:"tools,omitempty"`
+	Stream   bool                   `json:"stream"`
+	Format   string                 `json:"format,omitempty"` // "json" for JSON mode
+	Options  map[string]interface{} `json:"options,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+func (c *OllamaClient) Chat(ctx context.Context, cr ChatRequest) (*Message, error) {
+	req := ollamaChatRequest{Model: c.Model, Tools: toolSpecs(cr.Tools)}
+	if cr.Model != "" {
+		req.Model = cr.Model
+	}
+	if cr.Temperature != nil {
+		req.Options = map[string]interface{}{"temperature": *cr.Temperature}
+	}
+	if cr.JSON {
+		req.Format = "json"
+	}
+	for _, m := range cr.Messages {
+		om := ollamaMessage{Role: m.Role, Content: m.Content, ToolName: m.ToolName}
+		for _, tc := range m.ToolCalls {
+			var call ollamaToolCall
+			call.Function.Name = tc.Name
+			call.Function.Arguments = tc.Arguments
+			om.ToolCalls = append(om.ToolCalls, call)
+		}
+		req.Messages = append(req.Messages, om)
+	}
+
+	var resp ollamaChatResponse
+	ctx, call := startChat(ctx, "ollama", cr.Agent, req.Model)
+	err := postJSON(ctx, c.BaseURL+"/api/chat", nil, req, &resp)
+	call.finish(err, resp.PromptEvalCount, resp.EvalCount, len(resp.Message.ToolCalls))
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Message{Role: "assistant", Content: resp.Message.Content, PromptTokens: resp.PromptEvalCount, CompletionTokens: resp.EvalCount}
+	for i, tc := range resp.Message.ToolCalls {
+		args := tc.Function.Arguments
+		if len(args) == 0 || string(args) == "null" {
+			args = json.RawMessage("{}")
+		}
+		// Ollama does not assign call IDs; the index keeps them unique within the turn
+		out.ToolCalls = append(out.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      tc.Function.Name,
+			Arguments: args,
+		})
+	}
+	return out, nil
+}
+
+// --- OpenAI-compatible (/chat/completions) ---
+
+type OpenAIClient struct {
+	BaseURL string // e.g. https://api.openai.com/v1
+	APIKey  string
+	Model   string
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"` // JSON encoded as a string
+	} `json:"function"`
+}
+
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    *string          `json:"content"` // null on assistant turns that only call tools
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIChatRequest struct {
+	Model       string          `json:"model"`
+	Messages    []openAIMessage `json:"messages"`
+	Tools       []toolSpec      `json:"tools,omitempty"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	Format      *responseFormat `json:"response_format,omitempty"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"` // "json_object"
+}
+
+type openAIChatResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage"`
+}
+
+func (c *OpenAIClient) Chat(ctx context.Context, cr ChatRequest) (*Message, error) {
+	req := openAIChatRequest{Model: c.Model, Tools: toolSpecs(cr.Tools), Temperature: cr.Temperature}
+	if cr.Model != "" {
+		req.Model = cr.Model
+	}
+	if cr.JSON {
+		req.Format = &responseFormat{Type: "json_object"}
+	}
+	for _, m := range cr.Messages {
+		content := m.Content
+		om := openAIMessage{Role: m.Role, Content: &content, ToolCallID: m.ToolCallID}
+		for _, tc := range m.ToolCalls {
+			call := openAIToolCall{ID: tc.ID, Type: "function"}
+			call.Function.Name = tc.Name
+			call.Function.Arguments = string(tc.Arguments)
+			om.ToolCalls = append(om.ToolCalls, call)
+		}
+		req.Messages = append(req.Messages, om)
+	}
+
+	headers := map[string]string{}
+	if c.APIKey != "" {
+		headers["Authorization"] = "Bearer " + c.APIKey
+	}
+	var resp openAIChatResponse
+	ctx, call := startChat(ctx, "openai", cr.Agent, req.Model)
+	err := postJSON(ctx, c.BaseURL+"/chat/completions", headers, req, &resp)
+	if err == nil && len(resp.Choices) == 0 {
+		err = fmt.Errorf("chat completion returned no choices")
+	}
+	if err != nil {
+		call.finish(err, 0, 0, 0)
+		return nil, err
+	}
+
+	msg := resp.Choices[0].Message
+	call.finish(nil, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, len(msg.ToolCalls))
+	out := &Message{Role: "assistant", PromptTokens: resp.Usage.PromptTokens, CompletionTokens: resp.Usage.CompletionTokens}
+	if msg.Content != nil {
+		out.Content = *msg.Content
+	}
+	for _, tc := range msg.ToolCalls {
+		args := json.RawMessage(tc.Function.Arguments)
+		if !json.Valid(args) {
+			args = json.RawMessage("{}")
+		}
+		out.ToolCalls = append(out.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: args})
+	}
+	return out, nil
+}
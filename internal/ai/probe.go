@@ -0,0 +1,96 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Pinger is a model client that can check its backend without generating anything.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Ping checks client's backend. An open circuit breaker counts as down without a call;
+// clients that cannot be checked are assumed up.
+func Ping(ctx context.Context, client ChatClient) error {
+	if rc, ok := client.(*ResilientClient); ok {
+		if rc.Breaker.Open() {
+			return fmt.Errorf("%w: circuit open", ErrUnavailable)
+		}
+		client = rc.Client
+	}
+	if p, ok := client.(Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// Ping checks that Ollama answers and has the model pulled.
+func (c *OllamaClient) Ping(ctx context.Context) error {
+	return ollamaHasModel(ctx, c.BaseURL, c.Model)
+}
+
+// Ping checks that the endpoint answers and accepts the API key.
+func (c *OpenAIClient) Ping(ctx context.Context) error {
+	headers := map[string]string{}
+	if c.APIKey != "" {
+		headers["Authorization"] = "Bearer " + c.APIKey
+	}
+	return getJSON(ctx, c.BaseURL+"/models", headers, &struct{}{})
+}
+
+// Ping checks that Ollama answers and has the embedding model pulled.
+func (e *Embedder) Ping(ctx context.Context) error {
+	if e.Breaker != nil && e.Breaker.Open() {
+		return fmt.Errorf("%w: circuit open", ErrUnavailable)
+	}
+	return ollamaHasModel(ctx, e.BaseURL, e.Model)
+}
+
+// ollamaHasModel lists the models pulled into the Ollama at baseURL and looks for model.
+func ollamaHasModel(ctx context.Context, baseURL, model string) error {
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := getJSON(ctx, baseURL+"/api/tags", nil, &tags); err != nil {
+		return err
+	}
+	for _, m := range tags.Models {
+		if m.Name == model || (!strings.Contains(model, ":") && m.Name == model+":latest") {
+			return nil
+		}
+	}
+	return fmt.Errorf("model %q is not pulled", model)
+}
+
+// getJSON fetches url and decodes a 200 response into out.
+func getJSON(ctx context.Context, url string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("calling %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{URL: url, Code: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package ai
+
+import (
+	"academ_aide/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUnavailable matches every error returned while the model backend is down, timing
+// out or answering with nothing. Use errors.As with *UnavailableError for the retry hint.
+var ErrUnavailable = errors.New("model backend unavailable")
+
+// ErrEmptyResponse is the cause when the model returned neither text nor tool calls.
+var ErrEmptyResponse = errors.New("model returned an empty response")
+
+// UnavailableError reports a failed model call and when it is worth trying again.
+type UnavailableError struct {
+	RetryAfter time.Duration
+	Cause      error
+}
+
+func (e *UnavailableError) Error() string {
+	return fmt.Sprintf("%v: %v (retry after %s)", ErrUnavailable, e.Cause, e.RetryAfter)
+}
+
+func (e *UnavailableError) Unwrap() error { return e.Cause }
+
+func (e *UnavailableError) Is(target error) bool { return target == ErrUnavailable }
+
+// StatusError is a non-200 answer from a model endpoint.
+type StatusError struct {
+	URL  string
+	Code int
+	Body string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.URL, e.Code, e.Body)
+}
+
+// retryable reports whether a failed call may succeed if repeated: network errors,
+// timeouts, rate limits and server errors. Other 4xx answers are the request's fault.
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusTooManyRequests || se.Code == http.StatusRequestTimeout || se.Code >= 500
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// --- Circuit breaker ---
+
+// Breaker stops calls to a backend after Threshold consecutive failures. Once
+// Cooldown has passed one trial call is let through: success closes the breaker,
+// failure opens it again.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time // Zero while closed
+	trial    bool      // A half-open trial call is in flight
+
+	now func() time.Time // Clock; nil is time.Now
+}
+
+func (b *Breaker) clock() time.Time {
+	if b.now != nil {
+		return b.now()
+	}
+	return time.Now()
+}
+
+// Allow reports whether a call may go ahead, and if not, how long until it may.
+func (b *Breaker) Allow() (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return true, 0
+	}
+	if wait := b.Cooldown - b.clock().Sub(b.openedAt); wait > 0 {
+		return false, wait
+	}
+	if b.trial {
+		return false, time.Second
+	}
+	b.trial = true
+	return true, 0
+}
+
+// Success closes the breaker.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.openedAt, b.trial = 0, time.Time{}, false
+}
+
+// Failure counts a failed call and opens the breaker at the threshold, or again after
+// a failed trial.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.trial || b.failures >= b.Threshold {
+		if b.openedAt.IsZero() || b.trial {
+			slog.Warn("Model backend circuit opened", "failures", b.failures, "retry_in", b.Cooldown)
+		}
+		b.openedAt, b.trial = b.clock(), false
+	}
+}
+
+// Open reports whether calls are currently being refused.
+func (b *Breaker) Open() bool {
+	ok, _ := b.Allow()
+	if ok {
+		b.release()
+	}
+	return !ok
+}
+
+// release undoes an Allow that did not lead to a call.
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*Breaker{}
+)
+
+// breakerFor returns the breaker shared by every client of one backend, configured by
+// cfg.BreakerThreshold and cfg.BreakerCooldown.
+func breakerFor(backend string, cfg config.LLM) *Breaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	if b, ok := breakers[backend]; ok {
+		return b
+	}
+	b := &Breaker{
+		Threshold: cfg.BreakerThreshold,
+		Cooldown:  cfg.BreakerCooldown,
+	}
+	breakers[backend] = b
+	return b
+}
+
+// BackendDown reports whether client's backend breaker is open, so callers can skip
+// straight to their fallback. Clients without a breaker are never down.
+func BackendDown(client ChatClient) bool {
+	rc, ok := client.(*ResilientClient)
+	return ok && rc.Breaker.Open()
+}
+
+// --- Resilient client ---
+
+// ResilientClient wraps a ChatClient with a per-attempt timeout, retries with
+// exponential backoff and jitter, and a circuit breaker. Every failure it returns is
+// an *UnavailableError whose Cause is the last error, so a *StatusError or the
+// context's error can still be matched with errors.As and errors.Is.
+type ResilientClient struct {
+	Client  ChatClient
+	Timeout time.Duration // Per attempt
+	Retries int           // Attempts after the first
+	Backoff time.Duration // Before the first retry; doubles each time
+	Breaker *Breaker
+
+	sleep func(ctx context.Context, d time.Duration) error // Waits between attempts; nil uses a timer
+}
+
+// NewResilientClient wraps client with cfg.Timeout, cfg.Retries and cfg.RetryBackoff,
+// sharing the backend's breaker.
+func NewResilientClient(client ChatClient, backend string, cfg config.LLM) *ResilientClient {
+	return &ResilientClient{
+		Client:  client,
+		Timeout: cfg.Timeout,
+		Retries: cfg.Retries,
+		Backoff: cfg.RetryBackoff,
+		Breaker: breakerFor(backend, cfg),
+	}
+}
+
+func (c *ResilientClient) Chat(ctx context.Context, req ChatRequest) (*Message, error) {
+	var lastErr error
+	backoff := c.Backoff
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			if err := c.wait(ctx, wait); err != nil {
+				return nil, &UnavailableError{RetryAfter: backoff, Cause: err}
+			}
+			backoff *= 2
+		}
+		if ok, wait := c.Breaker.Allow(); !ok {
+			if lastErr == nil {
+				lastErr = errors.New("circuit open")
+			}
+			return nil, &UnavailableError{RetryAfter: wait, Cause: lastErr}
+		}
+
+		msg, err := c.attempt(ctx, req)
+		switch {
+		case err == nil && (msg.Content != "" || len(msg.ToolCalls) > 0):
+			c.Breaker.Success()
+			return msg, nil
+		case err == nil:
+			// The backend is up but the model said nothing; worth another try
+			c.Breaker.Success()
+			lastErr = ErrEmptyResponse
+		case !retryable(err) || ctx.Err() != nil:
+			// The caller gave up or the provider refused the request (bad key, unknown
+			// model): not the backend's health, and not worth repeating
+			c.Breaker.release()
+			return nil, &UnavailableError{RetryAfter: backoff, Cause: err}
+		default:
+			c.Breaker.Failure()
+			lastErr = err
+		}
+		slog.WarnContext(ctx, "Model call failed", "attempt", attempt+1, "attempts", c.Retries+1, "error", lastErr)
+	}
+
+	retryAfter := backoff
+	if ok, wait := c.Breaker.Allow(); !ok {
+		retryAfter = wait
+	} else {
+		c.Breaker.release()
+	}
+	return nil, &UnavailableError{RetryAfter: retryAfter, Cause: lastErr}
+}
+
+// wait sleeps for d, or until ctx is done.
+func (c *ResilientClient) wait(ctx context.Context, d time.Duration) error {
+	if c.sleep != nil {
+		return c.sleep(ctx, d)
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *ResilientClient) attempt(ctx context.Context, req ChatRequest) (*Message, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	return c.Client.Chat(ctx, req)
+}
@@ -0,0 +1,225 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// fakeClock is a settable time source for breakers.
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestBreaker(threshold int, cooldown time.Duration) (*Breaker, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)}
+	return &Breaker{Threshold: threshold, Cooldown: cooldown, now: clock.now}, clock
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	b, clock := newTestBreaker(3, time.Minute)
+
+	// Closed: failures below the threshold let calls through
+	b.Failure()
+	b.Failure()
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("breaker opened before the threshold")
+	}
+	b.release()
+
+	// Open at the threshold, refusing calls until the cooldown has passed
+	b.Failure()
+	if ok, wait := b.Allow(); ok || wait != time.Minute {
+		t.Fatalf("Allow() = %v, %s; want refused for 1m", ok, wait)
+	}
+	clock.advance(40 * time.Second)
+	if ok, wait := b.Allow(); ok || wait != 20*time.Second {
+		t.Fatalf("Allow() = %v, %s; want refused for 20s", ok, wait)
+	}
+	if !b.Open() {
+		t.Error("Open() = false while open")
+	}
+
+	// Half-open: one trial call, everyone else waits
+	clock.advance(20 * time.Second)
+	if b.Open() {
+		t.Error("Open() = true once the cooldown passed")
+	}
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("trial call refused after the cooldown")
+	}
+	if ok, _ := b.Allow(); ok {
+		t.Fatal("second call allowed during the trial")
+	}
+
+	// A failed trial opens it again for a full cooldown
+	b.Failure()
+	if ok, wait := b.Allow(); ok || wait != time.Minute {
+		t.Fatalf("after a failed trial Allow() = %v, %s; want refused for 1m", ok, wait)
+	}
+
+	// A successful trial closes it and resets the count
+	clock.advance(time.Minute)
+	if ok, _ := b.Allow(); !ok {
+		t.Fatal("trial call refused")
+	}
+	b.Success()
+	b.Failure()
+	if ok, _ := b.Allow(); !ok {
+		t.Error("breaker reopened on the first failure after closing")
+	}
+}
+
+// fakeChat answers with one scripted result per call, repeating the last.
+type fakeChat struct {
+	results []error // nil answers "ok"
+	empty   bool    // Answer nil errors with an empty message
+	calls   int
+}
+
+func (f *fakeChat) Chat(ctx context.Context, req ChatRequest) (*Message, error) {
+	i := f.calls
+	if i >= len(f.results) {
+		i = len(f.results) - 1
+	}
+	f.calls++
+	if err := f.results[i]; err != nil {
+		return nil, err
+	}
+	if f.empty {
+		return &Message{Role: "assistant"}, nil
+	}
+	return &Message{Role: "assistant", Content: "ok"}, nil
+}
+
+// testClient wraps chat with recorded, instant backoff waits.
+func testClient(chat ChatClient, retries, threshold int) (*ResilientClient, *[]time.Duration) {
+	var waits []time.Duration
+	b, _ := newTestBreaker(threshold, time.Minute)
+	return &ResilientClient{
+		Client:  chat,
+		Retries: retries,
+		Backoff: 100 * time.Millisecond,
+		Breaker: b,
+		sleep: func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return ctx.Err()
+		},
+	}, &waits
+}
+
+var errServer = &StatusError{URL: "http://llm", Code: http.StatusServiceUnavailable}
+
+func TestResilientClientRetriesWithBackoff(t *testing.T) {
+	chat := &fakeChat{results: []error{errServer, errServer, nil}}
+	c, waits := testClient(chat, 3, 10)
+
+	msg, err := c.Chat(context.Background(), ChatRequest{})
+	if err != nil || msg.Content != "ok" {
+		t.Fatalf("Chat() = %v, %v", msg, err)
+	}
+	if chat.calls != 3 {
+		t.Errorf("calls = %d, want 3", chat.calls)
+	}
+	// Each wait is the current backoff less up to half of it in jitter, doubling each time
+	if len(*waits) != 2 {
+		t.Fatalf("waits = %v, want 2", *waits)
+	}
+	for i, w := range *waits {
+		backoff := c.Backoff << i
+		if w < backoff/2 || w > backoff {
+			t.Errorf("wait %d = %s, want between %s and %s", i, w, backoff/2, backoff)
+		}
+	}
+	if c.Breaker.failures != 0 {
+		t.Errorf("failures = %d after a success, want 0", c.Breaker.failures)
+	}
+}
+
+func TestResilientClientFailures(t *testing.T) {
+	badRequest := &StatusError{URL: "http://llm", Code: http.StatusBadRequest}
+
+	tests := []struct {
+		name        string
+		chat        *fakeChat
+		retries     int
+		threshold   int
+		calls       int
+		unavailable bool
+		cause       error
+		open        bool
+	}{
+		{"gives up after the retries", &fakeChat{results: []error{errServer}}, 2, 10, 3, true, errServer, false},
+		{"client errors are not retried", &fakeChat{results: []error{badRequest}}, 2, 10, 1, true, badRequest, false},
+		{"empty answers are retried", &fakeChat{results: []error{nil}, empty: true}, 1, 10, 2, true, ErrEmptyResponse, false},
+		{"the breaker stops the retries", &fakeChat{results: []error{errServer}}, 5, 2, 2, true, errServer, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := testClient(tt.chat, tt.retries, tt.threshold)
+			_, err := c.Chat(context.Background(), ChatRequest{})
+			if tt.chat.calls != tt.calls {
+				t.Errorf("calls = %d, want %d", tt.chat.calls, tt.calls)
+			}
+			if errors.Is(err, ErrUnavailable) != tt.unavailable {
+				t.Errorf("err = %v, unavailable %v", err, tt.unavailable)
+			}
+			if !errors.Is(err, tt.cause) {
+				t.Errorf("err = %v, want cause %v", err, tt.cause)
+			}
+			if BackendDown(c) != tt.open {
+				t.Errorf("BackendDown = %v, want %v", BackendDown(c), tt.open)
+			}
+			var ue *UnavailableError
+			if tt.open && (!errors.As(err, &ue) || ue.RetryAfter != time.Minute) {
+				t.Errorf("err = %v, want a retry after the 1m cooldown", err)
+			}
+		})
+	}
+}
+
+func TestResilientClientOpenBreakerRefusesCalls(t *testing.T) {
+	chat := &fakeChat{results: []error{nil}}
+	c, _ := testClient(chat, 2, 1)
+	c.Breaker.Failure()
+
+	_, err := c.Chat(context.Background(), ChatRequest{})
+	if !errors.Is(err, ErrUnavailable) || chat.calls != 0 {
+		t.Errorf("Chat() err = %v after %d call(s); want refused without calling", err, chat.calls)
+	}
+}
+
+func TestResilientClientStopsWhenCancelled(t *testing.T) {
+	chat := &fakeChat{results: []error{errServer}}
+	c, _ := testClient(chat, 3, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	c.sleep = func(context.Context, time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}
+
+	_, err := c.Chat(ctx, ChatRequest{})
+	if !errors.Is(err, ErrUnavailable) || !errors.Is(err, context.Canceled) || chat.calls != 1 {
+		t.Errorf("Chat() err = %v after %d call(s); want cancelled after 1", err, chat.calls)
+	}
+}
+
+func TestResilientClientWrapsErrorsItDoesNotRetry(t *testing.T) {
+	badKey := &StatusError{URL: "http://llm", Code: http.StatusUnauthorized}
+	for _, cause := range []error{badKey, context.Canceled} {
+		chat := &fakeChat{results: []error{cause}}
+		c, _ := testClient(chat, 3, 1)
+
+		_, err := c.Chat(context.Background(), ChatRequest{})
+		var ue *UnavailableError
+		if !errors.As(err, &ue) || !errors.Is(err, cause) || chat.calls != 1 {
+			t.Errorf("Chat() err = %v after %d call(s); want an *UnavailableError wrapping %v after 1", err, chat.calls, cause)
+		}
+		if BackendDown(c) {
+			t.Errorf("%v opened the breaker", cause)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package app builds the server's object graph once: stores, repositories, model
+// clients, services and the handlers that use them.
+package app
+
+import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/config"
+	"academ_aide/internal/handlers"
+	"academ_aide/internal/health"
+	"academ_aide/internal/repository"
+	"academ_aide/internal/services"
+	"context"
+	"time"
+)
+
+// Services are shared by every handler and background job, so caches, circuit
+// breakers and alert queues exist once.
+type Services struct {
+	Accounts   *services.AccountService
+	Students   *services.StudentService
+	Faculty    *services.FacultyService
+	Admin      *services.AdminService
+	Imports    *services.ImportService
+	Grading    *services.GradingService
+	Alerts     *services.AlertService
+	Risk       *services.RiskService
+	Attendance *services.AttendanceService
+	Questions  *services.QuestionService
+	Feedback   *services.FeedbackService
+	Personas   *services.PersonaService
+	Threads    *services.ChatService
+	Safety     *services.SafetyService
+	Wellbeing  *services.WellbeingService
+	Insights   *services.AIService
+	Quizzes    *services.QuizService
+	Chat       *services.RAGService
+}
+
+type Handlers struct {
+	Auth       *handlers.AuthHandler
+	Student    *handlers.StudentHandler
+	Chat       *handlers.ChatHandler
+	Quiz       *handlers.QuizHandler
+	AI         *handlers.AIHandler
+	Teacher    *handlers.TeacherHandler
+	Counsellor *handlers.CounsellorHandler
+	Admin      *handlers.AdminHandler
+	Health     *handlers.HealthHandler
+}
+
+type App struct {
+	Config   *config.Config
+	Stores   *config.Stores
+	Repos    repository.Repositories
+	Embedder *ai.Embedder
+	LLM      ai.ChatClient
+	Services Services
+	Handlers Handlers
+	Health   *health.Checker
+}
+
+// New wires everything on top of open stores.
+func New(cfg *config.Config, stores *config.Stores) *App {
+	a := &App{
+		Config:   cfg,
+		Stores:   stores,
+		Repos:    repository.NewPostgres(stores.Postgres),
+		Embedder: ai.NewEmbedder(cfg.LLM),
+		LLM:      ai.NewChatClient(cfg.LLM),
+	}
+	a.Services = newServices(cfg, stores, a.Repos, a.Embedder, a.LLM)
+	a.Health = newHealthChecker(stores, a.Embedder, a.LLM)
+	a.Handlers = newHandlers(cfg, &a.Services)
+	a.Handlers.Health = handlers.NewHealthHandler(a.Health)
+	return a
+}
+
+// healthTimeout bounds a readiness check, so a hung dependency fails the probe
+// instead of stalling it.
+const healthTimeout = 2 * time.Second
+
+// newHealthChecker checks the databases, without which nothing works, and the model
+// backends, without which chat and quizzes only degrade.
+func newHealthChecker(stores *config.Stores, embedder *ai.Embedder, llm ai.ChatClient) *health.Checker {
+	return health.NewChecker(healthTimeout,
+		health.Check{Name: "postgres", Critical: true, Probe: stores.PingPostgres},
+		health.Check{Name: "mongo", Critical: true, Probe: stores.PingMongo},
+		health.Check{Name: "redis", Critical: true, Probe: stores.PingRedis},
+		health.Check{Name: "llm", Probe: func(ctx context.Context) error { return ai.Ping(ctx, llm) }},
+		health.Check{Name: "embeddings", Probe: embedder.Ping},
+	)
+}
+
+func newServices(cfg *config.Config, stores *config.Stores, repos repository.Repositories, embedder *ai.Embedder, llm ai.ChatClient) Services {
+	db, mdb, rdb := stores.Postgres, stores.Mongo, stores.Redis
+
+	s := Services{
+		Accounts:   services.NewAccountService(db, rdb, repos.Students, repos.Faculty),
+		Students:   services.NewStudentService(db, rdb, repos),
+		Faculty:    services.NewFacultyService(repos),
+		Admin:      services.NewAdminService(db, rdb),
+		Imports:    services.NewImportService(db, rdb),
+		Grading:    services.NewGradingService(db, rdb),
+		Alerts:     services.NewAlertService(db),
+		Attendance: services.NewAttendanceService(db),
+		Questions:  services.NewQuestionService(db, repos.Enrollments),
+		Feedback:   services.NewFeedbackService(db, mdb),
+		Personas:   services.NewPersonaService(db, repos.Enrollments, repos.Faculty),
+		Threads:    services.NewChatService(mdb),
+		Safety:     services.NewSafetyService(mdb),
+		Wellbeing:  services.NewWellbeingService(db, mdb),
+		Quizzes:    services.NewQuizService(db, mdb, embedder, llm),
+	}
+	s.Risk = services.NewRiskService(db, mdb, s.Alerts)
+	s.Insights = services.NewAIService(repos.Enrollments, s.Risk, llm)
+	s.Chat = services.NewRAGService(mdb, cfg.Chat, services.RAGDeps{
+		Embedder:  embedder,
+		Repo:      repository.NewCourseRepository(db),
+		Data:      repos,
+		Cache:     services.NewResponseCache(rdb, cfg.Chat),
+		Briefs:    services.NewBriefCache(rdb),
+		Threads:   s.Threads,
+		LLM:       llm,
+		Intents:   services.NewIntentClassifier(cfg.LLM),
+		Personas:  s.Personas,
+		Moderator: services.NewModerator(cfg.Chat),
+		Safety:    s.Safety,
+		Sentiment: services.NewSentimentScorer(cfg.LLM),
+		Wellbeing: s.Wellbeing,
+		Grading:   s.Grading,
+		Insights:  s.Insights,
+	})
+	return s
+}
+
+func newHandlers(cfg *config.Config, s *Services) Handlers {
+	return Handlers{
+		Auth:    handlers.NewAuthHandler(s.Accounts, cfg.Auth, cfg.HTTP.FrontendURL),
+		Student: handlers.NewStudentHandler(s.Students, s.Grading, s.Questions),
+		Chat:    handlers.NewChatHandler(s.Chat, s.Threads, s.Personas, s.Feedback),
+		Quiz:    handlers.NewQuizHandler(s.Quizzes),
+		AI:      handlers.NewAIHandler(s.Insights, s.Risk),
+		Teacher: handlers.NewTeacherHandler(handlers.TeacherDeps{
+			Faculty:    s.Faculty,
+			Grading:    s.Grading,
+			Risk:       s.Risk,
+			Alerts:     s.Alerts,
+			Attendance: s.Attendance,
+			Questions:  s.Questions,
+			Feedback:   s.Feedback,
+			Personas:   s.Personas,
+		}),
+		Counsellor: handlers.NewCounsellorHandler(s.Wellbeing),
+		Admin:      handlers.NewAdminHandler(s.Admin, s.Imports, s.Feedback, s.Personas, s.Safety, s.Wellbeing),
+	}
+}
+
+// Close releases the stores and the model backends' pooled connections.
+func (a *App) Close(ctx context.Context) {
+	a.Stores.Close(ctx)
+	ai.HTTPClient.CloseIdleConnections()
+}
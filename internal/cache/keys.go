@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis key prefixes shared by the handlers that populate the cache and the
+// services that need to invalidate it.
+const (
+	StudentProfilePrefix = "student_profile_v2:"
+	TimetablePrefix      = "timetable:"
+	ResponsePrefix       = "response:"
+	SemanticPrefix       = "semantic:"
+	SessionPrefix        = "session:"
+	ChatBriefPrefix      = "chat_brief:"
+)
+
+func StudentProfileKey(studentID string) string {
+	return StudentProfilePrefix + studentID
+}
+
+func TimetableKey(studentID string) string {
+	return TimetablePrefix + studentID
+}
+
+// ChatBriefKey holds what the chat assistant always knows about a user: profile,
+// courses, timetable and (for students) published grades.
+func ChatBriefKey(role, userID string) string {
+	return ChatBriefPrefix + role + ":" + userID
+}
+
+// ResponseKey identifies a chat answer for one user and agent; digest covers the
+// message and the context it was answered with.
+func ResponseKey(userID, agentID, digest string) string {
+	return ResponsePrefix + userID + ":" + agentID + ":" + digest
+}
+
+// SemanticKey holds the recent general answers one agent gave users of a role within
+// a course.
+func SemanticKey(courseID, role, agentID string) string {
+	return SemanticPrefix + courseID + ":" + role + ":" + agentID
+}
+
+// InvalidateResponses drops every cached chat answer, exact and semantic.
+func InvalidateResponses(ctx context.Context, rdb *redis.Client) {
+	DeleteByPrefix(ctx, rdb, ResponsePrefix)
+	DeleteByPrefix(ctx, rdb, SemanticPrefix)
+}
+
+// InvalidateStudents drops the cached profile, timetable and chat brief of every
+// given student.
+func InvalidateStudents(ctx context.Context, rdb *redis.Client, studentIDs ...string) {
+	if len(studentIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(studentIDs)*3)
+	for _, id := range studentIDs {
+		keys = append(keys, StudentProfileKey(id), TimetableKey(id), ChatBriefKey("student", id))
+	}
+	if err := rdb.Del(ctx, keys...).Err(); err != nil {
+		slog.WarnContext(ctx, "Cache invalidation failed", "error", err)
+	}
+}
+
+// InvalidateTeacher drops one teacher's chat brief, after their assignments changed.
+func InvalidateTeacher(ctx context.Context, rdb *redis.Client, facultyID string) {
+	if err := rdb.Del(ctx, ChatBriefKey("teacher", facultyID)).Err(); err != nil {
+		slog.WarnContext(ctx, "Cache invalidation failed", "error", err)
+	}
+}
+
+// InvalidateTeachers drops every teacher's chat brief. Briefs list taught courses and
+// teaching slots, and most course and schedule writes do not say who teaches them.
+func InvalidateTeachers(ctx context.Context, rdb *redis.Client) {
+	DeleteByPrefix(ctx, rdb, ChatBriefPrefix+"teacher:")
+}
+
+// DeleteByPrefix removes every key starting with prefix. It uses SCAN so it
+// does not block Redis the way KEYS would.
+func DeleteByPrefix(ctx context.Context, rdb *redis.Client, prefix string) {
+	iter := rdb.Scan(ctx, 0, prefix+"*", 500).Iterator()
+	var batch []string
+	del := func() {
+		if err := rdb.Del(ctx, batch...).Err(); err != nil {
+			slog.WarnContext(ctx, "Cache invalidation failed", "prefix", prefix, "error", err)
+		}
+		batch = batch[:0]
+	}
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == 500 {
+			del()
+		}
+	}
+	if len(batch) > 0 {
+		del()
+	}
+	if err := iter.Err(); err != nil {
+		slog.WarnContext(ctx, "Cache scan failed", "prefix", prefix, "error", err)
+	}
+}
@@ -0,0 +1,455 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is every setting the server and the command-line tools read. It is loaded
+// once at startup (Load) and handed to whatever needs a part of it; nothing else reads
+// the environment.
+//
+// Each field has a default, may be set in the YAML file named by CONFIG_FILE, and may
+// be overridden by the environment variable in its env tag.
+type Config struct {
+	Env string `yaml:"env" env:"APP_ENV"` // "development" or "production"
+
+	HTTP     HTTP     `yaml:"http"`
+	Postgres Postgres `yaml:"postgres"`
+	Mongo    Mongo    `yaml:"mongo"`
+	Redis    Redis    `yaml:"redis"`
+	Auth     Auth     `yaml:"auth"`
+	LLM      LLM      `yaml:"llm"`
+	Chat     Chat     `yaml:"chat"`
+	Jobs     Jobs     `yaml:"jobs"`
+	Log      Log      `yaml:"log"`
+	Tracing  Tracing  `yaml:"tracing"`
+}
+
+type HTTP struct {
+	Addr        string `yaml:"addr" env:"HTTP_ADDR"`
+	FrontendURL string `yaml:"frontend_url" env:"FRONTEND_URL"` // Where OAuth logins are sent back to
+
+	// Deadlines for handling one request; 0 sets none. Routes that call the model
+	// (chat, quizzes, AI insights) get the longer one.
+	RequestTimeout      time.Duration `yaml:"request_timeout" env:"REQUEST_TIMEOUT"`
+	ModelRequestTimeout time.Duration `yaml:"model_request_timeout" env:"MODEL_REQUEST_TIMEOUT"`
+
+	// Connection limits of the HTTP server; 0 sets none. Writing a response may take
+	// as long as the longest request, so WriteTimeout must exceed both deadlines.
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+
+	// On SIGINT or SIGTERM, how long in-flight requests get to finish
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+
+	// HTTPS is served when both are set
+	TLSCertFile string `yaml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" env:"TLS_KEY_FILE"`
+}
+
+// TLS reports whether the server is configured for HTTPS.
+func (h HTTP) TLS() bool {
+	return h.TLSCertFile != "" && h.TLSKeyFile != ""
+}
+
+type Postgres struct {
+	DSN string `yaml:"dsn" env:"POSTGRES_DSN"`
+}
+
+type Mongo struct {
+	URI      string `yaml:"uri" env:"MONGO_URI"`
+	Database string `yaml:"database" env:"MONGO_DATABASE"`
+}
+
+type Redis struct {
+	Addr     string `yaml:"addr" env:"REDIS_ADDR"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD"`
+	DB       int    `yaml:"db" env:"REDIS_DB"`
+}
+
+type Auth struct {
+	JWTSecret          string `yaml:"jwt_secret" env:"JWT_SECRET"`
+	GoogleClientID     string `yaml:"google_client_id" env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `yaml:"google_client_secret" env:"GOOGLE_CLIENT_SECRET"`
+	GoogleRedirectURL  string `yaml:"google_redirect_url" env:"GOOGLE_REDIRECT_URL"`
+}
+
+// LLM configures the chat and embedding backends and the resilience around them.
+type LLM struct {
+	Provider  string `yaml:"provider" env:"LLM_PROVIDER"` // "ollama" or "openai"
+	Model     string `yaml:"model" env:"LLM_MODEL"`
+	BaseURL   string `yaml:"base_url" env:"LLM_BASE_URL"` // OpenAI-compatible endpoint
+	APIKey    string `yaml:"api_key" env:"LLM_API_KEY"`
+	OllamaURL string `yaml:"ollama_url" env:"OLLAMA_URL"` // Ollama chat, and embeddings for every provider
+
+	EmbeddingModel   string        `yaml:"embedding_model" env:"EMBEDDING_MODEL"`
+	EmbeddingTimeout time.Duration `yaml:"embedding_timeout" env:"EMBEDDING_TIMEOUT"`
+
+	IntentModel    string `yaml:"intent_model" env:"INTENT_MODEL"`       // Empty: Model; "off" disables the fallback
+	SentimentModel string `yaml:"sentiment_model" env:"SENTIMENT_MODEL"` // Empty or "off": lexicon only
+
+	Timeout          time.Duration `yaml:"timeout" env:"LLM_TIMEOUT"` // Per attempt
+	Retries          int           `yaml:"retries" env:"LLM_RETRIES"`
+	RetryBackoff     time.Duration `yaml:"retry_backoff" env:"LLM_RETRY_BACKOFF"`
+	BreakerThreshold int           `yaml:"breaker_threshold" env:"LLM_BREAKER_THRESHOLD"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" env:"LLM_BREAKER_COOLDOWN"`
+}
+
+// Chat configures the assistant on top of the model.
+type Chat struct {
+	ContextTokens int `yaml:"context_tokens" env:"LLM_CONTEXT_TOKENS"` // Model context window
+	ReplyTokens   int `yaml:"reply_tokens" env:"LLM_REPLY_TOKENS"`     // Part of the window kept for the answer
+	MaxToolSteps  int `yaml:"max_tool_steps" env:"CHAT_MAX_TOOL_STEPS"`
+
+	SemanticCache          bool    `yaml:"semantic_cache" env:"SEMANTIC_CACHE"`
+	SemanticCacheThreshold float64 `yaml:"semantic_cache_threshold" env:"SEMANTIC_CACHE_THRESHOLD"`
+
+	ModerationURL    string `yaml:"moderation_url" env:"MODERATION_URL"`
+	ModerationAPIKey string `yaml:"moderation_api_key" env:"MODERATION_API_KEY"`
+	SafetyHelpline   string `yaml:"safety_helpline" env:"SAFETY_HELPLINE"`
+}
+
+// Jobs configures work the server does besides answering requests. An interval of 0
+// disables its job.
+type Jobs struct {
+	AutoMigrate         bool          `yaml:"auto_migrate" env:"AUTO_MIGRATE"`
+	RiskScoringInterval time.Duration `yaml:"risk_scoring_interval" env:"RISK_SCORING_INTERVAL"`
+	AlertCheckInterval  time.Duration `yaml:"alert_check_interval" env:"ALERT_CHECK_INTERVAL"`
+}
+
+// Log configures the server's log output.
+type Log struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // "debug", "info", "warn" or "error"
+	Format string `yaml:"format" env:"LOG_FORMAT"` // "json", or "text" for reading in a terminal
+}
+
+// Tracing configures OpenTelemetry traces of requests, queries and model calls.
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" env:"TRACING_EXPORTER"`         // "off", "otlp" or "stdout"
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`         // OTLP/HTTP traces URL; empty: the OTEL_EXPORTER_OTLP_* variables
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO"` // Share of new traces kept
+	ServiceName string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+}
+
+const (
+	Development = "development"
+	Production  = "production"
+)
+
+// devJWTSecret signs tokens in development when no JWT_SECRET is set. Production
+// refuses to start with it.
+const devJWTSecret = "supersecretkey"
+
+// exampleJWTSecrets are secrets published in this repository, which anyone could use
+// to forge tokens.
+var exampleJWTSecrets = map[string]bool{
+	devJWTSecret: true,
+	"your-super-secret-jwt-key-change-in-production": true, // README
+}
+
+// minJWTSecret is the shortest secret accepted in production (HS256 wants 256 bits).
+const minJWTSecret = 32
+
+// Default returns the settings used when nothing is configured: every service on
+// localhost, as in the README's local setup.
+func Default() *Config {
+	return &Config{
+		Env: Development,
+		HTTP: HTTP{
+			Addr:        ":8080",
+			FrontendURL: "http://localhost:3000",
+
+			RequestTimeout:      30 * time.Second,
+			ModelRequestTimeout: 3 * time.Minute,
+
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       time.Minute,
+			WriteTimeout:      4 * time.Minute,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Postgres: Postgres{DSN: "host=localhost user=postgres password=postgres dbname=academ_aide port=5432 sslmode=disable"},
+		Mongo:    Mongo{URI: "mongodb://localhost:27017", Database: "academ_aide"},
+		Redis:    Redis{Addr: "localhost:6379"},
+		LLM: LLM{
+			Provider:         "ollama",
+			Model:            "llama3.2",
+			BaseURL:          "https://api.openai.com/v1",
+			OllamaURL:        "http://localhost:11434",
+			EmbeddingModel:   "nomic-embed-text",
+			EmbeddingTimeout: 15 * time.Second,
+			Timeout:          60 * time.Second,
+			Retries:          2,
+			RetryBackoff:     500 * time.Millisecond,
+			BreakerThreshold: 5,
+			BreakerCooldown:  30 * time.Second,
+		},
+		Chat: Chat{
+			ContextTokens:          8192,
+			ReplyTokens:            1024,
+			MaxToolSteps:           4,
+			SemanticCacheThreshold: 0.95,
+			SafetyHelpline:         "Tele-MANAS 14416 or KIRAN 1800-599-0019 (India, 24x7)",
+		},
+		Jobs: Jobs{
+			RiskScoringInterval: time.Hour,
+			AlertCheckInterval:  15 * time.Minute,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			Exporter:    "off",
+			SampleRatio: 1,
+			ServiceName: "academ_aide",
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file named by CONFIG_FILE
+// (if any) and the environment, in that order, and validates it.
+func Load() (*Config, error) {
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), os.LookupEnv); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// MustLoad is Load for programs that cannot run misconfigured.
+func MustLoad() *Config {
+	cfg, err := Load()
+	if err != nil {
+		slog.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// Production reports whether the server runs in production mode.
+func (c *Config) Production() bool {
+	return c.Env == Production
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv sets every field with an env tag whose variable is set, recursing into
+// nested structs. All malformed values are reported together.
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	var errs []error
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(fv, lookup); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		name := field.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok := lookup(name)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setField(fv, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s=%q: %w", name, raw, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setField(fv reflect.Value, raw string) error {
+	if fv.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported setting type %s", fv.Type())
+	}
+	return nil
+}
+
+// validate reports every invalid setting at once. In development a missing JWT secret
+// falls back to a fixed one, with a warning.
+func (c *Config) validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Env {
+	case Development:
+		if c.Auth.JWTSecret == "" {
+			slog.Warn("JWT_SECRET is not set; signing tokens with the development secret")
+			c.Auth.JWTSecret = devJWTSecret
+		}
+	case Production:
+		switch {
+		case c.Auth.JWTSecret == "":
+			fail("JWT_SECRET is required in production")
+		case exampleJWTSecrets[c.Auth.JWTSecret]:
+			fail("JWT_SECRET is a published example value; generate a random one for production")
+		case len(c.Auth.JWTSecret) < minJWTSecret:
+			fail("JWT_SECRET must be at least %d bytes in production", minJWTSecret)
+		}
+	default:
+		fail("APP_ENV must be %q or %q, not %q", Development, Production, c.Env)
+	}
+
+	for name, v := range map[string]string{
+		"HTTP_ADDR":      c.HTTP.Addr,
+		"POSTGRES_DSN":   c.Postgres.DSN,
+		"MONGO_URI":      c.Mongo.URI,
+		"MONGO_DATABASE": c.Mongo.Database,
+		"REDIS_ADDR":     c.Redis.Addr,
+	} {
+		if v == "" {
+			fail("%s is required", name)
+		}
+	}
+	c.HTTP.FrontendURL = strings.TrimRight(c.HTTP.FrontendURL, "/")
+	if !strings.HasPrefix(c.HTTP.FrontendURL, "http://") && !strings.HasPrefix(c.HTTP.FrontendURL, "https://") {
+		fail("FRONTEND_URL must be an http(s) URL, not %q", c.HTTP.FrontendURL)
+	}
+
+	c.LLM.Provider = strings.ToLower(c.LLM.Provider)
+	if c.LLM.Provider != "ollama" && c.LLM.Provider != "openai" {
+		fail("LLM_PROVIDER must be \"ollama\" or \"openai\", not %q", c.LLM.Provider)
+	}
+	c.LLM.BaseURL = strings.TrimRight(c.LLM.BaseURL, "/")
+	c.LLM.OllamaURL = strings.TrimRight(c.LLM.OllamaURL, "/")
+	if c.LLM.Model == "" {
+		fail("LLM_MODEL is required")
+	}
+	if c.LLM.Retries < 0 {
+		fail("LLM_RETRIES must not be negative")
+	}
+	if c.LLM.BreakerThreshold < 1 {
+		fail("LLM_BREAKER_THRESHOLD must be at least 1")
+	}
+	for name, d := range map[string]time.Duration{
+		"REQUEST_TIMEOUT":          c.HTTP.RequestTimeout,
+		"MODEL_REQUEST_TIMEOUT":    c.HTTP.ModelRequestTimeout,
+		"HTTP_READ_HEADER_TIMEOUT": c.HTTP.ReadHeaderTimeout,
+		"HTTP_READ_TIMEOUT":        c.HTTP.ReadTimeout,
+		"HTTP_WRITE_TIMEOUT":       c.HTTP.WriteTimeout,
+		"HTTP_IDLE_TIMEOUT":        c.HTTP.IdleTimeout,
+		"EMBEDDING_TIMEOUT":        c.LLM.EmbeddingTimeout,
+		"LLM_TIMEOUT":              c.LLM.Timeout,
+		"LLM_RETRY_BACKOFF":        c.LLM.RetryBackoff,
+		"LLM_BREAKER_COOLDOWN":     c.LLM.BreakerCooldown,
+		"RISK_SCORING_INTERVAL":    c.Jobs.RiskScoringInterval,
+		"ALERT_CHECK_INTERVAL":     c.Jobs.AlertCheckInterval,
+	} {
+		if d < 0 {
+			fail("%s must not be negative", name)
+		}
+	}
+
+	if w := c.HTTP.WriteTimeout; w > 0 {
+		// A request without a deadline (0) may outlast any write timeout
+		for _, d := range []time.Duration{c.HTTP.RequestTimeout, c.HTTP.ModelRequestTimeout} {
+			if d == 0 || d >= w {
+				fail("HTTP_WRITE_TIMEOUT must exceed REQUEST_TIMEOUT and MODEL_REQUEST_TIMEOUT, or responses are cut off")
+				break
+			}
+		}
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		fail("SHUTDOWN_TIMEOUT must be positive")
+	}
+	if (c.HTTP.TLSCertFile == "") != (c.HTTP.TLSKeyFile == "") {
+		fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if c.Chat.ContextTokens <= 0 {
+		fail("LLM_CONTEXT_TOKENS must be positive")
+	}
+	if c.Chat.ReplyTokens <= 0 || c.Chat.ReplyTokens >= c.Chat.ContextTokens {
+		fail("LLM_REPLY_TOKENS must be positive and smaller than LLM_CONTEXT_TOKENS")
+	}
+	if c.Chat.MaxToolSteps < 0 {
+		fail("CHAT_MAX_TOOL_STEPS must not be negative")
+	}
+	if c.Chat.SemanticCacheThreshold <= 0 || c.Chat.SemanticCacheThreshold > 1 {
+		fail("SEMANTIC_CACHE_THRESHOLD must be in (0, 1]")
+	}
+
+	c.Log.Level = strings.ToLower(c.Log.Level)
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		fail("LOG_LEVEL must be debug, info, warn or error, not %q", c.Log.Level)
+	}
+	c.Log.Format = strings.ToLower(c.Log.Format)
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		fail("LOG_FORMAT must be \"json\" or \"text\", not %q", c.Log.Format)
+	}
+
+	c.Tracing.Exporter = strings.ToLower(c.Tracing.Exporter)
+	switch c.Tracing.Exporter {
+	case "off", "otlp", "stdout":
+	default:
+		fail("TRACING_EXPORTER must be off, otlp or stdout, not %q", c.Tracing.Exporter)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		fail("TRACING_SAMPLE_RATIO must be in [0, 1]")
+	}
+	if c.Tracing.ServiceName == "" {
+		fail("OTEL_SERVICE_NAME is required")
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every setting for the rest of the test, so the developer's own
+// environment does not leak in.
+func clearEnv(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	applyEnv(reflect.ValueOf(Default()).Elem(), func(name string) (string, bool) {
+		t.Setenv(name, "")
+		return "", false
+	})
+}
+
+func TestLoadFileThenEnv(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "academ_aide.yaml")
+	file := "http:\n  addr: \":9090\"\nllm:\n  model: qwen2.5\n  timeout: 20s\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LLM_MODEL", "llama3.1")
+	t.Setenv("SEMANTIC_CACHE", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.HTTP.Addr != ":9090" || cfg.LLM.Timeout != 20*time.Second {
+		t.Errorf("file settings not applied: addr %q, timeout %s", cfg.HTTP.Addr, cfg.LLM.Timeout)
+	}
+	if cfg.LLM.Model != "llama3.1" || !cfg.Chat.SemanticCache {
+		t.Errorf("env did not override: model %q, semantic cache %v", cfg.LLM.Model, cfg.Chat.SemanticCache)
+	}
+	if cfg.Auth.JWTSecret != devJWTSecret || cfg.Redis.Addr != "localhost:6379" {
+		t.Errorf("development defaults not kept: %+v", cfg)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	clearEnv(t)
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"production without secret", map[string]string{"APP_ENV": "production"}, "JWT_SECRET is required"},
+		{"production with example secret", map[string]string{"APP_ENV": "production", "JWT_SECRET": "your-super-secret-jwt-key-change-in-production"}, "published example"},
+		{"production with short secret", map[string]string{"APP_ENV": "production", "JWT_SECRET": "short"}, "at least 32 bytes"},
+		{"unknown mode", map[string]string{"APP_ENV": "staging"}, "APP_ENV must be"},
+		{"malformed duration", map[string]string{"LLM_TIMEOUT": "soon"}, "LLM_TIMEOUT"},
+		{"reply larger than window", map[string]string{"LLM_REPLY_TOKENS": "9000"}, "LLM_REPLY_TOKENS"},
+		{"unknown provider", map[string]string{"LLM_PROVIDER": "bard"}, "LLM_PROVIDER"},
+		{"unknown log level", map[string]string{"LOG_LEVEL": "verbose"}, "LOG_LEVEL"},
+		{"unknown tracing exporter", map[string]string{"TRACING_EXPORTER": "jaeger"}, "TRACING_EXPORTER"},
+		{"write timeout shorter than model deadline", map[string]string{"HTTP_WRITE_TIMEOUT": "1m"}, "HTTP_WRITE_TIMEOUT"},
+		{"TLS certificate without key", map[string]string{"TLS_CERT_FILE": "server.crt"}, "TLS_KEY_FILE"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("err = %v, want it to mention %q", err, tc.want)
+			}
+		})
+	}
+
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_SECRET", strings.Repeat("k", minJWTSecret))
+	if _, err := Load(); err != nil {
+		t.Errorf("production with a real secret: %v", err)
+	}
+}
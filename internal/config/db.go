@@ -3,73 +3,137 @@ package config
 import (
 	"context"
 	"database/sql"
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/jackc/pgx/v5/stdlib" // Postgres driver
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel/attribute"
 )
 
-var (
-	PostgresDB *sql.DB
-	MongoDB    *mongo.Database
-	RedisClient *redis.Client
-)
-
-func InitDB() {
-	initPostgres()
-	initMongo()
-	initRedis()
+// Stores holds the database connections. It is opened once at startup and passed to
+// whatever needs it; nothing reads connections from package state.
+type Stores struct {
+	Postgres *sql.DB
+	Mongo    *mongo.Database
+	Redis    *redis.Client
 }
 
-func initPostgres() {
-	dsn := os.Getenv("POSTGRES_DSN")
-	if dsn == "" {
-		dsn = "host=localhost user=postgres password=postgres dbname=academ_aide port=5432 sslmode=disable"
-	}
+// Open creates the Postgres, MongoDB and Redis clients without waiting for the
+// servers, so a store that is down does not stop the server from starting; the
+// readiness check reports it until it is back. It fails only on invalid settings.
+func Open(cfg *Config) (*Stores, error) {
+	s := &Stores{}
 	var err error
-	PostgresDB, err = sql.Open("pgx", dsn)
+	// Queries, MongoDB commands and Redis commands are traced (see internal/tracing),
+	// without their arguments
+	s.Postgres, err = otelsql.Open("pgx", cfg.Postgres.DSN,
+		otelsql.WithAttributes(attribute.String("db.system", "postgresql")),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{OmitConnResetSession: true, OmitRows: true}))
 	if err != nil {
-		log.Fatal("Failed to connect to Postgres:", err)
+		return nil, fmt.Errorf("failed to open Postgres: %w", err)
+	}
+	client, err := mongo.NewClient(options.Client().ApplyURI(cfg.Mongo.URI).SetMonitor(otelmongo.NewMonitor()))
+	if err == nil {
+		err = client.Connect(context.Background())
 	}
-	if err = PostgresDB.Ping(); err != nil {
-		log.Fatal("Failed to ping Postgres:", err)
+	if err != nil {
+		s.Close(context.Background())
+		return nil, fmt.Errorf("failed to open MongoDB: %w", err)
+	}
+	s.Mongo = client.Database(cfg.Mongo.Database)
+	s.Redis = redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	if err := redisotel.InstrumentTracing(s.Redis, redisotel.WithDBStatement(false)); err != nil {
+		s.Close(context.Background())
+		return nil, fmt.Errorf("failed to trace Redis: %w", err)
 	}
-	log.Println("Connected to PostgreSQL")
+	return s, nil
 }
 
-func initMongo() {
-	uri := os.Getenv("MONGO_URI")
-	if uri == "" {
-		uri = "mongodb://localhost:27017"
+// MustOpen is Open for the server, which cannot run with invalid store settings.
+func MustOpen(cfg *Config) *Stores {
+	s, err := Open(cfg)
+	if err != nil {
+		slog.Error("Opening the databases failed", "error", err)
+		os.Exit(1)
 	}
-	clientOptions := options.Client().ApplyURI(uri)
-	client, err := mongo.Connect(context.Background(), clientOptions)
+	return s
+}
+
+// Connect opens Postgres, MongoDB and Redis and pings each. The stores are closed
+// again if any is unreachable.
+func Connect(ctx context.Context, cfg *Config) (*Stores, error) {
+	s, err := Open(cfg)
 	if err != nil {
-		log.Fatal("Failed to connect to MongoDB:", err)
+		return nil, err
 	}
-	err = client.Ping(context.Background(), nil)
+	for _, ping := range []func(context.Context) error{s.PingPostgres, s.PingMongo, s.PingRedis} {
+		if err := ping(ctx); err != nil {
+			s.Close(ctx)
+			return nil, err
+		}
+	}
+	slog.Info("Connected to PostgreSQL, MongoDB and Redis", "database", cfg.Mongo.Database, "redis", cfg.Redis.Addr)
+	return s, nil
+}
+
+// connectTimeout bounds pinging all three stores.
+const connectTimeout = 15 * time.Second
+
+// MustConnect is Connect for programs that cannot do anything without their
+// databases.
+func MustConnect(cfg *Config) *Stores {
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	s, err := Connect(ctx, cfg)
 	if err != nil {
-		log.Fatal("Failed to ping MongoDB:", err)
+		slog.Error("Connecting to the databases failed", "error", err)
+		os.Exit(1)
 	}
-	MongoDB = client.Database("academ_aide")
-	log.Println("Connected to MongoDB")
+	return s
 }
 
-func initRedis() {
-	addr := os.Getenv("REDIS_ADDR")
-	if addr == "" {
-		addr = "localhost:6379"
+// Close closes every open connection.
+func (s *Stores) Close(ctx context.Context) {
+	if s.Postgres != nil {
+		s.Postgres.Close()
 	}
-	RedisClient = redis.NewClient(&redis.Options{
-		Addr: addr,
-		Password: "",
-		DB: 0,
-	})
-	if err := RedisClient.Ping(context.Background()).Err(); err != nil {
-		log.Fatal("Failed to ping Redis:", err)
+	if s.Mongo != nil {
+		s.Mongo.Client().Disconnect(ctx)
+	}
+	if s.Redis != nil {
+		s.Redis.Close()
+	}
+}
+
+func (s *Stores) PingPostgres(ctx context.Context) error {
+	if err := s.Postgres.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping Postgres: %w", err)
+	}
+	return nil
+}
+
+func (s *Stores) PingMongo(ctx context.Context) error {
+	if err := s.Mongo.Client().Ping(ctx, nil); err != nil {
+		return fmt.Errorf("failed to ping MongoDB: %w", err)
+	}
+	return nil
+}
+
+func (s *Stores) PingRedis(ctx context.Context) error {
+	if err := s.Redis.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping Redis: %w", err)
 	}
-	log.Println("Connected to Redis")
+	return nil
 }
@@ -0,0 +1,806 @@
+package handlers
+
+import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/models"
+	"academ_aide/internal/services"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	adminService     MasterDataService
+	importService    DataImporter
+	feedbackService  FeedbackReporter
+	personaService   PersonaAdmin
+	safetyService    IncidentLister
+	wellbeingService WellbeingAuditor
+}
+
+func NewAdminHandler(admin MasterDataService, imports DataImporter, feedback FeedbackReporter, personas PersonaAdmin, safety IncidentLister, wellbeing WellbeingAuditor) *AdminHandler {
+	return &AdminHandler{
+		adminService:     admin,
+		importService:    imports,
+		feedbackService:  feedback,
+		personaService:   personas,
+		safetyService:    safety,
+		wellbeingService: wellbeing,
+	}
+}
+
+// respondServiceError maps service errors onto HTTP status codes.
+func respondServiceError(c *gin.Context, err error) {
+	var vErr *services.ValidationError
+	var unavailable *ai.UnavailableError
+	var rejected *ai.StatusError
+	switch {
+	case errors.As(err, &vErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "field": vErr.Field, "message": vErr.Message})
+	case errors.Is(err, services.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not teach this course"})
+	case errors.Is(err, services.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Record not found"})
+	case errors.Is(err, services.ErrDuplicate):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReferenced):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, context.DeadlineExceeded):
+		slog.WarnContext(c.Request.Context(), "Request timed out", "error", err)
+		c.JSON(http.StatusGatewayTimeout, gin.H{"error": "Request timed out"})
+	case errors.As(err, &unavailable):
+		if errors.As(err, &rejected) {
+			// A provider 4xx (bad API key, unknown model) needs an operator, not a retry
+			slog.ErrorContext(c.Request.Context(), "Model request rejected", "status", rejected.Code, "error", err)
+		} else {
+			slog.WarnContext(c.Request.Context(), "Model unavailable", "error", err)
+		}
+		retryAfter := int(math.Max(1, math.Ceil(unavailable.RetryAfter.Seconds())))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "The AI assistant is temporarily unavailable", "retry_after": retryAfter})
+	default:
+		slog.ErrorContext(c.Request.Context(), "Operation failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+	}
+}
+
+func intParam(c *gin.Context, name string) (int, bool) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+// pagination reads ?limit (default 50, max 200) and ?offset.
+func pagination(c *gin.Context) (int, int) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// --- Departments ---
+
+// ListDepartments godoc
+// @Summary      List Departments
+// @Tags         Admin
+// @Router       /admin/departments [get]
+func (h *AdminHandler) ListDepartments(c *gin.Context) {
+	depts, err := h.adminService.ListDepartments(c.Request.Context())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, depts)
+}
+
+// CreateDepartment godoc
+// @Summary      Create Department
+// @Tags         Admin
+// @Router       /admin/departments [post]
+func (h *AdminHandler) CreateDepartment(c *gin.Context) {
+	var req models.Department
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.adminService.CreateDepartment(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, req)
+}
+
+// UpdateDepartment godoc
+// @Summary      Update Department
+// @Tags         Admin
+// @Param        id path string true "Department ID"
+// @Router       /admin/departments/{id} [put]
+func (h *AdminHandler) UpdateDepartment(c *gin.Context) {
+	var req models.Department
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.DeptID = c.Param("id")
+	if err := h.adminService.UpdateDepartment(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// DeleteDepartment godoc
+// @Summary      Delete Department
+// @Tags         Admin
+// @Param        id path string true "Department ID"
+// @Router       /admin/departments/{id} [delete]
+func (h *AdminHandler) DeleteDepartment(c *gin.Context) {
+	if err := h.adminService.DeleteDepartment(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Department deleted"})
+}
+
+// --- Faculty ---
+
+// ListFaculty godoc
+// @Summary      List Faculty
+// @Tags         Admin
+// @Router       /admin/faculty [get]
+func (h *AdminHandler) ListFaculty(c *gin.Context) {
+	faculty, err := h.adminService.ListFaculty(c.Request.Context())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, faculty)
+}
+
+// CreateFaculty godoc
+// @Summary      Create Faculty
+// @Tags         Admin
+// @Router       /admin/faculty [post]
+func (h *AdminHandler) CreateFaculty(c *gin.Context) {
+	var req models.Faculty
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.adminService.CreateFaculty(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, req)
+}
+
+// UpdateFaculty godoc
+// @Summary      Update Faculty
+// @Tags         Admin
+// @Param        id path string true "Faculty ID"
+// @Router       /admin/faculty/{id} [put]
+func (h *AdminHandler) UpdateFaculty(c *gin.Context) {
+	var req models.Faculty
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.FacultyID = c.Param("id")
+	if err := h.adminService.UpdateFaculty(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// DeleteFaculty godoc
+// @Summary      Delete Faculty
+// @Tags         Admin
+// @Param        id path string true "Faculty ID"
+// @Router       /admin/faculty/{id} [delete]
+func (h *AdminHandler) DeleteFaculty(c *gin.Context) {
+	if err := h.adminService.DeleteFaculty(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Faculty deleted"})
+}
+
+// --- Courses ---
+
+// ListCourses godoc
+// @Summary      List Courses
+// @Tags         Admin
+// @Router       /admin/courses [get]
+func (h *AdminHandler) ListCourses(c *gin.Context) {
+	courses, err := h.adminService.ListCourses(c.Request.Context())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, courses)
+}
+
+// CreateCourse godoc
+// @Summary      Create Course
+// @Tags         Admin
+// @Router       /admin/courses [post]
+func (h *AdminHandler) CreateCourse(c *gin.Context) {
+	var req models.Course
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.adminService.CreateCourse(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, req)
+}
+
+// UpdateCourse godoc
+// @Summary      Update Course
+// @Tags         Admin
+// @Param        id path string true "Course ID"
+// @Router       /admin/courses/{id} [put]
+func (h *AdminHandler) UpdateCourse(c *gin.Context) {
+	var req models.Course
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.CourseID = c.Param("id")
+	if err := h.adminService.UpdateCourse(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// DeleteCourse godoc
+// @Summary      Delete Course
+// @Tags         Admin
+// @Param        id path string true "Course ID"
+// @Router       /admin/courses/{id} [delete]
+func (h *AdminHandler) DeleteCourse(c *gin.Context) {
+	if err := h.adminService.DeleteCourse(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Course deleted"})
+}
+
+// --- Sections ---
+
+// ListSections godoc
+// @Summary      List Sections
+// @Tags         Admin
+// @Router       /admin/sections [get]
+func (h *AdminHandler) ListSections(c *gin.Context) {
+	sections, err := h.adminService.ListSections(c.Request.Context())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, sections)
+}
+
+// CreateSection godoc
+// @Summary      Create Section
+// @Tags         Admin
+// @Router       /admin/sections [post]
+func (h *AdminHandler) CreateSection(c *gin.Context) {
+	var req models.Section
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.adminService.CreateSection(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, req)
+}
+
+// UpdateSection godoc
+// @Summary      Update Section
+// @Tags         Admin
+// @Param        name path string true "Section Name"
+// @Router       /admin/sections/{name} [put]
+func (h *AdminHandler) UpdateSection(c *gin.Context) {
+	var req models.Section
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.SectionName = c.Param("name")
+	if err := h.adminService.UpdateSection(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// DeleteSection godoc
+// @Summary      Delete Section
+// @Tags         Admin
+// @Param        name path string true "Section Name"
+// @Router       /admin/sections/{name} [delete]
+func (h *AdminHandler) DeleteSection(c *gin.Context) {
+	if err := h.adminService.DeleteSection(c.Request.Context(), c.GetString("user_id"), c.Param("name")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Section deleted"})
+}
+
+// --- Teaching Assignments ---
+
+// ListTeaches godoc
+// @Summary      List Teaching Assignments
+// @Tags         Admin
+// @Param        course_id query string false "Course ID"
+// @Router       /admin/teaches [get]
+func (h *AdminHandler) ListTeaches(c *gin.Context) {
+	assignments, err := h.adminService.ListTeaches(c.Request.Context(), c.Query("course_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, assignments)
+}
+
+// CreateTeaches godoc
+// @Summary      Assign Faculty to a Course Section
+// @Tags         Admin
+// @Router       /admin/teaches [post]
+func (h *AdminHandler) CreateTeaches(c *gin.Context) {
+	var req models.Teaches
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.adminService.CreateTeaches(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, req)
+}
+
+// DeleteTeaches godoc
+// @Summary      Remove a Teaching Assignment
+// @Tags         Admin
+// @Param        faculty_id query string true "Faculty ID"
+// @Param        course_id query string true "Course ID"
+// @Param        section_name query string true "Section Name"
+// @Router       /admin/teaches [delete]
+func (h *AdminHandler) DeleteTeaches(c *gin.Context) {
+	req := models.Teaches{
+		FacultyID:   c.Query("faculty_id"),
+		CourseID:    c.Query("course_id"),
+		SectionName: c.Query("section_name"),
+	}
+	if err := h.adminService.DeleteTeaches(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Teaching assignment removed"})
+}
+
+// --- Syllabus Units ---
+
+// ListSyllabusUnits godoc
+// @Summary      List Syllabus Units
+// @Tags         Admin
+// @Param        course_id query string false "Course ID"
+// @Router       /admin/syllabus-units [get]
+func (h *AdminHandler) ListSyllabusUnits(c *gin.Context) {
+	units, err := h.adminService.ListSyllabusUnits(c.Request.Context(), c.Query("course_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, units)
+}
+
+// CreateSyllabusUnit godoc
+// @Summary      Create Syllabus Unit
+// @Tags         Admin
+// @Router       /admin/syllabus-units [post]
+func (h *AdminHandler) CreateSyllabusUnit(c *gin.Context) {
+	var req models.SyllabusUnit
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	unit, err := h.adminService.CreateSyllabusUnit(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, unit)
+}
+
+// UpdateSyllabusUnit godoc
+// @Summary      Update Syllabus Unit
+// @Tags         Admin
+// @Param        id path int true "Unit ID"
+// @Router       /admin/syllabus-units/{id} [put]
+func (h *AdminHandler) UpdateSyllabusUnit(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req models.SyllabusUnit
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.UnitID = id
+	if err := h.adminService.UpdateSyllabusUnit(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// DeleteSyllabusUnit godoc
+// @Summary      Delete Syllabus Unit
+// @Tags         Admin
+// @Param        id path int true "Unit ID"
+// @Router       /admin/syllabus-units/{id} [delete]
+func (h *AdminHandler) DeleteSyllabusUnit(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminService.DeleteSyllabusUnit(c.Request.Context(), c.GetString("user_id"), id); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Syllabus unit deleted"})
+}
+
+// --- Schedule ---
+
+// ListSchedules godoc
+// @Summary      List Timetable Slots
+// @Tags         Admin
+// @Param        course_id query string false "Course ID"
+// @Router       /admin/schedules [get]
+func (h *AdminHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.adminService.ListSchedules(c.Request.Context(), c.Query("course_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateSchedule godoc
+// @Summary      Create Timetable Slot
+// @Tags         Admin
+// @Router       /admin/schedules [post]
+func (h *AdminHandler) CreateSchedule(c *gin.Context) {
+	var req models.Schedule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	sch, err := h.adminService.CreateSchedule(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sch)
+}
+
+// UpdateSchedule godoc
+// @Summary      Update Timetable Slot
+// @Tags         Admin
+// @Param        id path int true "Schedule ID"
+// @Router       /admin/schedules/{id} [put]
+func (h *AdminHandler) UpdateSchedule(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req models.Schedule
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	req.ScheduleID = id
+	if err := h.adminService.UpdateSchedule(c.Request.Context(), c.GetString("user_id"), req); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// DeleteSchedule godoc
+// @Summary      Delete Timetable Slot
+// @Tags         Admin
+// @Param        id path int true "Schedule ID"
+// @Router       /admin/schedules/{id} [delete]
+func (h *AdminHandler) DeleteSchedule(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminService.DeleteSchedule(c.Request.Context(), c.GetString("user_id"), id); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted"})
+}
+
+// --- Audit ---
+
+// GetAuditLog godoc
+// @Summary      Admin Audit Log
+// @Description  Returns who changed which master data record, newest first
+// @Tags         Admin
+// @Param        entity query string false "Entity (e.g. COURSE)"
+// @Param        limit query int false "Page size (default 50, max 200)"
+// @Param        offset query int false "Offset"
+// @Router       /admin/audit-log [get]
+func (h *AdminHandler) GetAuditLog(c *gin.Context) {
+	limit, offset := pagination(c)
+	entries, err := h.adminService.ListAuditLog(c.Request.Context(), c.Query("entity"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "limit": limit, "offset": offset})
+}
+
+// GetFeedbackReport godoc
+// @Summary      Answer Quality Report
+// @Description  Aggregates ratings of all chat answers by course, agent and source file.
+// @Tags         Admin
+// @Param        course_id query string false "Course ID"
+// @Param        from query string false "From date (YYYY-MM-DD)"
+// @Param        to query string false "To date (YYYY-MM-DD, inclusive)"
+// @Router       /admin/feedback-report [get]
+func (h *AdminHandler) GetFeedbackReport(c *gin.Context) {
+	report, err := h.feedbackService.Report(c.Request.Context(), "", feedbackFilter(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetSafetyIncidents godoc
+// @Summary      List Safety Incidents
+// @Description  Chat guardrail incidents, newest first: prompt injection in messages or course
+// @Description  materials, redacted personal data and moderation flags (self_harm, abuse).
+// @Tags         Admin
+// @Param        kind query string false "Incident kind"
+// @Param        user_id query string false "User ID"
+// @Param        from query string false "From date (YYYY-MM-DD)"
+// @Param        to query string false "To date (YYYY-MM-DD, inclusive)"
+// @Param        limit query int false "Page size (default 50, max 200)"
+// @Param        offset query int false "Offset"
+// @Router       /admin/safety-incidents [get]
+func (h *AdminHandler) GetSafetyIncidents(c *gin.Context) {
+	limit, offset := pagination(c)
+	incidents, total, err := h.safetyService.List(c.Request.Context(), services.IncidentFilter{
+		Kind:   c.Query("kind"),
+		UserID: c.Query("user_id"),
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+	}, limit, offset)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"incidents": incidents, "total": total, "limit": limit, "offset": offset})
+}
+
+// GetWellbeingAccessLog godoc
+// @Summary      Wellbeing Access Log
+// @Description  Who raised, listed, viewed or changed which wellbeing alert, newest first.
+// @Description  Alert contents are only visible to counsellors.
+// @Tags         Admin
+// @Param        actor_id query string false "Counsellor ID, or system"
+// @Param        student_id query string false "Student ID"
+// @Param        limit query int false "Page size (default 50, max 200)"
+// @Param        offset query int false "Offset"
+// @Router       /admin/wellbeing-access-log [get]
+func (h *AdminHandler) GetWellbeingAccessLog(c *gin.Context) {
+	limit, offset := pagination(c)
+	entries, err := h.wellbeingService.ListAccessLog(c.Request.Context(), c.Query("actor_id"), c.Query("student_id"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "limit": limit, "offset": offset})
+}
+
+// --- Agent Personas ---
+
+// bindPersona reads a persona definition; omitted fields default to an active persona
+// with course material retrieval on.
+func bindPersona(c *gin.Context) (models.AgentPersona, bool) {
+	req := models.AgentPersona{Active: true, Retrieval: models.PersonaRetrieval{Enabled: true}}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return req, false
+	}
+	return req, true
+}
+
+// ListPersonas godoc
+// @Summary      List Agent Personas
+// @Description  Current version of every chat agent (built-in, custom and course-specific), including inactive ones.
+// @Tags         Admin
+// @Router       /admin/personas [get]
+func (h *AdminHandler) ListPersonas(c *gin.Context) {
+	personas, err := h.personaService.ListAll(c.Request.Context())
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, personas)
+}
+
+// CreatePersona godoc
+// @Summary      Create Agent Persona
+// @Tags         Admin
+// @Router       /admin/personas [post]
+func (h *AdminHandler) CreatePersona(c *gin.Context) {
+	req, ok := bindPersona(c)
+	if !ok {
+		return
+	}
+	persona, err := h.personaService.Create(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, persona)
+}
+
+// UpdatePersona godoc
+// @Summary      Update Agent Persona
+// @Description  Stores the definition as the agent's next version. Editing a built-in agent overrides its YAML definition.
+// @Tags         Admin
+// @Param        id path string true "Agent ID"
+// @Router       /admin/personas/{id} [put]
+func (h *AdminHandler) UpdatePersona(c *gin.Context) {
+	req, ok := bindPersona(c)
+	if !ok {
+		return
+	}
+	req.ID = c.Param("id")
+	persona, err := h.personaService.Update(c.Request.Context(), c.GetString("user_id"), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, persona)
+}
+
+// GetPersonaVersions godoc
+// @Summary      Agent Persona History
+// @Description  Every version of an agent, newest first.
+// @Tags         Admin
+// @Param        id path string true "Agent ID"
+// @Router       /admin/personas/{id}/versions [get]
+func (h *AdminHandler) GetPersonaVersions(c *gin.Context) {
+	versions, err := h.personaService.Versions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+// --- Bulk Import / Export ---
+
+// maxImportBytes caps the size of an uploaded CSV
+const maxImportBytes = 10 << 20
+
+// ImportDataset godoc
+// @Summary      Bulk Import CSV
+// @Description  Imports students, enrollments, schedules or grades from CSV in a single transaction.
+// @Description  With dry_run=true nothing is committed; the report lists row-level validation errors.
+// @Tags         Admin
+// @Accept       multipart/form-data,text/csv
+// @Param        dataset path string true "students | enrollments | schedules | grades"
+// @Param        dry_run query bool false "Validate only"
+// @Param        file formData file false "CSV file (or send the CSV as the raw request body)"
+// @Success      200 {object} services.ImportReport
+// @Router       /admin/import/{dataset} [post]
+func (h *AdminHandler) ImportDataset(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true" || c.Query("dry_run") == "1"
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Could not read file"})
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	report, err := h.importService.Import(c.Request.Context(), c.GetString("user_id"), c.Param("dataset"), body, dryRun)
+	if errors.Is(err, services.ErrUnknownDataset) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown dataset", "datasets": services.DatasetNames()})
+		return
+	} else if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Import failed", "dataset", c.Param("dataset"), "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed"})
+		return
+	}
+
+	status := http.StatusOK
+	if len(report.Errors) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, report)
+}
+
+// ExportDataset godoc
+// @Summary      Bulk Export
+// @Description  Exports a dataset as CSV (default) or XLSX, in the same layout the import accepts
+// @Tags         Admin
+// @Param        dataset path string true "students | enrollments | schedules | grades"
+// @Param        format query string false "csv | xlsx"
+// @Router       /admin/export/{dataset} [get]
+func (h *AdminHandler) ExportDataset(c *gin.Context) {
+	dataset := c.Param("dataset")
+	format := c.DefaultQuery("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return
+	}
+
+	header, records, err := h.importService.Export(c.Request.Context(), dataset)
+	if errors.Is(err, services.ErrUnknownDataset) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown dataset", "datasets": services.DatasetNames()})
+		return
+	} else if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Export failed", "dataset", dataset, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Export failed"})
+		return
+	}
+
+	filename := dataset + "." + format
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		err = services.WriteXLSX(c.Writer, dataset, header, records)
+	} else {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		err = services.WriteCSV(c.Writer, header, records)
+	}
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Export write failed", "dataset", dataset, "error", err)
+	}
+}
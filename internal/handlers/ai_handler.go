@@ -1,37 +1,66 @@
 package handlers
 
 import (
+	"academ_aide/internal/ai"
 	"academ_aide/internal/services"
+	"context"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AIHandler struct {
-	aiService *services.AIService
+	aiService   InsightService
+	riskService QuizAttemptRecorder
 }
 
-func NewAIHandler() *AIHandler {
+func NewAIHandler(insights InsightService, risk QuizAttemptRecorder) *AIHandler {
 	return &AIHandler{
-		aiService: services.NewAIService(),
+		aiService:   insights,
+		riskService: risk,
 	}
 }
 
 // GetInsights godoc
 // @Summary      Get AI Insights for a student
-// @Description  Returns risk analysis and suggestions
+// @Description  Returns risk analysis and suggestions for the logged-in student, or for student_id when a teacher of theirs asks
 // @Tags         AI
-// @Param        student_id query string true "Student ID"
+// @Param        student_id query string false "Student ID (teachers only)"
 // @Success      200 {object} services.AIInsightsResponse
 // @Router       /ai/insights [get]
 func (h *AIHandler) GetInsights(c *gin.Context) {
-	studentID := c.Query("student_id")
-	if studentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "student_id is required"})
+	userID, ok := studentID(c)
+	if !ok {
 		return
 	}
+	ctx := c.Request.Context()
 
-	insights, err := h.aiService.GetStudentInsights(studentID)
+	var insights *services.AIInsightsResponse
+	var err error
+	switch c.GetString("role") {
+	case "student":
+		if id := c.Query("student_id"); id != "" && id != userID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Students can only view their own insights"})
+			return
+		}
+		insights, err = h.aiService.GetStudentInsights(ctx, userID)
+	case "teacher":
+		id := c.Query("student_id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "student_id is required"})
+			return
+		}
+		insights, err = h.aiService.TeacherInsights(ctx, userID, id)
+		if errors.Is(err, services.ErrForbidden) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not teach this student"})
+			return
+		}
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insights are available to students and their teachers"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to analyze data"})
 		return
@@ -52,7 +81,7 @@ func (h *AIHandler) CalculateWhatIf(c *gin.Context) {
 		return
 	}
 
-	result, err := h.aiService.CalculateWhatIf(req.StudentID, req.MissedClasses)
+	result, err := h.aiService.CalculateWhatIf(c.Request.Context(), req.StudentID, req.MissedClasses)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Simulation failed"})
 		return
@@ -77,7 +106,28 @@ func (h *AIHandler) AnalyzeQuiz(c *gin.Context) {
 		return
 	}
 
-	analysis, err := h.aiService.AnalyzeQuizPerformance(req)
+	// Feed the attempt into risk scoring (quiz and engagement factors). Attempts are
+	// only ever recorded against the logged-in student.
+	userID, ok := studentID(c)
+	if !ok {
+		return
+	}
+	if req.StudentID != "" && (req.StudentID != userID || c.GetString("role") != "student") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Quiz attempts can only be recorded for yourself"})
+		return
+	}
+	if c.GetString("role") == "student" && req.CourseID != "" {
+		req.StudentID = userID
+		if err := h.riskService.RecordQuizAttempt(c.Request.Context(), req.StudentID, req.CourseID, req.Score, req.TotalQuestions); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Recording quiz attempt failed", "error", err)
+		}
+	}
+
+	analysis, err := h.aiService.AnalyzeQuizPerformance(c.Request.Context(), req)
+	if errors.Is(err, ai.ErrUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+		respondServiceError(c, err)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Analysis failed"})
 		return
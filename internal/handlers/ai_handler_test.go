@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"academ_aide/internal/services"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeInsights records whose insights were read; F1 teaches S2 only.
+type fakeInsights struct {
+	InsightService
+	read []string
+}
+
+func (f *fakeInsights) GetStudentInsights(_ context.Context, studentID string) (*services.AIInsightsResponse, error) {
+	f.read = append(f.read, studentID)
+	return &services.AIInsightsResponse{}, nil
+}
+
+func (f *fakeInsights) TeacherInsights(ctx context.Context, facultyID, studentID string) (*services.AIInsightsResponse, error) {
+	if facultyID != "F1" || studentID != "S2" {
+		return nil, services.ErrForbidden
+	}
+	return f.GetStudentInsights(ctx, studentID)
+}
+
+func (f *fakeInsights) AnalyzeQuizPerformance(context.Context, services.QuizSubmission) (*services.QuizAnalysisResponse, error) {
+	return &services.QuizAnalysisResponse{}, nil
+}
+
+type fakeAttempts struct {
+	recorded []string
+}
+
+func (f *fakeAttempts) RecordQuizAttempt(_ context.Context, studentID, courseID string, _, _ int) error {
+	f.recorded = append(f.recorded, studentID+"/"+courseID)
+	return nil
+}
+
+func TestGetInsightsOnlyForSelfOrTaughtStudents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		userID, role, query string
+		status              int
+		want                []string
+	}{
+		{"S1", "student", "", http.StatusOK, []string{"S1"}},
+		{"S1", "student", "?student_id=S2", http.StatusForbidden, nil},
+		{"F1", "teacher", "?student_id=S2", http.StatusOK, []string{"S2"}},
+		{"F1", "teacher", "?student_id=S3", http.StatusForbidden, nil},
+		{"C1", "counsellor", "?student_id=S2", http.StatusForbidden, nil},
+		{"", "", "?student_id=S2", http.StatusUnauthorized, nil},
+	} {
+		insights := &fakeInsights{}
+		h := NewAIHandler(insights, &fakeAttempts{})
+		r := gin.New()
+		r.GET("/ai/insights", func(c *gin.Context) {
+			if tc.userID != "" {
+				c.Set("user_id", tc.userID)
+				c.Set("role", tc.role)
+			}
+			h.GetInsights(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ai/insights"+tc.query, nil))
+
+		if w.Code != tc.status || strings.Join(insights.read, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s %s%s: status %d, read %v; want %d, %v", tc.role, tc.userID, tc.query, w.Code, insights.read, tc.status, tc.want)
+		}
+	}
+}
+
+func TestAnalyzeQuizRecordsOnlyTheCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		userID, role, body string
+		status             int
+		want               []string
+	}{
+		{"S1", "student", `{"course_id": "CS101", "score": 3, "total_questions": 5}`, http.StatusOK, []string{"S1/CS101"}},
+		{"S1", "student", `{"student_id": "S1", "course_id": "CS101", "score": 3, "total_questions": 5}`, http.StatusOK, []string{"S1/CS101"}},
+		{"S1", "student", `{"student_id": "S2", "course_id": "CS101", "score": 3, "total_questions": 5}`, http.StatusForbidden, nil},
+		{"F1", "teacher", `{"student_id": "S2", "course_id": "CS101", "score": 3, "total_questions": 5}`, http.StatusForbidden, nil},
+		{"F1", "teacher", `{"course_id": "CS101", "score": 3, "total_questions": 5}`, http.StatusOK, nil},
+		{"", "", `{"student_id": "S2", "course_id": "CS101", "score": 3, "total_questions": 5}`, http.StatusUnauthorized, nil},
+	} {
+		attempts := &fakeAttempts{}
+		h := NewAIHandler(&fakeInsights{}, attempts)
+		r := gin.New()
+		r.POST("/ai/quiz-analysis", func(c *gin.Context) {
+			if tc.userID != "" {
+				c.Set("user_id", tc.userID)
+				c.Set("role", tc.role)
+			}
+			h.AnalyzeQuiz(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/ai/quiz-analysis", strings.NewReader(tc.body)))
+
+		if w.Code != tc.status || strings.Join(attempts.recorded, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s %s %s: status %d, recorded %v; want %d, %v", tc.role, tc.userID, tc.body, w.Code, attempts.recorded, tc.status, tc.want)
+		}
+	}
+}
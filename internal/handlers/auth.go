@@ -2,66 +2,91 @@ package handlers
 
 import (
 	"academ_aide/internal/config"
-
-	"context"
+	"academ_aide/internal/services"
 	"net/http"
-	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
 )
 
 // Simple Login - In production use proper Password Hashing
 type LoginRequest struct {
 	ID       string `json:"id"` // Unified ID field
 	Password string `json:"password"`
-	Role     string `json:"role"` // "student" or "teacher"
+	Role     string `json:"role"` // "student", "teacher", "admin" or "counsellor"
+}
+
+// AuthHandler issues tokens: password login for every role, and Google sign-in with
+// self-registration for students.
+type AuthHandler struct {
+	accounts    AccountStore
+	oauth       *oauth2.Config
+	secret      []byte // Signs and verifies JWTs
+	frontendURL string // Google sign-in returns the user here
+}
+
+func NewAuthHandler(accounts AccountStore, cfg config.Auth, frontendURL string) *AuthHandler {
+	return &AuthHandler{
+		accounts:    accounts,
+		oauth:       googleOAuthConfig(cfg),
+		secret:      []byte(cfg.JWTSecret),
+		frontendURL: frontendURL,
+	}
+}
+
+var notFoundMessages = map[string]string{
+	"student":    "Student not found",
+	"teacher":    "Faculty not found",
+	"admin":      "Admin not found",
+	"counsellor": "Counsellor not found",
 }
 
-func LoginHandler(c *gin.Context) {
+// Login godoc
+// @Summary      Log in
+// @Description  Checks the ID exists for the role and returns a 24h JWT. Teachers, admins and counsellors must give their stored password.
+// @Tags         Auth
+// @Router       /login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	if req.Role != "student" && req.Role != "teacher" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be 'student' or 'teacher'"})
+	if req.Role != "student" && req.Role != "teacher" && req.Role != "admin" && req.Role != "counsellor" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid role. Must be 'student', 'teacher', 'admin' or 'counsellor'"})
 		return
 	}
 
-	var exists bool
+	exists, err := h.accounts.Exists(c.Request.Context(), req.Role, req.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": notFoundMessages[req.Role]})
+		return
+	}
 
-	if req.Role == "student" {
-		// Verify Student Exists
-		err := config.PostgresDB.QueryRow("SELECT EXISTS(SELECT 1 FROM STUDENT WHERE student_id=$1)", req.ID).Scan(&exists)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
-			return
-		}
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Student not found"})
-			return
-		}
-	} else if req.Role == "teacher" {
-		// Verify Faculty Exists
-		err := config.PostgresDB.QueryRow("SELECT EXISTS(SELECT 1 FROM FACULTY WHERE faculty_id=$1)", req.ID).Scan(&exists)
+	// Mock Password Verification (Common)
+	if req.Password == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password required"})
+		return
+	}
+
+	// Staff roles are checked against their stored bcrypt hash
+	if services.HasPassword(req.Role) {
+		ok, err := h.accounts.CheckPassword(c.Request.Context(), req.Role, req.ID, req.Password)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
 			return
 		}
-		if !exists {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Faculty not found"})
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
-		// No wallet logic for teachers
-	}
-
-	// Mock Password Verification (Common)
-	if req.Password == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password required"})
-		return
 	}
 
 	// Generate Real JWT
@@ -75,26 +100,24 @@ func LoginHandler(c *gin.Context) {
 	if req.Role == "student" {
 
 		claims["student_id"] = req.ID // Backwards compatibility if needed
-	} else {
+	} else if req.Role == "teacher" {
 		claims["faculty_id"] = req.ID
+	} else if req.Role == "counsellor" {
+		claims["counsellor_id"] = req.ID
+	} else {
+		claims["admin_id"] = req.ID
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "supersecretkey"
-	}
-
-	tokenString, err := token.SignedString([]byte(secret))
+	tokenString, err := token.SignedString(h.secret)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
 		return
 	}
 
 	// Store in Redis (Session)
-	err = config.RedisClient.Set(context.Background(), "session:"+req.ID, tokenString, 24*time.Hour).Err()
-	if err != nil {
+	if err := h.accounts.StoreSession(c.Request.Context(), req.ID, tokenString, 24*time.Hour); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Redis Error"})
 		return
 	}
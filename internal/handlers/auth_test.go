@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"academ_aide/internal/config"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAccounts knows every ID; passwords holds the stored password of staff accounts
+// by "role/id".
+type fakeAccounts struct {
+	AccountStore // Methods a test does not use panic
+	passwords    map[string]string
+}
+
+func (fakeAccounts) Exists(context.Context, string, string) (bool, error) { return true, nil }
+
+func (f fakeAccounts) CheckPassword(_ context.Context, role, id, password string) (bool, error) {
+	stored, ok := f.passwords[role+"/"+id]
+	return ok && stored == password, nil
+}
+
+func (fakeAccounts) StoreSession(context.Context, string, string, time.Duration) error { return nil }
+
+func TestLoginChecksStaffPasswords(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	accounts := fakeAccounts{passwords: map[string]string{"admin/A001": "correct horse", "teacher/F1001": "battery staple"}}
+	h := NewAuthHandler(accounts, config.Auth{JWTSecret: "test"}, "")
+	r := gin.New()
+	r.POST("/login", h.Login)
+
+	for _, tc := range []struct {
+		body   string
+		status int
+	}{
+		{`{"id": "A001", "role": "admin", "password": "correct horse"}`, http.StatusOK},
+		{`{"id": "A001", "role": "admin", "password": "anything"}`, http.StatusUnauthorized},
+		{`{"id": "A002", "role": "admin", "password": "correct horse"}`, http.StatusUnauthorized}, // No hash set
+		{`{"id": "A001", "role": "admin"}`, http.StatusUnauthorized},
+		{`{"id": "C001", "role": "counsellor", "password": "anything"}`, http.StatusUnauthorized},
+		{`{"id": "F1001", "role": "teacher", "password": "battery staple"}`, http.StatusOK},
+		{`{"id": "F1001", "role": "teacher", "password": "anything"}`, http.StatusUnauthorized},
+		{`{"id": "F1002", "role": "teacher", "password": "anything"}`, http.StatusUnauthorized}, // No hash set
+		{`{"id": "S1", "role": "student", "password": "anything"}`, http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tc.body)))
+		if w.Code != tc.status {
+			t.Errorf("%s: status %d, want %d (%s)", tc.body, w.Code, tc.status, w.Body)
+		}
+	}
+}
@@ -1,63 +1,251 @@
 package handlers
 
 import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/models"
 	"academ_aide/internal/services"
+	"context"
+	"errors"
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ChatRequest is a chat message. The user and role come from the token, never the body.
 type ChatRequest struct {
-	StudentID string `json:"student_id"`
-	FacultyID string `json:"faculty_id"`
-	Role      string `json:"role"` // "student" or "teacher"
-	Message   string `json:"message"`
-	AgentID   string `json:"agent_id"` // Optional, defaults to "general" if empty
+	Message string `json:"message"`
+	AgentID string `json:"agent_id"` // Optional, defaults to "general"; see GET /chat/agents
+
+	// Optional thread to continue; a new thread named after the message is started if empty
+	ConversationID string `json:"conversation_id"`
+}
+
+// chatRoles are the roles the chat assistant has a tool set for.
+var chatRoles = map[string]bool{"student": true, "teacher": true}
+
+type ChatHandler struct {
+	chatService     ChatResponder
+	threadService   ThreadStore
+	agentService    AgentLister
+	feedbackService FeedbackSubmitter
 }
 
-func ChatHandler(c *gin.Context) {
+func NewChatHandler(chat ChatResponder, threads ThreadStore, agents AgentLister, feedback FeedbackSubmitter) *ChatHandler {
+	return &ChatHandler{
+		chatService:     chat,
+		threadService:   threads,
+		agentService:    agents,
+		feedbackService: feedback,
+	}
+}
+
+// SendMessage godoc
+// @Summary      Send a chat message
+// @Description  Answers in the given thread, or starts a thread named after the message.
+// @Description  Students and teachers only; the role is taken from the token.
+// @Tags         Chat
+// @Router       /chat/message [post]
+func (h *ChatHandler) SendMessage(c *gin.Context) {
 	var req ChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
 		return
 	}
 
-	rag := services.NewRAGService()
+	// The tools and records the assistant may use follow the authenticated role
+	userID, ok := studentID(c)
+	if !ok {
+		return
+	}
+	role := c.GetString("role")
+	if !chatRoles[role] {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Chat is available to students and teachers"})
+		return
+	}
 
-	// Determine UserID and Role defaulting
-	userID := req.StudentID
-	role := "student"
-	if req.Role == "teacher" {
-		userID = req.FacultyID
-		role = "teacher"
+	conversationID := req.ConversationID
+	if conversationID != "" {
+		if _, err := h.threadService.GetThread(c.Request.Context(), userID, conversationID); err != nil {
+			respondServiceError(c, err)
+			return
+		}
+	} else {
+		thread, err := h.threadService.CreateThread(c.Request.Context(), userID, req.Message, req.AgentID)
+		if err != nil {
+			respondServiceError(c, err)
+			return
+		}
+		conversationID = thread.ID
 	}
 
-	// If AgentID is missing, it will default to "general" in getAgentPrompt
-	response, err := rag.ProcessChat(userID, role, req.Message, req.AgentID)
+	reply, err := h.chatService.ProcessChat(c.Request.Context(), userID, role, req.Message, req.AgentID, conversationID)
 	if err != nil {
+		var vErr *services.ValidationError
+		// e.g. an agent the user may not use, the model is down or the request ran out of time
+		if errors.As(err, &vErr) || errors.Is(err, ai.ErrUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+			respondServiceError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "AI Processing Failed"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"response": response,
-		"user_id":  userID,
-		"role":     role,
+		"response":        reply.Response,
+		"message_id":      reply.MessageID, // For POST /chat/messages/:id/feedback
+		"citations":       reply.Citations,
+		"intent":          reply.Intent,
+		"support":         reply.Support, // Campus support resources when the user seems distressed
+		"user_id":         userID,
+		"role":            role,
+		"conversation_id": conversationID,
 	})
 }
 
-func ClearChatHandler(c *gin.Context) {
-	studentID := c.Query("student_id")
-	if studentID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "student_id is required"})
+// ClearHistory godoc
+// @Summary      Delete all of the caller's chat threads, messages and context
+// @Description  Only the authenticated user's own history can be cleared.
+// @Tags         Chat
+// @Router       /chat/history [delete]
+func (h *ChatHandler) ClearHistory(c *gin.Context) {
+	userID, ok := studentID(c)
+	if !ok {
 		return
 	}
 
-	rag := services.NewRAGService()
-	if err := rag.ClearChatHistory(studentID); err != nil {
+	if err := h.chatService.ClearChatHistory(c.Request.Context(), userID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear history"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Chat history cleared"})
 }
+
+// ListAgents godoc
+// @Summary      List the chat agents available to the caller (shared and course-specific)
+// @Tags         Chat
+// @Router       /chat/agents [get]
+func (h *ChatHandler) ListAgents(c *gin.Context) {
+	agents, err := h.agentService.List(c.Request.Context(), c.GetString("user_id"), c.GetString("role"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, agents)
+}
+
+// --- Conversation Threads ---
+
+// ListThreads godoc
+// @Summary      List the user's chat threads, most recently active first
+// @Tags         Chat
+// @Router       /chat/threads [get]
+func (h *ChatHandler) ListThreads(c *gin.Context) {
+	limit, offset := pagination(c)
+	threads, total, err := h.threadService.ListThreads(c.Request.Context(), c.GetString("user_id"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"threads": threads, "total": total, "limit": limit, "offset": offset})
+}
+
+// CreateThread godoc
+// @Summary      Start a chat thread
+// @Tags         Chat
+// @Router       /chat/threads [post]
+func (h *ChatHandler) CreateThread(c *gin.Context) {
+	var req struct {
+		Title   string `json:"title"`
+		AgentID string `json:"agent_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	thread, err := h.threadService.CreateThread(c.Request.Context(), c.GetString("user_id"), req.Title, req.AgentID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, thread)
+}
+
+// RenameThread godoc
+// @Summary      Rename a chat thread
+// @Tags         Chat
+// @Router       /chat/threads/{id} [put]
+func (h *ChatHandler) RenameThread(c *gin.Context) {
+	var req struct {
+		Title string `json:"title"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	thread, err := h.threadService.RenameThread(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req.Title)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, thread)
+}
+
+// DeleteThread godoc
+// @Summary      Delete a chat thread and its messages
+// @Tags         Chat
+// @Router       /chat/threads/{id} [delete]
+func (h *ChatHandler) DeleteThread(c *gin.Context) {
+	if err := h.threadService.DeleteThread(c.Request.Context(), c.GetString("user_id"), c.Param("id")); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Thread deleted"})
+}
+
+// GetThreadMessages godoc
+// @Summary      Page through a thread's messages (newest first) with agent and citations
+// @Tags         Chat
+// @Router       /chat/threads/{id}/messages [get]
+func (h *ChatHandler) GetThreadMessages(c *gin.Context) {
+	limit, offset := pagination(c)
+	messages, total, err := h.threadService.Messages(c.Request.Context(), c.GetString("user_id"), c.Param("id"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages, "total": total, "limit": limit, "offset": offset})
+}
+
+// ClearThread godoc
+// @Summary      Delete a thread's messages but keep the thread
+// @Tags         Chat
+// @Router       /chat/threads/{id}/messages [delete]
+func (h *ChatHandler) ClearThread(c *gin.Context) {
+	deleted, err := h.threadService.ClearThread(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Thread cleared", "deleted": deleted})
+}
+
+// SubmitFeedback godoc
+// @Summary      Rate a bot answer (thumbs up/down with optional reason and comment)
+// @Tags         Chat
+// @Param        id path string true "Bot message ID (message_id from POST /chat/message)"
+// @Router       /chat/messages/{id}/feedback [post]
+func (h *ChatHandler) SubmitFeedback(c *gin.Context) {
+	var req models.AnswerFeedback
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	fb, err := h.feedbackService.Submit(c.Request.Context(), c.GetString("user_id"), c.Param("id"), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, fb)
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/models"
+	"academ_aide/internal/services"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeChat records who chatted in which role and whose history was cleared.
+type fakeChat struct {
+	ChatResponder // Methods a test does not use panic
+	asked         []string
+	cleared       []string
+	err           error // Returned by ProcessChat when set
+}
+
+func (f *fakeChat) ProcessChat(_ context.Context, userID, role, _, _, _ string) (*services.ChatReply, error) {
+	f.asked = append(f.asked, userID+"/"+role)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &services.ChatReply{Response: "ok"}, nil
+}
+
+type fakeThreads struct {
+	ThreadStore
+}
+
+func (fakeThreads) CreateThread(_ context.Context, userID, title, _ string) (*models.Conversation, error) {
+	return &models.Conversation{ID: "t1", UserID: userID, Title: title}, nil
+}
+
+func (f *fakeChat) ClearChatHistory(_ context.Context, userID string) error {
+	f.cleared = append(f.cleared, userID)
+	return nil
+}
+
+func TestClearHistoryOnlyClearsTheCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		userID string
+		status int
+		want   []string
+	}{
+		{"S1", http.StatusOK, []string{"S1"}},
+		{"", http.StatusUnauthorized, nil},
+	} {
+		chat := &fakeChat{}
+		h := NewChatHandler(chat, nil, nil, nil)
+		r := gin.New()
+		r.DELETE("/chat/history", func(c *gin.Context) {
+			if tc.userID != "" {
+				c.Set("user_id", tc.userID)
+			}
+			h.ClearHistory(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/chat/history?student_id=S2", nil))
+
+		if w.Code != tc.status || len(chat.cleared) != len(tc.want) || (len(tc.want) > 0 && chat.cleared[0] != tc.want[0]) {
+			t.Errorf("user %q: status %d, cleared %v; want %d, %v", tc.userID, w.Code, chat.cleared, tc.status, tc.want)
+		}
+	}
+}
+
+func TestSendMessageTakesTheRoleFromTheToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"message": "Show the gradebook", "role": "teacher", "faculty_id": "F1", "student_id": "S2"}`
+	for _, tc := range []struct {
+		userID, role string
+		status       int
+		want         []string
+	}{
+		{"S1", "student", http.StatusOK, []string{"S1/student"}},
+		{"F1", "teacher", http.StatusOK, []string{"F1/teacher"}},
+		{"A1", "admin", http.StatusForbidden, nil},
+		{"C1", "counsellor", http.StatusForbidden, nil},
+	} {
+		chat := &fakeChat{}
+		h := NewChatHandler(chat, fakeThreads{}, nil, nil)
+		r := gin.New()
+		r.POST("/chat/message", func(c *gin.Context) {
+			c.Set("user_id", tc.userID)
+			c.Set("role", tc.role)
+			h.SendMessage(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/chat/message", strings.NewReader(body)))
+
+		if w.Code != tc.status || strings.Join(chat.asked, ",") != strings.Join(tc.want, ",") {
+			t.Errorf("%s token: status %d, chatted as %v; want %d, %v", tc.role, w.Code, chat.asked, tc.status, tc.want)
+		}
+	}
+}
+
+func TestSendMessageMapsModelFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rejected := &ai.StatusError{URL: "http://llm", Code: http.StatusUnauthorized}
+	for _, tc := range []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"provider rejected the request", &ai.UnavailableError{RetryAfter: time.Second, Cause: rejected}, http.StatusServiceUnavailable},
+		{"backend down", &ai.UnavailableError{RetryAfter: time.Minute, Cause: errors.New("circuit open")}, http.StatusServiceUnavailable},
+		{"request timed out", &ai.UnavailableError{RetryAfter: time.Second, Cause: context.DeadlineExceeded}, http.StatusGatewayTimeout},
+	} {
+		h := NewChatHandler(&fakeChat{err: tc.err}, fakeThreads{}, nil, nil)
+		r := gin.New()
+		r.POST("/chat/message", func(c *gin.Context) {
+			c.Set("user_id", "S1")
+			c.Set("role", "student")
+			h.SendMessage(c)
+		})
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/chat/message", strings.NewReader(`{"message": "hi"}`)))
+
+		if w.Code != tc.status {
+			t.Errorf("%s: status %d, want %d", tc.name, w.Code, tc.status)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CounsellorHandler serves the confidential wellbeing alerts. Only the counsellor role
+// reaches it; every call is written to the wellbeing access log.
+type CounsellorHandler struct {
+	wellbeingService WellbeingCaseload
+}
+
+func NewCounsellorHandler(wellbeing WellbeingCaseload) *CounsellorHandler {
+	return &CounsellorHandler{
+		wellbeingService: wellbeing,
+	}
+}
+
+// GetWellbeingAlerts godoc
+// @Summary      List Wellbeing Alerts
+// @Description  Alerts assigned to the counsellor or still unassigned, high severity and most recent first.
+// @Description  The triggering message is only returned by the detail view.
+// @Tags         Counsellor
+// @Param        status query string false "active (default), open, acknowledged, resolved or all"
+// @Param        limit query int false "Page size (default 50, max 200)"
+// @Param        offset query int false "Offset"
+// @Router       /counsellor/alerts [get]
+func (h *CounsellorHandler) GetWellbeingAlerts(c *gin.Context) {
+	limit, offset := pagination(c)
+	alerts, total, err := h.wellbeingService.ListAlerts(c.Request.Context(), c.GetString("user_id"), c.Query("status"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts, "total": total, "limit": limit, "offset": offset})
+}
+
+// GetWellbeingAlert godoc
+// @Summary      Wellbeing Alert Detail
+// @Description  One alert with the student's contact details and the message that raised it.
+// @Tags         Counsellor
+// @Param        id path int true "Alert ID"
+// @Router       /counsellor/alerts/{id} [get]
+func (h *CounsellorHandler) GetWellbeingAlert(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	alert, err := h.wellbeingService.GetAlert(c.Request.Context(), c.GetString("user_id"), id)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// UpdateWellbeingAlert godoc
+// @Summary      Update Wellbeing Alert
+// @Description  Acknowledge, resolve or reopen an alert, optionally with a note. Acting on an
+// @Description  unassigned alert assigns it to you.
+// @Tags         Counsellor
+// @Param        id path int true "Alert ID"
+// @Router       /counsellor/alerts/{id} [put]
+func (h *CounsellorHandler) UpdateWellbeingAlert(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Status string `json:"status" binding:"required"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	alert, err := h.wellbeingService.UpdateAlert(c.Request.Context(), c.GetString("user_id"), id, req.Status, req.Note)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// GetStudentMood godoc
+// @Summary      Student Mood Trend
+// @Description  The last week of chat mood scores for a student you hold an alert for.
+// @Tags         Counsellor
+// @Param        id path string true "Student ID"
+// @Router       /counsellor/students/{id}/mood [get]
+func (h *CounsellorHandler) GetStudentMood(c *gin.Context) {
+	trend, err := h.wellbeingService.StudentTrend(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, trend)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"academ_aide/internal/health"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler answers the orchestrator's liveness and readiness probes.
+type HealthHandler struct {
+	checker ReadinessChecker
+}
+
+func NewHealthHandler(checker ReadinessChecker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Live godoc
+// @Summary      Liveness
+// @Description  200 while the process is serving requests; it checks no dependency, so a
+// @Description  database outage does not get the server restarted.
+// @Tags         Health
+// @Router       /healthz [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusOK})
+}
+
+// Ready godoc
+// @Summary      Readiness
+// @Description  Checks Postgres, MongoDB, Redis, the chat model and the embedding model.
+// @Description  503 when a database is down; 200 with status "degraded" when only a model
+// @Description  backend is, since chat still answers in its degraded mode.
+// @Tags         Health
+// @Router       /readyz [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	report := h.checker.Run(c.Request.Context())
+	status := http.StatusOK
+	if report.Status == health.StatusUnavailable {
+		status = http.StatusServiceUnavailable
+	}
+	if report.Status != health.StatusOK {
+		for name, res := range report.Checks {
+			if res.Status != health.StatusOK {
+				slog.WarnContext(c.Request.Context(), "Readiness check failed", "check", name, "error", res.Error)
+			}
+		}
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"academ_aide/internal/health"
+	"academ_aide/internal/models"
+	"academ_aide/internal/services"
+	"context"
+	"io"
+	"time"
+)
+
+// The handlers depend on these interfaces rather than on the services themselves, so
+// a handler can be tested against a fake. Each lists only what its handlers call; the
+// services in internal/services satisfy them and internal/app wires them together.
+
+// --- Auth ---
+
+type AccountStore interface {
+	Exists(ctx context.Context, role, id string) (bool, error)
+	CheckPassword(ctx context.Context, role, id, password string) (bool, error)
+	StoreSession(ctx context.Context, userID, token string, ttl time.Duration) error
+	StudentIDByEmail(ctx context.Context, email string) (string, error)
+	RegisterStudent(ctx context.Context, st models.Student) error
+}
+
+// --- Student ---
+
+type StudentDashboard interface {
+	Profile(ctx context.Context, studentID string) (*models.Student, error)
+	Timetable(ctx context.Context, studentID string) ([]models.ScheduleItem, bool, error)
+	Resources(ctx context.Context, studentID string) ([]models.Resource, error)
+	Announcements(ctx context.Context, studentID string) ([]models.CourseAnnouncement, error)
+	Courses(ctx context.Context, studentID string) ([]models.EnrolledCourse, error)
+	Teachers(ctx context.Context, studentID string) ([]models.CourseTeacher, error)
+}
+
+type GradeReader interface {
+	StudentGrades(ctx context.Context, studentID string) ([]models.StudentGrade, error)
+}
+
+type QuestionAsker interface {
+	Ask(ctx context.Context, studentID, courseID, question string) (*models.StudentQuestion, error)
+	ListForStudent(ctx context.Context, studentID string) ([]models.StudentQuestion, error)
+}
+
+// --- Chat ---
+
+type ChatResponder interface {
+	ProcessChat(ctx context.Context, userID, role, message, agentID, conversationID string) (*services.ChatReply, error)
+	ClearChatHistory(ctx context.Context, studentID string) error
+}
+
+type ThreadStore interface {
+	GetThread(ctx context.Context, userID, threadID string) (*models.Conversation, error)
+	ListThreads(ctx context.Context, userID string, limit, offset int) ([]models.Conversation, int64, error)
+	CreateThread(ctx context.Context, userID, title, agentID string) (*models.Conversation, error)
+	RenameThread(ctx context.Context, userID, threadID, title string) (*models.Conversation, error)
+	DeleteThread(ctx context.Context, userID, threadID string) error
+	Messages(ctx context.Context, userID, threadID string, limit, offset int) ([]models.ChatLog, int64, error)
+	ClearThread(ctx context.Context, userID, threadID string) (int64, error)
+}
+
+type AgentLister interface {
+	List(ctx context.Context, userID, role string) ([]models.AgentPersona, error)
+}
+
+type FeedbackSubmitter interface {
+	Submit(ctx context.Context, userID, messageID string, fb models.AnswerFeedback) (*models.AnswerFeedback, error)
+}
+
+// --- AI features ---
+
+type QuizGenerator interface {
+	GenerateQuiz(ctx context.Context, courseID string, unit int, numQuestions int) (*models.Quiz, error)
+}
+
+type InsightService interface {
+	GetStudentInsights(ctx context.Context, studentID string) (*services.AIInsightsResponse, error)
+	TeacherInsights(ctx context.Context, facultyID, studentID string) (*services.AIInsightsResponse, error)
+	CalculateWhatIf(ctx context.Context, studentID string, missedClasses int) (*services.WhatIfScenario, error)
+	AnalyzeQuizPerformance(ctx context.Context, sub services.QuizSubmission) (*services.QuizAnalysisResponse, error)
+}
+
+type QuizAttemptRecorder interface {
+	RecordQuizAttempt(ctx context.Context, studentID, courseID string, score, total int) error
+}
+
+// --- Teacher ---
+
+type FacultyDashboard interface {
+	Courses(ctx context.Context, facultyID string) ([]models.TaughtCourse, error)
+	EnrolledStudents(ctx context.Context, courseID string) ([]models.StudentContact, error)
+	ClassHealth(ctx context.Context, courseID string) (*models.ClassHealth, error)
+	StudentStanding(ctx context.Context, studentID, courseID string) (*models.StudentStanding, error)
+	PostAnnouncement(ctx context.Context, facultyID, courseID, content string) error
+	Profile(ctx context.Context, facultyID string) (*models.FacultyProfile, error)
+}
+
+type Gradebook interface {
+	GetScheme(ctx context.Context, facultyID, courseID string) ([]models.AssessmentComponent, error)
+	SetScheme(ctx context.Context, facultyID, courseID string, comps []models.AssessmentComponent) ([]models.AssessmentComponent, error)
+	EnterMarks(ctx context.Context, facultyID, courseID string, componentID int, entries []models.MarkEntry) error
+	Gradebook(ctx context.Context, facultyID, courseID string) ([]models.GradebookRow, error)
+	Publish(ctx context.Context, facultyID, courseID string, studentIDs []string) (*services.PublishResult, error)
+	Unpublish(ctx context.Context, facultyID, courseID string, studentIDs []string) ([]string, error)
+	ListAudit(ctx context.Context, facultyID, courseID string, limit, offset int) ([]models.GradeAuditEntry, error)
+}
+
+type RiskReader interface {
+	LevelCounts(ctx context.Context, facultyID, courseID string) (map[string]int, error)
+	CourseRisks(ctx context.Context, facultyID, courseID string, levels ...string) ([]models.RiskScore, error)
+	StudentRisk(ctx context.Context, facultyID, studentID, courseID string) (*models.RiskScore, error)
+}
+
+type AlertInbox interface {
+	List(ctx context.Context, facultyID string, f services.AlertFilter, limit, offset int) ([]models.Alert, int, int, error)
+	MarkRead(ctx context.Context, facultyID string, alertID int) error
+	MarkAllRead(ctx context.Context, facultyID, courseID string) (int, error)
+	Dismiss(ctx context.Context, facultyID string, alertID int) error
+}
+
+type AttendanceRecorder interface {
+	Record(ctx context.Context, facultyID, courseID, date string, records []models.AttendanceRecord) error
+}
+
+type QuestionBoard interface {
+	ListForTeacher(ctx context.Context, facultyID, courseID string, openOnly bool) ([]models.StudentQuestion, error)
+	Answer(ctx context.Context, facultyID string, questionID int, answer string) error
+}
+
+type FeedbackReporter interface {
+	Report(ctx context.Context, facultyID string, f services.FeedbackFilter) (*models.FeedbackReport, error)
+}
+
+type CoursePersonaEditor interface {
+	CoursePersonas(ctx context.Context, facultyID, courseID string) ([]models.AgentPersona, error)
+	CreateCoursePersona(ctx context.Context, facultyID, courseID string, p models.AgentPersona) (*models.AgentPersona, error)
+	UpdateCoursePersona(ctx context.Context, facultyID, courseID string, p models.AgentPersona) (*models.AgentPersona, error)
+}
+
+// --- Counsellor ---
+
+type WellbeingCaseload interface {
+	ListAlerts(ctx context.Context, counsellorID, status string, limit, offset int) ([]models.WellbeingAlert, int, error)
+	GetAlert(ctx context.Context, counsellorID string, alertID int) (*models.WellbeingAlert, error)
+	UpdateAlert(ctx context.Context, counsellorID string, alertID int, status, note string) (*models.WellbeingAlert, error)
+	StudentTrend(ctx context.Context, counsellorID, studentID string) (models.MoodTrend, error)
+}
+
+// --- Admin ---
+
+type MasterDataService interface {
+	ListDepartments(ctx context.Context) ([]models.Department, error)
+	CreateDepartment(ctx context.Context, actorID string, d models.Department) error
+	UpdateDepartment(ctx context.Context, actorID string, d models.Department) error
+	DeleteDepartment(ctx context.Context, actorID, deptID string) error
+
+	ListFaculty(ctx context.Context) ([]models.Faculty, error)
+	CreateFaculty(ctx context.Context, actorID string, f models.Faculty) error
+	UpdateFaculty(ctx context.Context, actorID string, f models.Faculty) error
+	DeleteFaculty(ctx context.Context, actorID, facultyID string) error
+
+	ListCourses(ctx context.Context) ([]models.Course, error)
+	CreateCourse(ctx context.Context, actorID string, c models.Course) error
+	UpdateCourse(ctx context.Context, actorID string, c models.Course) error
+	DeleteCourse(ctx context.Context, actorID, courseID string) error
+
+	ListSections(ctx context.Context) ([]models.Section, error)
+	CreateSection(ctx context.Context, actorID string, sec models.Section) error
+	UpdateSection(ctx context.Context, actorID string, sec models.Section) error
+	DeleteSection(ctx context.Context, actorID, name string) error
+
+	ListTeaches(ctx context.Context, courseID string) ([]models.Teaches, error)
+	CreateTeaches(ctx context.Context, actorID string, t models.Teaches) error
+	DeleteTeaches(ctx context.Context, actorID string, t models.Teaches) error
+
+	ListSyllabusUnits(ctx context.Context, courseID string) ([]models.SyllabusUnit, error)
+	CreateSyllabusUnit(ctx context.Context, actorID string, u models.SyllabusUnit) (*models.SyllabusUnit, error)
+	UpdateSyllabusUnit(ctx context.Context, actorID string, u models.SyllabusUnit) error
+	DeleteSyllabusUnit(ctx context.Context, actorID string, unitID int) error
+
+	ListSchedules(ctx context.Context, courseID string) ([]models.Schedule, error)
+	CreateSchedule(ctx context.Context, actorID string, sch models.Schedule) (*models.Schedule, error)
+	UpdateSchedule(ctx context.Context, actorID string, sch models.Schedule) error
+	DeleteSchedule(ctx context.Context, actorID string, scheduleID int) error
+
+	ListAuditLog(ctx context.Context, entity string, limit, offset int) ([]models.AuditEntry, error)
+}
+
+type DataImporter interface {
+	Import(ctx context.Context, actorID, dataset string, r io.Reader, dryRun bool) (*services.ImportReport, error)
+	Export(ctx context.Context, dataset string) ([]string, [][]string, error)
+}
+
+type PersonaAdmin interface {
+	ListAll(ctx context.Context) ([]models.AgentPersona, error)
+	Create(ctx context.Context, actorID string, p models.AgentPersona) (*models.AgentPersona, error)
+	Update(ctx context.Context, actorID string, p models.AgentPersona) (*models.AgentPersona, error)
+	Versions(ctx context.Context, agentID string) ([]models.AgentPersona, error)
+}
+
+type IncidentLister interface {
+	List(ctx context.Context, f services.IncidentFilter, limit, offset int) ([]models.SafetyIncident, int64, error)
+}
+
+type WellbeingAuditor interface {
+	ListAccessLog(ctx context.Context, actorID, studentID string, limit, offset int) ([]models.WellbeingAccessEntry, error)
+}
+
+// --- Health ---
+
+type ReadinessChecker interface {
+	Run(ctx context.Context) health.Report
+}
@@ -2,13 +2,14 @@ package handlers
 
 import (
 	"academ_aide/internal/config"
+	"academ_aide/internal/models"
 	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"io"
 	"net/http"
-	"os"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -17,29 +18,28 @@ import (
 	"golang.org/x/oauth2/google"
 )
 
-var googleOauthConfig = &oauth2.Config{
-	ClientID:     "", // Set in Init
-	ClientSecret: "", // Set in Init
-	RedirectURL:  "", // Set in Init
-	Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
-	Endpoint:     google.Endpoint,
+func googleOAuthConfig(cfg config.Auth) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     cfg.GoogleClientID,
+		ClientSecret: cfg.GoogleClientSecret,
+		RedirectURL:  cfg.GoogleRedirectURL,
+		Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email", "https://www.googleapis.com/auth/userinfo.profile"},
+		Endpoint:     google.Endpoint,
+	}
 }
 
-func InitOAuth() {
-	googleOauthConfig.ClientID = os.Getenv("GOOGLE_CLIENT_ID")
-	googleOauthConfig.ClientSecret = os.Getenv("GOOGLE_CLIENT_SECRET")
-	googleOauthConfig.RedirectURL = os.Getenv("GOOGLE_REDIRECT_URL")
-}
+const (
+	googleUserInfoURL = "https://www.googleapis.com/oauth2/v2/userinfo"
+	googleTimeout     = 10 * time.Second // Code exchange and user info together
+)
 
-func GoogleLogin(c *gin.Context) {
-	InitOAuth() // Ensure config is loaded
+func (h *AuthHandler) GoogleLogin(c *gin.Context) {
 	oauthState := generateStateOauthCookie(c)
-	u := googleOauthConfig.AuthCodeURL(oauthState)
+	u := h.oauth.AuthCodeURL(oauthState)
 	c.Redirect(http.StatusTemporaryRedirect, u)
 }
 
-func GoogleCallback(c *gin.Context) {
-	InitOAuth()
+func (h *AuthHandler) GoogleCallback(c *gin.Context) {
 	oauthState, _ := c.Cookie("oauthstate")
 
 	if c.Query("state") != oauthState {
@@ -47,14 +47,22 @@ func GoogleCallback(c *gin.Context) {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(c.Request.Context(), googleTimeout)
+	defer cancel()
+
 	code := c.Query("code")
-	token, err := googleOauthConfig.Exchange(context.Background(), code)
+	token, err := h.oauth.Exchange(ctx, code)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Code exchange failed"})
 		return
 	}
 
-	resp, err := http.Get("https://www.googleapis.com/oauth2/v2/userinfo?access_token=" + token.AccessToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, googleUserInfoURL, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "User info failed"})
+		return
+	}
+	resp, err := h.oauth.Client(ctx, token).Do(req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "User info failed"})
 		return
@@ -73,25 +81,29 @@ func GoogleCallback(c *gin.Context) {
 	email := googleUser["email"].(string)
 
 	// Check if student exists
-	var studentID string
-	err = config.PostgresDB.QueryRow("SELECT student_id FROM STUDENT WHERE s_email=$1", email).Scan(&studentID)
+	studentID, err := h.accounts.StudentIDByEmail(ctx, email)
 
 	if err == nil {
 		// Student exists -> Login
-		tokenString := generateToken(studentID, false)
+		tokenString := h.generateToken(studentID, false)
 
 		// Redirect to Frontend Callback with Login Mode
-		c.Redirect(http.StatusTemporaryRedirect, "http://localhost:3000/auth/callback?token="+tokenString+"&mode=login&student_id="+studentID)
+		c.Redirect(http.StatusTemporaryRedirect, h.frontendCallback(url.Values{"token": {tokenString}, "mode": {"login"}, "student_id": {studentID}}))
 	} else {
 		// New Student -> Onboarding
 		// Generate Temporary Token
-		tempTokenString := generateToken(email, true) // Use Email as ID for now, marked as partial
+		tempTokenString := h.generateToken(email, true) // Use Email as ID for now, marked as partial
 
 		// Redirect to Frontend Callback with Signup Mode
-		c.Redirect(http.StatusTemporaryRedirect, "http://localhost:3000/auth/callback?token="+tempTokenString+"&mode=signup&email="+email)
+		c.Redirect(http.StatusTemporaryRedirect, h.frontendCallback(url.Values{"token": {tempTokenString}, "mode": {"signup"}, "email": {email}}))
 	}
 }
 
+// frontendCallback is the frontend page that finishes a Google sign-in.
+func (h *AuthHandler) frontendCallback(query url.Values) string {
+	return h.frontendURL + "/auth/callback?" + query.Encode()
+}
+
 // CompleteRegistration Request
 type CompleteRegistrationRequest struct {
 	DeptID        string `json:"dept_id"`
@@ -102,7 +114,7 @@ type CompleteRegistrationRequest struct {
 	LastName      string `json:"last_name"`
 }
 
-func CompleteRegistration(c *gin.Context) {
+func (h *AuthHandler) CompleteRegistration(c *gin.Context) {
 	// 1. Get Token from Header (MIDDLEWARE SHOULD HANDLE THIS usually, but for Onboarding logic we might need custom handling or use the AuthMiddleware but allow 'partial' tokens?)
 	// For simplicity, let's assume the client sends the 'temp_token' in Authorization header.
 	// We need to parse it here to get the 'email'.
@@ -127,9 +139,13 @@ func CompleteRegistration(c *gin.Context) {
 	// Let's implement parsing logic here for safety if middleware isn't adapted yet.
 
 	tokenString := authHeader[len("Bearer "):]
-	token, _ := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return h.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		return
+	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
@@ -155,33 +171,35 @@ func CompleteRegistration(c *gin.Context) {
 	newStudentID := "S" + time.Now().Format("20060102150405")
 
 	// Insert into DB
-	_, err := config.PostgresDB.Exec("INSERT INTO STUDENT (student_id, s_first_name, s_last_name, s_email, s_phone_no, semester, year_of_joining, dept_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
-		newStudentID, req.FirstName, req.LastName, email, req.PhoneNo, req.Semester, req.YearOfJoining, req.DeptID)
-
+	err = h.accounts.RegisterStudent(c.Request.Context(), models.Student{
+		StudentID:     newStudentID,
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Email:         email,
+		PhoneNo:       req.PhoneNo,
+		Semester:      req.Semester,
+		YearOfJoining: req.YearOfJoining,
+		DeptID:        req.DeptID,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Insert Failed: " + err.Error()})
 		return
 	}
 
 	// Generate Final Token
-	finalToken := generateToken(newStudentID, false)
+	finalToken := h.generateToken(newStudentID, false)
 
 	c.JSON(http.StatusOK, gin.H{"token": finalToken, "student_id": newStudentID})
 }
 
-func generateToken(id string, partial bool) string {
+func (h *AuthHandler) generateToken(id string, partial bool) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"student_id": id,
 		"partial":    partial,
 		"exp":        time.Now().Add(24 * time.Hour).Unix(),
 	})
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "supersecretkey"
-	}
-
-	tokenString, _ := token.SignedString([]byte(secret))
+	tokenString, _ := token.SignedString(h.secret)
 	return tokenString
 }
 
@@ -190,9 +208,8 @@ func generateStateOauthCookie(c *gin.Context) string {
 	rand.Read(b)
 	state := base64.URLEncoding.EncodeToString(b)
 
-	// Secure cookie (http-only)
-	// Domain/Path should be set correctly for localhost
-	c.SetCookie("oauthstate", state, 3600, "/", "localhost", false, true)
+	// Secure cookie (http-only), scoped to the API's own host
+	c.SetCookie("oauthstate", state, 3600, "/", "", false, true)
 
 	return state
 }
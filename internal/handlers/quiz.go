@@ -1,7 +1,9 @@
 package handlers
 
 import (
-	"academ_aide/internal/services"
+	"academ_aide/internal/ai"
+	"context"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,7 +15,21 @@ type GenerateQuizRequest struct {
 	NumQuestions int    `json:"num_questions"` // Optional, Default 5
 }
 
-func GenerateQuiz(c *gin.Context) {
+type QuizHandler struct {
+	quizService QuizGenerator
+}
+
+func NewQuizHandler(quizzes QuizGenerator) *QuizHandler {
+	return &QuizHandler{
+		quizService: quizzes,
+	}
+}
+
+// GenerateQuiz godoc
+// @Summary      Generate a quiz from a course's syllabus and materials
+// @Tags         AI
+// @Router       /quiz/generate [post]
+func (h *QuizHandler) GenerateQuiz(c *gin.Context) {
 	var req GenerateQuizRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
@@ -25,8 +41,11 @@ func GenerateQuiz(c *gin.Context) {
 		req.NumQuestions = 5
 	}
 
-	svc := services.NewQuizService()
-	quiz, err := svc.GenerateQuiz(req.CourseID, req.Unit, req.NumQuestions)
+	quiz, err := h.quizService.GenerateQuiz(c.Request.Context(), req.CourseID, req.Unit, req.NumQuestions)
+	if errors.Is(err, ai.ErrUnavailable) || errors.Is(err, context.DeadlineExceeded) {
+		respondServiceError(c, err)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"academ_aide/internal/models"
+	"academ_aide/internal/services"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeDashboard serves one known student; everyone else is not found.
+type fakeDashboard struct {
+	StudentDashboard // Methods a test does not use panic
+	cached           bool
+}
+
+func (f fakeDashboard) Profile(_ context.Context, id string) (*models.Student, error) {
+	if id != "S1" {
+		return nil, services.ErrNotFound
+	}
+	return &models.Student{StudentID: "S1", FirstName: "Asha"}, nil
+}
+
+func (f fakeDashboard) Timetable(context.Context, string) ([]models.ScheduleItem, bool, error) {
+	return []models.ScheduleItem{{CourseID: "CS101", DayOfWeek: "Monday"}}, f.cached, nil
+}
+
+func serve(h gin.HandlerFunc, userID string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		if userID != "" {
+			c.Set("user_id", userID)
+		}
+		h(c)
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w
+}
+
+func TestGetProfile(t *testing.T) {
+	h := NewStudentHandler(fakeDashboard{}, nil, nil)
+
+	for _, tc := range []struct {
+		userID string
+		status int
+	}{
+		{"S1", http.StatusOK},
+		{"S2", http.StatusNotFound},
+		{"", http.StatusUnauthorized},
+	} {
+		if w := serve(h.GetProfile, tc.userID); w.Code != tc.status {
+			t.Errorf("user %q: status %d, want %d", tc.userID, w.Code, tc.status)
+		}
+	}
+}
+
+func TestGetTimetableSource(t *testing.T) {
+	for cached, want := range map[bool]string{true: "cache", false: "database"} {
+		w := serve(NewStudentHandler(fakeDashboard{cached: cached}, nil, nil).GetTimetable, "S1")
+		var body struct {
+			Source string                `json:"source"`
+			Data   []models.ScheduleItem `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		if body.Source != want || len(body.Data) != 1 {
+			t.Errorf("cached=%v: got source %q with %d items, want %q with 1", cached, body.Source, len(body.Data), want)
+		}
+	}
+}
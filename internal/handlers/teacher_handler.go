@@ -1,17 +1,48 @@
 package handlers
 
 import (
-	"academ_aide/internal/config"
-	"database/sql"
+	"academ_aide/internal/models"
+	"academ_aide/internal/services"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-type TeacherHandler struct{}
+type TeacherHandler struct {
+	facultyService    FacultyDashboard
+	gradingService    Gradebook
+	riskService       RiskReader
+	alertService      AlertInbox
+	attendanceService AttendanceRecorder
+	questionService   QuestionBoard
+	feedbackService   FeedbackReporter
+	personaService    CoursePersonaEditor
+}
+
+// TeacherDeps are the services behind the teacher dashboard.
+type TeacherDeps struct {
+	Faculty    FacultyDashboard
+	Grading    Gradebook
+	Risk       RiskReader
+	Alerts     AlertInbox
+	Attendance AttendanceRecorder
+	Questions  QuestionBoard
+	Feedback   FeedbackReporter
+	Personas   CoursePersonaEditor
+}
 
-func NewTeacherHandler() *TeacherHandler {
-	return &TeacherHandler{}
+func NewTeacherHandler(d TeacherDeps) *TeacherHandler {
+	return &TeacherHandler{
+		facultyService:    d.Faculty,
+		gradingService:    d.Grading,
+		riskService:       d.Risk,
+		alertService:      d.Alerts,
+		attendanceService: d.Attendance,
+		questionService:   d.Questions,
+		feedbackService:   d.Feedback,
+		personaService:    d.Personas,
+	}
 }
 
 // GetMyCourses godoc
@@ -20,26 +51,11 @@ func NewTeacherHandler() *TeacherHandler {
 // @Tags         Teacher
 // @Router       /teacher/courses [get]
 func (h *TeacherHandler) GetMyCourses(c *gin.Context) {
-	facultyID := c.GetString("user_id") // Authenticated Faculty ID
-	rows, err := config.PostgresDB.Query(`
-		SELECT c.course_id, c.title, t.section_name 
-		FROM TEACHES t
-		JOIN COURSE c ON t.course_id = c.course_id
-		WHERE t.faculty_id=$1
-	`, facultyID)
+	courses, err := h.facultyService.Courses(c.Request.Context(), c.GetString("user_id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
 		return
 	}
-	defer rows.Close()
-
-	var courses []gin.H
-	for rows.Next() {
-		var id, title, section string
-		if err := rows.Scan(&id, &title, &section); err == nil {
-			courses = append(courses, gin.H{"course_id": id, "title": title, "section": section})
-		}
-	}
 	c.JSON(http.StatusOK, courses)
 }
 
@@ -50,30 +66,11 @@ func (h *TeacherHandler) GetMyCourses(c *gin.Context) {
 // @Param        course_id query string true "Course ID"
 // @Router       /teacher/students [get]
 func (h *TeacherHandler) GetEnrolledStudents(c *gin.Context) {
-	courseID := c.Query("course_id")
-	rows, err := config.PostgresDB.Query(`
-		SELECT s.student_id, s.s_first_name, s.s_last_name, s.s_email
-		FROM ENROLLS_IN e
-		JOIN STUDENT s ON e.student_id = s.student_id
-		WHERE e.course_id=$1
-	`, courseID)
+	students, err := h.facultyService.EnrolledStudents(c.Request.Context(), c.Query("course_id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
 		return
 	}
-	defer rows.Close()
-
-	var students []gin.H
-	for rows.Next() {
-		var id, fname, lname, email string
-		if err := rows.Scan(&id, &fname, &lname, &email); err == nil {
-			students = append(students, gin.H{
-				"student_id": id,
-				"name":       fname + " " + lname,
-				"email":      email,
-			})
-		}
-	}
 	c.JSON(http.StatusOK, students)
 }
 
@@ -84,65 +81,54 @@ func (h *TeacherHandler) GetClassHealth(c *gin.Context) {
 		return
 	}
 
-	var title string
-	err := config.PostgresDB.QueryRow("SELECT title FROM COURSE WHERE course_id=$1", courseID).Scan(&title)
-	if err == sql.ErrNoRows {
+	health, err := h.facultyService.ClassHealth(c.Request.Context(), courseID)
+	if errors.Is(err, services.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
 		return
 	} else if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
 		return
 	}
-
-	// Dynamic Grades
-	rows, err := config.PostgresDB.Query("SELECT grade FROM ENROLLS_IN WHERE course_id=$1 AND grade IS NOT NULL", courseID)
-	perfMap := map[string]int{"Excellent (A/A+)": 0, "Good (B/B+)": 0, "Average (C/C+)": 0, "Poor (D/F)": 0}
-
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var g string
-			if err := rows.Scan(&g); err == nil {
-				switch g {
-				case "O", "A+", "A":
-					perfMap["Excellent (A/A+)"]++
-				case "B+", "B":
-					perfMap["Good (B/B+)"]++
-				case "C+", "C":
-					perfMap["Average (C/C+)"]++
-				case "D", "F":
-					perfMap["Poor (D/F)"]++
-				}
-			}
-		}
-	}
-
-	healthData := gin.H{
-		"course_id": courseID,
-		"title":     title,
-		// Attendance still mocked as we lack attendance table
-		"attendance_distribution": gin.H{"90-100%": 12, "75-90%": 8, "60-75%": 3, "<60%": 1},
-		"performance_heatmap":     perfMap,
-	}
-	c.JSON(http.StatusOK, healthData)
+	c.JSON(http.StatusOK, health)
 }
 
+// GetAtRiskStudents godoc
+// @Summary      Get At-Risk Students
+// @Description  Returns risk level counts and the high/medium risk students of a course with their contributing factors. The teacher must teach the course.
+// @Tags         Teacher
+// @Param        course_id query string true "Course ID"
+// @Router       /teacher/at-risk [get]
 func (h *TeacherHandler) GetAtRiskStudents(c *gin.Context) {
 	courseID := c.Query("course_id")
-	// Dynamic Risk Count (Based on D/F grades)
-	var highRisk int
-	config.PostgresDB.QueryRow("SELECT COUNT(*) FROM ENROLLS_IN WHERE course_id=$1 AND grade IN ('D', 'F')", courseID).Scan(&highRisk)
+	if courseID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "course_id is required"})
+		return
+	}
+	ctx := c.Request.Context()
+	facultyID := c.GetString("user_id")
+
+	counts, err := h.riskService.LevelCounts(ctx, facultyID, courseID)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	students, err := h.riskService.CourseRisks(ctx, facultyID, courseID, services.RiskHigh, services.RiskMedium)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"high_risk_count":   highRisk,
-		"medium_risk_count": 2,  // Mocked
-		"low_risk_count":    20, // Mocked
+		"high_risk_count":   counts[services.RiskHigh],
+		"medium_risk_count": counts[services.RiskMedium],
+		"low_risk_count":    counts[services.RiskLow],
+		"students":          students,
 	})
 }
 
 // GetStudentDetails godoc
 // @Summary      Get Student Details
-// @Description  Returns detailed info for a student including grade and risk status
+// @Description  Returns detailed info for a student including grade and risk status. The teacher must teach the course.
 // @Tags         Teacher
 // @Param        student_id query string true "Student ID"
 // @Param        course_id query string true "Course ID"
@@ -150,34 +136,47 @@ func (h *TeacherHandler) GetAtRiskStudents(c *gin.Context) {
 func (h *TeacherHandler) GetStudentDetails(c *gin.Context) {
 	studentID := c.Query("student_id")
 	courseID := c.Query("course_id")
+	ctx := c.Request.Context()
 
-	var fname, lname, email string
-	err := config.PostgresDB.QueryRow("SELECT s_first_name, s_last_name, s_email FROM STUDENT WHERE student_id=$1", studentID).Scan(&fname, &lname, &email)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+	// Also checks that the teacher teaches the course. Scores are stored by the risk
+	// scoring job, so an enrollment is "Not Scored" until its next run.
+	risk, err := h.riskService.StudentRisk(ctx, c.GetString("user_id"), studentID, courseID)
+	if err != nil && !errors.Is(err, services.ErrNotFound) {
+		respondServiceError(c, err)
 		return
 	}
 
-	var grade sql.NullString
-	config.PostgresDB.QueryRow("SELECT grade FROM ENROLLS_IN WHERE student_id=$1 AND course_id=$2", studentID, courseID).Scan(&grade)
-
-	currentGrade := "N/A"
-	if grade.Valid {
-		currentGrade = grade.String
+	standing, err := h.facultyService.StudentStanding(ctx, studentID, courseID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Student not found"})
+		return
 	}
 
-	riskStatus := "No Risk"
-	if currentGrade == "D" || currentGrade == "F" {
-		riskStatus = "High Risk"
+	riskStatus := "Not Scored"
+	var riskScore interface{}
+	riskFactors := []models.RiskFactor{}
+	if risk != nil {
+		switch risk.Level {
+		case services.RiskHigh:
+			riskStatus = "High Risk"
+		case services.RiskMedium:
+			riskStatus = "Medium Risk"
+		default:
+			riskStatus = "Low Risk"
+		}
+		riskScore = risk.Score
+		riskFactors = risk.Factors
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"student_id":    studentID,
-		"name":          fname + " " + lname,
-		"email":         email,
+		"name":          standing.Name,
+		"email":         standing.Email,
 		"course_id":     courseID,
-		"current_grade": currentGrade,
+		"current_grade": standing.Grade,
 		"risk_status":   riskStatus,
+		"risk_score":    riskScore,
+		"risk_factors":  riskFactors,
 		"attendance":    "85%",                          // Mocked
 		"last_active":   "2 days ago",                   // Mocked
 		"other_courses": []string{"CS354TA", "IS353IA"}, // Mocked
@@ -200,41 +199,147 @@ func (h *TeacherHandler) PostAnnouncement(c *gin.Context) {
 	}
 	facultyID := c.GetString("user_id")
 
-	_, err := config.PostgresDB.Exec("INSERT INTO ANNOUNCEMENT (faculty_id, course_id, content) VALUES ($1, $2, $3)", facultyID, req.CourseID, req.Content)
-	if err != nil {
+	if err := h.facultyService.PostAnnouncement(c.Request.Context(), facultyID, req.CourseID, req.Content); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to post announcement"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Announcement posted successfully"})
 }
 
-// GetAlerts (Unchanged mostly, but validated)
+// GetAlerts godoc
+// @Summary      Get Alerts
+// @Description  Returns the logged-in faculty's early-warning alerts, newest first
+// @Tags         Teacher
+// @Param        course_id query string false "Course ID"
+// @Param        severity query string false "high | medium | low"
+// @Param        status query string false "active (default) | unread | read | dismissed | all"
+// @Param        limit query int false "Page size (default 50, max 200)"
+// @Param        offset query int false "Offset"
+// @Router       /teacher/alerts [get]
 func (h *TeacherHandler) GetAlerts(c *gin.Context) {
-	userID := c.GetString("user_id")
-	rows, err := config.PostgresDB.Query("SELECT course_id FROM TEACHES WHERE faculty_id=$1", userID)
+	limit, offset := pagination(c)
+	filter := services.AlertFilter{
+		CourseID: c.Query("course_id"),
+		Severity: c.Query("severity"),
+		Status:   c.Query("status"),
+	}
+	alerts, total, unread, err := h.alertService.List(c.Request.Context(), c.GetString("user_id"), filter, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		respondServiceError(c, err)
 		return
 	}
-	defer rows.Close()
-	var alerts []string
-	for rows.Next() {
-		var cID string
-		rows.Scan(&cID)
-		// Mock logic but tied to course
-		alerts = append(alerts, "Update for "+cID+": Check student progress.")
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts, "total": total, "unread": unread, "limit": limit, "offset": offset})
+}
+
+// MarkAlertRead godoc
+// @Summary      Mark Alert Read
+// @Tags         Teacher
+// @Param        id path int true "Alert ID"
+// @Router       /teacher/alerts/{id}/read [post]
+func (h *TeacherHandler) MarkAlertRead(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.alertService.MarkRead(c.Request.Context(), c.GetString("user_id"), id); err != nil {
+		respondServiceError(c, err)
+		return
 	}
-	// Check for recent announcements
-	var count int
-	config.PostgresDB.QueryRow("SELECT COUNT(*) FROM ANNOUNCEMENT WHERE faculty_id=$1 AND created_at > NOW() - INTERVAL '1 day'", userID).Scan(&count)
-	if count > 0 {
-		alerts = append(alerts, "You posted "+string(rune(count))+" announcements recently.")
+	c.JSON(http.StatusOK, gin.H{"message": "Alert marked as read"})
+}
+
+// DismissAlert godoc
+// @Summary      Dismiss Alert
+// @Tags         Teacher
+// @Param        id path int true "Alert ID"
+// @Router       /teacher/alerts/{id}/dismiss [post]
+func (h *TeacherHandler) DismissAlert(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.alertService.Dismiss(c.Request.Context(), c.GetString("user_id"), id); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert dismissed"})
+}
+
+// MarkAllAlertsRead godoc
+// @Summary      Mark All Alerts Read
+// @Tags         Teacher
+// @Param        course_id query string false "Course ID"
+// @Router       /teacher/alerts/read-all [post]
+func (h *TeacherHandler) MarkAllAlertsRead(c *gin.Context) {
+	n, err := h.alertService.MarkAllRead(c.Request.Context(), c.GetString("user_id"), c.Query("course_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"updated": n})
+}
 
-	if len(alerts) == 0 {
-		alerts = append(alerts, "No immediate alerts.")
+// RecordAttendance godoc
+// @Summary      Record Attendance
+// @Description  Records attendance for one class date; re-submitting a date corrects it
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/attendance [post]
+func (h *TeacherHandler) RecordAttendance(c *gin.Context) {
+	var req struct {
+		Date    string                    `json:"date"`
+		Records []models.AttendanceRecord `json:"records"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.attendanceService.Record(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), req.Date, req.Records); err != nil {
+		respondServiceError(c, err)
+		return
 	}
-	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+	c.JSON(http.StatusOK, gin.H{"message": "Attendance recorded"})
+}
+
+// GetQuestions godoc
+// @Summary      Get Student Questions
+// @Description  Returns questions students asked in a course
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Param        status query string false "open (default) | all"
+// @Router       /teacher/courses/{course_id}/questions [get]
+func (h *TeacherHandler) GetQuestions(c *gin.Context) {
+	openOnly := c.DefaultQuery("status", "open") == "open"
+	questions, err := h.questionService.ListForTeacher(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), openOnly)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, questions)
+}
+
+// AnswerQuestion godoc
+// @Summary      Answer Student Question
+// @Tags         Teacher
+// @Param        id path int true "Question ID"
+// @Router       /teacher/questions/{id}/answer [post]
+func (h *TeacherHandler) AnswerQuestion(c *gin.Context) {
+	id, ok := intParam(c, "id")
+	if !ok {
+		return
+	}
+	var req struct {
+		Answer string `json:"answer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	if err := h.questionService.Answer(c.Request.Context(), c.GetString("user_id"), id, req.Answer); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Answer posted"})
 }
 
 // GetProfile godoc
@@ -243,55 +348,241 @@ func (h *TeacherHandler) GetAlerts(c *gin.Context) {
 // @Tags         Teacher
 // @Router       /teacher/profile [get]
 func (h *TeacherHandler) GetProfile(c *gin.Context) {
-	facultyID := c.GetString("user_id")
+	f, err := h.facultyService.Profile(c.Request.Context(), c.GetString("user_id"))
+	if errors.Is(err, services.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Faculty not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+		return
+	}
+	c.JSON(http.StatusOK, f)
+}
+
+// --- Grading ---
 
-	var f struct {
-		FacultyID string `json:"faculty_id"`
-		FirstName string `json:"first_name"`
-		LastName  string `json:"last_name"`
-		Email     string `json:"email"`
-		PhoneNo   string `json:"phone_no"`
+// GetAssessmentScheme godoc
+// @Summary      Get Assessment Scheme
+// @Description  Returns the weighted assessment components of a course
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/assessment-scheme [get]
+func (h *TeacherHandler) GetAssessmentScheme(c *gin.Context) {
+	comps, err := h.gradingService.GetScheme(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
 	}
+	c.JSON(http.StatusOK, comps)
+}
 
-	err := config.PostgresDB.QueryRow(`
-		SELECT faculty_id, f_first_name, f_last_name, f_email, f_phone_no
-		FROM FACULTY WHERE faculty_id=$1
-	`, facultyID).Scan(&f.FacultyID, &f.FirstName, &f.LastName, &f.Email, &f.PhoneNo)
+// SetAssessmentScheme godoc
+// @Summary      Set Assessment Scheme
+// @Description  Replaces the course's components (internal tests, assignments, labs, final). Weights must total 100.
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/assessment-scheme [put]
+func (h *TeacherHandler) SetAssessmentScheme(c *gin.Context) {
+	var req struct {
+		Components []models.AssessmentComponent `json:"components"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	comps, err := h.gradingService.SetScheme(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), req.Components)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, comps)
+}
 
-	if err == sql.ErrNoRows {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Faculty not found"})
+// EnterMarks godoc
+// @Summary      Enter Marks
+// @Description  Records marks for one component for a batch of students and recomputes their draft grades
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Param        component_id path int true "Component ID"
+// @Router       /teacher/courses/{course_id}/components/{component_id}/marks [put]
+func (h *TeacherHandler) EnterMarks(c *gin.Context) {
+	componentID, ok := intParam(c, "component_id")
+	if !ok {
 		return
-	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "DB Error"})
+	}
+	var req struct {
+		Entries []models.MarkEntry `json:"entries"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
+	if err := h.gradingService.EnterMarks(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), componentID, req.Entries); err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Marks saved"})
+}
 
-	// Get Affiliated Departments
-	rows, err := config.PostgresDB.Query(`
-		SELECT DISTINCT d.dept_name
-		FROM TEACHES t
-		JOIN COURSE c ON t.course_id = c.course_id
-		JOIN DEPARTMENT d ON c.dept_id = d.dept_id
-		WHERE t.faculty_id=$1
-	`, facultyID)
-
-	var departments []string
-	if err == nil {
-		defer rows.Close()
-		for rows.Next() {
-			var dName string
-			if err := rows.Scan(&dName); err == nil {
-				departments = append(departments, dName)
-			}
+// GetGradebook godoc
+// @Summary      Get Gradebook
+// @Description  Returns marks, computed draft grade and published grade for every enrolled student
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/gradebook [get]
+func (h *TeacherHandler) GetGradebook(c *gin.Context) {
+	book, err := h.gradingService.Gradebook(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, book)
+}
+
+// gradeSelection is the optional body of publish/unpublish; no IDs means the whole course.
+type gradeSelection struct {
+	StudentIDs []string `json:"student_ids"`
+}
+
+// PublishGrades godoc
+// @Summary      Publish Grades
+// @Description  Makes draft grades visible to students. Students with missing marks are reported as incomplete.
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/grades/publish [post]
+func (h *TeacherHandler) PublishGrades(c *gin.Context) {
+	var req gradeSelection
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
 		}
 	}
+	result, err := h.gradingService.Publish(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), req.StudentIDs)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"faculty_id":  f.FacultyID,
-		"first_name":  f.FirstName,
-		"last_name":   f.LastName,
-		"email":       f.Email,
-		"phone_no":    f.PhoneNo,
-		"departments": departments,
-	})
+// UnpublishGrades godoc
+// @Summary      Unpublish Grades
+// @Description  Withdraws published grades so they can be corrected
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/grades/unpublish [post]
+func (h *TeacherHandler) UnpublishGrades(c *gin.Context) {
+	var req gradeSelection
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	withdrawn, err := h.gradingService.Unpublish(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), req.StudentIDs)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unpublished": withdrawn})
+}
+
+// GetGradeAudit godoc
+// @Summary      Grade Audit Log
+// @Description  Returns scheme edits, mark changes and publications for a course, newest first
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Param        limit query int false "Page size (default 50, max 200)"
+// @Param        offset query int false "Offset"
+// @Router       /teacher/courses/{course_id}/grade-audit [get]
+func (h *TeacherHandler) GetGradeAudit(c *gin.Context) {
+	limit, offset := pagination(c)
+	entries, err := h.gradingService.ListAudit(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), limit, offset)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "limit": limit, "offset": offset})
+}
+
+// GetFeedbackReport godoc
+// @Summary      Answer Quality Report
+// @Description  Aggregates students' ratings of chat answers grounded in the teacher's courses
+// @Description  by course, agent and source file, with recent negative comments.
+// @Tags         Teacher
+// @Param        course_id query string false "Course ID"
+// @Param        from query string false "From date (YYYY-MM-DD)"
+// @Param        to query string false "To date (YYYY-MM-DD, inclusive)"
+// @Router       /teacher/feedback-report [get]
+func (h *TeacherHandler) GetFeedbackReport(c *gin.Context) {
+	report, err := h.feedbackService.Report(c.Request.Context(), c.GetString("user_id"), feedbackFilter(c))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func feedbackFilter(c *gin.Context) services.FeedbackFilter {
+	return services.FeedbackFilter{
+		CourseID: c.Query("course_id"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	}
+}
+
+// GetCoursePersonas godoc
+// @Summary      List Course Personas
+// @Description  Chat agents defined for one of the teacher's courses, including inactive ones.
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/personas [get]
+func (h *TeacherHandler) GetCoursePersonas(c *gin.Context) {
+	personas, err := h.personaService.CoursePersonas(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"))
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, personas)
+}
+
+// CreateCoursePersona godoc
+// @Summary      Create Course Persona
+// @Description  Adds a chat agent only the course's students (roles default to ["student"]) can use.
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Router       /teacher/courses/{course_id}/personas [post]
+func (h *TeacherHandler) CreateCoursePersona(c *gin.Context) {
+	req, ok := bindPersona(c)
+	if !ok {
+		return
+	}
+	persona, err := h.personaService.CreateCoursePersona(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, persona)
+}
+
+// UpdateCoursePersona godoc
+// @Summary      Update Course Persona
+// @Description  Stores the definition as the persona's next version.
+// @Tags         Teacher
+// @Param        course_id path string true "Course ID"
+// @Param        id path string true "Agent ID"
+// @Router       /teacher/courses/{course_id}/personas/{id} [put]
+func (h *TeacherHandler) UpdateCoursePersona(c *gin.Context) {
+	req, ok := bindPersona(c)
+	if !ok {
+		return
+	}
+	req.ID = c.Param("id")
+	persona, err := h.personaService.UpdateCoursePersona(c.Request.Context(), c.GetString("user_id"), c.Param("course_id"), req)
+	if err != nil {
+		respondServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, persona)
 }
@@ -0,0 +1,98 @@
+// Package health runs the readiness checks behind /readyz: one probe per dependency,
+// run concurrently under a shared deadline.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Statuses of a check and of the server as a whole.
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"    // A non-critical dependency is down
+	StatusUnavailable = "unavailable" // A critical dependency is down
+)
+
+// Check probes one dependency. The server cannot serve without a Critical one; without
+// any other it still answers, in part (chat falls back to its degraded mode while the
+// model or embeddings are down).
+type Check struct {
+	Name     string
+	Critical bool
+	Probe    func(ctx context.Context) error
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Checker runs a fixed set of checks.
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+}
+
+// NewChecker runs checks with timeout as the deadline for all of them.
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, timeout: timeout}
+}
+
+// Run probes every dependency concurrently. A check that is still running at the
+// deadline has failed.
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := probe(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = res
+			switch {
+			case res.Status == StatusOK:
+			case check.Critical:
+				report.Status = StatusUnavailable
+			case report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func probe(ctx context.Context, check Check) Result {
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- check.Probe(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	res := Result{Status: StatusOK, LatencyMS: float64(time.Since(start).Microseconds()) / 1000}
+	if err != nil {
+		res.Status, res.Error = StatusUnavailable, err.Error()
+	}
+	return res
+}
@@ -0,0 +1,40 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunGradesFailuresByCriticality(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error { <-ctx.Done(); time.Sleep(time.Second); return nil }
+
+	cases := []struct {
+		name   string
+		checks []Check
+		want   string
+	}{
+		{"all up", []Check{{"postgres", true, up}, {"llm", false, up}}, StatusOK},
+		{"optional down", []Check{{"postgres", true, up}, {"llm", false, down}}, StatusDegraded},
+		{"critical down", []Check{{"postgres", true, down}, {"llm", false, down}}, StatusUnavailable},
+		{"critical hangs", []Check{{"redis", true, hang}}, StatusUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			start := time.Now()
+			report := NewChecker(50*time.Millisecond, tc.checks...).Run(context.Background())
+			if report.Status != tc.want {
+				t.Errorf("status = %q, want %q (%v)", report.Status, tc.want, report.Checks)
+			}
+			if len(report.Checks) != len(tc.checks) {
+				t.Errorf("got %d results for %d checks", len(report.Checks), len(tc.checks))
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Run waited %s for a hung probe", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Package logging sets up the server's structured logs: JSON (or text) records from
+// log/slog at the configured level, tagged with the request ID and user of the request
+// that produced them, and with personal data removed.
+//
+// Code logs through slog with a context where it has one, so records can be traced to
+// their request:
+//
+//	slog.WarnContext(ctx, "Brief cache read failed", "error", err)
+package logging
+
+import (
+	"academ_aide/internal/config"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup makes a logger writing to stderr the default, for slog and the standard log
+// package alike, and returns it.
+func Setup(cfg config.Log) *slog.Logger {
+	logger := New(os.Stderr, cfg)
+	slog.SetDefault(logger)
+	return logger
+}
+
+// New returns a logger writing to w.
+func New(w io.Writer, cfg config.Log) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level(cfg.Level)}
+	var h slog.Handler
+	if cfg.Format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&contextHandler{redactHandler{h}})
+}
+
+func level(name string) slog.Level {
+	switch name {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	userIDKey
+)
+
+// WithRequestID returns ctx carrying the ID of the request it serves.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithUserID returns ctx carrying the authenticated user of its request.
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// contextHandler adds the request ID, user and trace carried by a record's context, so
+// a log line leads to its trace and back.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if id, _ := ctx.Value(userIDKey).(string); id != "" {
+			r.AddAttrs(slog.String("user_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,78 @@
+package logging
+
+import (
+	"academ_aide/internal/config"
+	"academ_aide/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRecordsAreTaggedAndRedacted(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, config.Log{Level: "info", Format: "json"})
+	ctx := WithUserID(WithRequestID(context.Background(), "req-1"), "S1001")
+
+	total := 91.5
+	logger.InfoContext(ctx, "Reply to asha@example.edu: your CGPA is 8.7",
+		"error", errors.New("calling 9876543210 failed"),
+		"grade", "A+",
+		"grades", []models.StudentGrade{{CourseID: "CS101", Grade: "A", Total: &total}},
+	)
+	logger.DebugContext(ctx, "below the level")
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("want one JSON record, got %q: %v", buf.String(), err)
+	}
+	if rec["request_id"] != "req-1" || rec["user_id"] != "S1001" {
+		t.Errorf("record not tagged with its request: %v", rec)
+	}
+	if got := rec["msg"]; got != "Reply to [redacted]: your CGPA is [redacted]" {
+		t.Errorf("msg = %q", got)
+	}
+	if got := rec["error"]; got != "calling [redacted] failed" {
+		t.Errorf("error = %q", got)
+	}
+	if rec["grade"] != redacted || rec["grades"] != redacted {
+		t.Errorf("grades logged: grade %v, grades %v", rec["grade"], rec["grades"])
+	}
+	if strings.Contains(buf.String(), "below the level") {
+		t.Error("debug record written at info level")
+	}
+}
+
+func TestRedactKeepsNestedFieldsApart(t *testing.T) {
+	total := 72.0
+	got := redactAny(models.StudentGrade{CourseID: "CS101", Title: "Data Structures", Grade: "B", Total: &total})
+	fields, ok := got.(map[string]interface{})
+	if !ok {
+		t.Fatalf("redactAny returned %T", got)
+	}
+	if fields["course_id"] != "CS101" || fields["grade"] != redacted || fields["total"] != redacted {
+		t.Errorf("redactAny = %v", fields)
+	}
+}
+
+func TestRecordsCarryTheTrace(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, config.Log{Level: "info", Format: "json"})
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+	})
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), sc), "Model call failed")
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("want one JSON record, got %q: %v", buf.String(), err)
+	}
+	if rec["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || rec["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("record not tagged with its trace: %v", rec)
+	}
+}
@@ -0,0 +1,124 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// sensitiveKeys are attribute and JSON field names whose values are never logged.
+var sensitiveKeys = map[string]bool{
+	"email": true, "f_email": true, "phone": true, "phone_no": true, "f_phone_no": true,
+	"grade": true, "grades": true, "cgpa": true, "sgpa": true, "gpa": true,
+	"marks": true, "total": true, "components": true, "score": true,
+	"password": true, "token": true, "authorization": true,
+}
+
+var (
+	emailPattern = regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)
+	phonePattern = regexp.MustCompile(`(?:\+91[\s-]?)?\b[6-9]\d{9}\b|\b\d{3}-\d{4}\b`)
+	// "grade A", "CGPA: 8.5", "marks of 72" and the like; the label is kept.
+	gradePattern = regexp.MustCompile(`(?i)\b(c?gpa|sgpa|grades?|marks?|score|total)(\s+(?:is|of|was))?(\s*[:=]?\s*\**)([A-F][+-]?|O|\d{1,3}(?:\.\d+)?)\b`)
+)
+
+// Redact removes emails, phone numbers and grades from text.
+func Redact(text string) string {
+	text = emailPattern.ReplaceAllString(text, redacted)
+	text = phonePattern.ReplaceAllString(text, redacted)
+	return gradePattern.ReplaceAllString(text, "$1$2$3"+redacted)
+}
+
+// redactHandler removes personal data from a record's message and attributes before
+// the wrapped handler sees them.
+type redactHandler struct {
+	slog.Handler
+}
+
+func (h redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, clean)
+}
+
+func (h redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return redactHandler{h.Handler.WithAttrs(clean)}
+}
+
+func (h redactHandler) WithGroup(name string) slog.Handler {
+	return redactHandler{h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redacted)
+	}
+	v := a.Value.Resolve()
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		clean := make([]any, len(group))
+		for i, g := range group {
+			clean[i] = redactAttr(g)
+		}
+		return slog.Group(a.Key, clean...)
+	case slog.KindAny:
+		return slog.Any(a.Key, redactAny(v.Any()))
+	}
+	return slog.Attr{Key: a.Key, Value: v}
+}
+
+// redactAny turns errors and other text into redacted strings, and values that encode
+// as JSON objects (models, maps) into redacted maps, so their sensitive fields go too.
+func redactAny(v any) any {
+	switch t := v.(type) {
+	case error:
+		return Redact(t.Error())
+	case fmt.Stringer:
+		return Redact(t.String())
+	case []byte:
+		return Redact(string(t))
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Redact(fmt.Sprint(v))
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return Redact(string(data))
+	}
+	return redactJSON(decoded)
+}
+
+func redactJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, field := range t {
+			if sensitiveKeys[strings.ToLower(k)] {
+				t[k] = redacted
+			} else {
+				t[k] = redactJSON(field)
+			}
+		}
+	case []any:
+		for i, item := range t {
+			t[i] = redactJSON(item)
+		}
+	case string:
+		return Redact(t)
+	}
+	return v
+}
@@ -0,0 +1,118 @@
+// Package metrics holds the server's Prometheus metrics, served on /metrics.
+//
+// Cache hit ratios are left to the query, e.g.
+//
+//	sum by (cache) (rate(academ_aide_cache_requests_total{result="hit"}[5m]))
+//	  / sum by (cache) (rate(academ_aide_cache_requests_total[5m]))
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "academ_aide"
+
+// Registry holds every metric below plus the Go runtime and process collectors.
+var Registry = prometheus.NewRegistry()
+
+// modelBuckets cover a fast classification call up to a long generation.
+var modelBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 40, 80}
+
+var (
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to serve an HTTP request, by route template.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 180},
+	}, []string{"method", "route", "status"})
+
+	LLMRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "llm_request_duration_seconds",
+		Help:      "Time of one chat model call (each retry counts), by agent and model.",
+		Buckets:   modelBuckets,
+	}, []string{"agent", "model", "outcome"})
+
+	LLMTokens = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "llm_tokens_total",
+		Help:      "Tokens the chat model reported reading (prompt) and writing (completion).",
+	}, []string{"agent", "model", "kind"})
+
+	EmbeddingDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "embedding_request_duration_seconds",
+		Help:      "Time of one embedding call.",
+		Buckets:   modelBuckets,
+	}, []string{"model", "outcome"})
+
+	VectorSearchDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "vector_search_duration_seconds",
+		Help:      "Time of one pgvector search over course material chunks.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by cache and result (hit or miss).",
+	}, []string{"cache", "result"})
+
+	ChatContextDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "chat_context_duration_seconds",
+		Help:      "Time from receiving a chat message to the first model call.",
+		Buckets:   prometheus.DefBuckets,
+	})
+)
+
+// Caches (the cache label)
+const (
+	CacheProfile   = "profile"
+	CacheTimetable = "timetable"
+	CacheBrief     = "chat_brief"
+	CacheResponse  = "response"
+	CacheSemantic  = "semantic"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration, LLMRequestDuration, LLMTokens, EmbeddingDuration,
+		VectorSearchDuration, CacheRequests, ChatContextDuration,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Outcome is the outcome label of a call that returned err.
+func Outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// CacheLookup counts a hit or miss of cache.
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
+
+// Since observes the time elapsed since start.
+func Since(o prometheus.Observer, start time.Time) {
+	o.Observe(time.Since(start).Seconds())
+}
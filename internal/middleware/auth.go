@@ -1,16 +1,18 @@
 package middleware
 
 import (
+	"academ_aide/internal/logging"
 	"fmt"
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware accepts requests carrying a JWT signed with secret and puts its user
+// ID and role in the context.
+func AuthMiddleware(secret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -28,11 +30,6 @@ func AuthMiddleware() gin.HandlerFunc {
 		}
 
 		tokenString := parts[1]
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			// Fallback or error - for now fallback to hardcoded for dev safety if env not loaded right, but better to error
-			secret = "supersecretkey"
-		}
 
 		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -74,6 +71,11 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Logs written while serving the request name its user
+		if userID := c.GetString("user_id"); userID != "" {
+			c.Request = c.Request.WithContext(logging.WithUserID(c.Request.Context(), userID))
+		}
+
 		c.Next()
 	}
 }
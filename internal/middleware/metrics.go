@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"academ_aide/internal/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records each request's latency by method, route template and status.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, routeOf(c), strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Tracing opens a span per request, named after its route and continuing the caller's
+// trace if it sent a traceparent header. Probes and scrapes are not traced.
+func Tracing(service string) gin.HandlerFunc {
+	return otelgin.Middleware(service, otelgin.WithGinFilter(func(c *gin.Context) bool {
+		return !quietRoutes[c.FullPath()]
+	}))
+}
@@ -0,0 +1,307 @@
+// Package migrate applies the numbered SQL migrations in migrations/ to Postgres.
+//
+// Each migration is a pair of files, NNNN_name.up.sql and NNNN_name.down.sql, embedded
+// in the binary. Applied versions are recorded in schema_migrations with the checksum
+// of their up file; editing a migration after it was applied is an error, so a change
+// to the schema always means a new migration.
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var files embed.FS
+
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // sha256 of Up
+}
+
+// Status is a migration and whether it has been applied.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Modified  bool // Applied with a different checksum
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load reads the embedded migrations, ordered by version.
+func Load() ([]Migration, error) {
+	return load(files, "migrations")
+}
+
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, e := range entries {
+		m := fileName.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.up.sql or NNNN_name.down.sql", e.Name())
+		}
+		version, _ := strconv.Atoi(m[1])
+		body, err := fs.ReadFile(fsys, dir+"/"+e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migration %d is named both %q and %q", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+			sum := sha256.Sum256(body)
+			mig.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator runs migrations against one database. Every run holds a Postgres advisory
+// lock, so servers starting together do not migrate twice.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// lockID identifies the migration advisory lock. Any fixed number works.
+const lockID = 7243851609
+
+const createTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`
+
+type applied struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// withLock runs fn on a connection holding the migration lock.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, done map[int]applied) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("acquiring migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockID)
+
+	if _, err := conn.ExecContext(ctx, createTable); err != nil {
+		return fmt.Errorf("creating schema_migrations: %w", err)
+	}
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return err
+	}
+	done := map[int]applied{}
+	for rows.Next() {
+		var version int
+		var a applied
+		if err := rows.Scan(&version, &a.checksum, &a.appliedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		done[version] = a
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	return fn(conn, done)
+}
+
+// verify refuses to run when an applied migration was edited or is unknown to this binary.
+func (m *Migrator) verify(done map[int]applied) error {
+	known := map[int]bool{}
+	for _, mig := range m.migrations {
+		known[mig.Version] = true
+		if a, ok := done[mig.Version]; ok && a.checksum != mig.Checksum {
+			return fmt.Errorf("migration %04d_%s was changed after it was applied; add a new migration instead", mig.Version, mig.Name)
+		}
+	}
+	for version := range done {
+		if !known[version] {
+			return fmt.Errorf("database has migration %04d, which this build does not know; deploy a newer build", version)
+		}
+	}
+	return nil
+}
+
+// run executes a migration file and its bookkeeping in one transaction.
+func run(ctx context.Context, conn *sql.Conn, body, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, body); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func apply(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	slog.InfoContext(ctx, "Migrating up", "version", mig.Version, "name", mig.Name)
+	err := run(ctx, conn, mig.Up,
+		"INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		mig.Version, mig.Name, mig.Checksum)
+	if err != nil {
+		return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+func revert(ctx context.Context, conn *sql.Conn, mig Migration) error {
+	slog.InfoContext(ctx, "Migrating down", "version", mig.Version, "name", mig.Name)
+	if err := run(ctx, conn, mig.Down, "DELETE FROM schema_migrations WHERE version = $1", mig.Version); err != nil {
+		return fmt.Errorf("reverting %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+// Up applies every pending migration in order and returns those it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var ran []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, done map[int]applied) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, mig); err != nil {
+				return err
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// newest returns up to n applied migrations, newest first.
+func (m *Migrator) newest(done map[int]applied, n int) []Migration {
+	var out []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(out) < n; i-- {
+		if _, ok := done[m.migrations[i].Version]; ok {
+			out = append(out, m.migrations[i])
+		}
+	}
+	return out
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns them.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var ran []Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, done map[int]applied) error {
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		for _, mig := range m.newest(done, steps) {
+			if err := revert(ctx, conn, mig); err != nil {
+				return err
+			}
+			ran = append(ran, mig)
+		}
+		return nil
+	})
+	return ran, err
+}
+
+// Redo reverts the newest applied migration and applies it again, for iterating on
+// a migration that has not been shared yet.
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.withLock(ctx, func(conn *sql.Conn, done map[int]applied) error {
+		last := m.newest(done, 1)
+		if len(last) == 0 {
+			return fmt.Errorf("no migration has been applied")
+		}
+		// The checksum of the migration being redone may differ; the others must not.
+		delete(done, last[0].Version)
+		if err := m.verify(done); err != nil {
+			return err
+		}
+		if err := revert(ctx, conn, last[0]); err != nil {
+			return err
+		}
+		if err := apply(ctx, conn, last[0]); err != nil {
+			return err
+		}
+		redone = &last[0]
+		return nil
+	})
+	return redone, err
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(_ *sql.Conn, done map[int]applied) error {
+		for _, mig := range m.migrations {
+			st := Status{Migration: mig}
+			if a, ok := done[mig.Version]; ok {
+				at := a.appliedAt
+				st.AppliedAt = &at
+				st.Modified = a.checksum != mig.Checksum
+			}
+			statuses = append(statuses, st)
+		}
+		return nil
+	})
+	return statuses, err
+}
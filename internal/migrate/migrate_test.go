@@ -0,0 +1,42 @@
+package migrate
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			t.Errorf("migration %d has version %d; versions must run 1, 2, 3, ... without gaps", i, m.Version)
+		}
+		if strings.Contains(strings.ToUpper(m.Up), "DROP TABLE") {
+			t.Errorf("%04d_%s: up migrations must not drop tables", m.Version, m.Name)
+		}
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing down": {
+			"m/0001_init.up.sql": {Data: []byte("SELECT 1")},
+		},
+		"bad name": {
+			"m/init.up.sql":   {Data: []byte("SELECT 1")},
+			"m/init.down.sql": {Data: []byte("SELECT 1")},
+		},
+		"name mismatch": {
+			"m/0001_init.up.sql":    {Data: []byte("SELECT 1")},
+			"m/0001_other.down.sql": {Data: []byte("SELECT 1")},
+		},
+	} {
+		if _, err := load(fsys, "m"); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS SCHEDULE;
+DROP TABLE IF EXISTS ENROLLS_IN;
+DROP TABLE IF EXISTS RESOURCE;
+DROP TABLE IF EXISTS SYLLABUS_UNIT;
+DROP TABLE IF EXISTS TEACHES;
+DROP TABLE IF EXISTS COURSE;
+DROP TABLE IF EXISTS SECTION;
+DROP TABLE IF EXISTS STUDENT;
+DROP TABLE IF EXISTS DEPARTMENT;
+DROP TABLE IF EXISTS FACULTY;
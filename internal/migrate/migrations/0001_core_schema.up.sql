@@ -0,0 +1,108 @@
+-- Core Schema
+-- Departments, people, courses, sections, enrollment and the timetable. Every statement
+-- is IF NOT EXISTS so a database created by the old setup scripts can be adopted.
+
+CREATE EXTENSION IF NOT EXISTS vector;
+
+-- 1. FACULTY
+CREATE TABLE IF NOT EXISTS FACULTY (
+    faculty_id VARCHAR(20) PRIMARY KEY,
+    f_first_name VARCHAR(50) NOT NULL,
+    f_last_name VARCHAR(50) NOT NULL,
+    f_email VARCHAR(100) UNIQUE NOT NULL,
+    f_phone_no VARCHAR(15)
+);
+
+-- 2. DEPARTMENT
+CREATE TABLE IF NOT EXISTS DEPARTMENT (
+    dept_id VARCHAR(10) PRIMARY KEY,
+    dept_name VARCHAR(100) NOT NULL,
+    hod_id VARCHAR(20),
+    CONSTRAINT fk_dept_hod FOREIGN KEY (hod_id) REFERENCES FACULTY(faculty_id)
+);
+
+-- 3. STUDENT
+CREATE TABLE IF NOT EXISTS STUDENT (
+    student_id VARCHAR(20) PRIMARY KEY,
+    s_first_name VARCHAR(50) NOT NULL,
+    s_last_name VARCHAR(50) NOT NULL,
+    s_email VARCHAR(100) UNIQUE NOT NULL,
+    s_phone_no VARCHAR(15),
+    semester INTEGER NOT NULL,
+    year_of_joining INTEGER NOT NULL,
+    dept_id VARCHAR(10) NOT NULL,
+    CONSTRAINT fk_student_dept FOREIGN KEY (dept_id) REFERENCES DEPARTMENT(dept_id)
+);
+
+-- 4. SECTION
+CREATE TABLE IF NOT EXISTS SECTION (
+    section_name VARCHAR(10) PRIMARY KEY,
+    dept_id VARCHAR(10) NOT NULL,
+    CONSTRAINT fk_section_dept FOREIGN KEY (dept_id) REFERENCES DEPARTMENT(dept_id)
+);
+
+-- 5. COURSE
+CREATE TABLE IF NOT EXISTS COURSE (
+    course_id VARCHAR(10) PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    credits INTEGER NOT NULL CHECK (credits > 0),
+    dept_id VARCHAR(10) NOT NULL,
+    description TEXT,
+    embedding vector(768),
+    CONSTRAINT fk_course_dept FOREIGN KEY (dept_id) REFERENCES DEPARTMENT(dept_id)
+);
+
+-- 6. TEACHES
+CREATE TABLE IF NOT EXISTS TEACHES (
+    faculty_id VARCHAR(20),
+    course_id VARCHAR(10),
+    section_name VARCHAR(10),
+    PRIMARY KEY (faculty_id, course_id, section_name),
+    CONSTRAINT fk_teaches_faculty FOREIGN KEY (faculty_id) REFERENCES FACULTY(faculty_id),
+    CONSTRAINT fk_teaches_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id),
+    CONSTRAINT fk_teaches_section FOREIGN KEY (section_name) REFERENCES SECTION(section_name)
+);
+
+-- 7. SYLLABUS_UNIT
+CREATE TABLE IF NOT EXISTS SYLLABUS_UNIT (
+    unit_id SERIAL PRIMARY KEY,
+    unit_no INTEGER NOT NULL,
+    topic VARCHAR(255) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    CONSTRAINT fk_syllabus_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id)
+);
+
+-- 8. RESOURCE
+CREATE TABLE IF NOT EXISTS RESOURCE (
+    resource_id SERIAL PRIMARY KEY,
+    title VARCHAR(100) NOT NULL,
+    description TEXT,
+    type VARCHAR(50),
+    course_id VARCHAR(10) NOT NULL,
+    CONSTRAINT fk_resource_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id)
+);
+
+-- 9. ENROLLS_IN
+CREATE TABLE IF NOT EXISTS ENROLLS_IN (
+    student_id VARCHAR(20),
+    course_id VARCHAR(10),
+    grade VARCHAR(2),
+    status VARCHAR(20) DEFAULT 'Enrolled',
+    backlog BOOLEAN DEFAULT FALSE,
+    PRIMARY KEY (student_id, course_id),
+    CONSTRAINT fk_enrolls_student FOREIGN KEY (student_id) REFERENCES STUDENT(student_id),
+    CONSTRAINT fk_enrolls_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id)
+);
+
+-- 10. SCHEDULE
+CREATE TABLE IF NOT EXISTS SCHEDULE (
+    schedule_id SERIAL PRIMARY KEY,
+    course_id VARCHAR(10) NOT NULL,
+    section_name VARCHAR(10) NOT NULL,
+    day_of_week VARCHAR(10) NOT NULL CHECK (day_of_week IN ('Monday', 'Tuesday', 'Wednesday', 'Thursday', 'Friday', 'Saturday')),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    room_number VARCHAR(20),
+    CONSTRAINT fk_schedule_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id),
+    CONSTRAINT fk_schedule_section FOREIGN KEY (section_name) REFERENCES SECTION(section_name)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS COURSE_MATERIAL_CHUNK;
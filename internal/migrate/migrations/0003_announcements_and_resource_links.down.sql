@@ -0,0 +1,2 @@
+ALTER TABLE RESOURCE DROP COLUMN IF EXISTS link;
+DROP TABLE IF EXISTS ANNOUNCEMENT;
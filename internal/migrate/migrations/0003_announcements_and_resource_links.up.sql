@@ -0,0 +1,16 @@
+-- Announcements and Resource Links
+-- Teachers post announcements to their courses; resources may link to the material.
+
+CREATE TABLE IF NOT EXISTS ANNOUNCEMENT (
+    announcement_id SERIAL PRIMARY KEY,
+    faculty_id VARCHAR(20) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_announcement_faculty FOREIGN KEY (faculty_id) REFERENCES FACULTY(faculty_id) ON DELETE CASCADE,
+    CONSTRAINT fk_announcement_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_announcement_course ON ANNOUNCEMENT (course_id, created_at DESC);
+
+ALTER TABLE RESOURCE ADD COLUMN IF NOT EXISTS link TEXT;
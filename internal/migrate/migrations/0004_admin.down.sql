@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS ADMIN_AUDIT_LOG;
+DROP TABLE IF EXISTS ADMIN;
@@ -0,0 +1,31 @@
+-- Admin Setup
+-- Administrators manage the master data (departments, faculty, courses, sections,
+-- teaching assignments, syllabus and timetable) through the /admin API.
+
+-- 1. Administrator accounts (login with role "admin")
+CREATE TABLE IF NOT EXISTS ADMIN (
+    admin_id VARCHAR(20) PRIMARY KEY,
+    a_first_name VARCHAR(50) NOT NULL,
+    a_last_name VARCHAR(50) NOT NULL,
+    a_email VARCHAR(100) UNIQUE NOT NULL
+);
+
+-- 2. Audit trail of every change made through the admin API
+CREATE TABLE IF NOT EXISTS ADMIN_AUDIT_LOG (
+    audit_id SERIAL PRIMARY KEY,
+    actor_id VARCHAR(20) NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'import')),
+    entity VARCHAR(30) NOT NULL,      -- e.g. 'COURSE', 'SCHEDULE'
+    entity_key VARCHAR(100) NOT NULL, -- primary key of the changed row
+    old_data JSONB,                   -- NULL on create
+    new_data JSONB,                   -- NULL on delete
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_entity ON ADMIN_AUDIT_LOG (entity, entity_key);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_actor ON ADMIN_AUDIT_LOG (actor_id, created_at DESC);
+
+-- Default administrator
+INSERT INTO ADMIN (admin_id, a_first_name, a_last_name, a_email) VALUES
+('A001', 'System', 'Admin', 'admin@rvce.edu.in')
+ON CONFLICT (admin_id) DO NOTHING;
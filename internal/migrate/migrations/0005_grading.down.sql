@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS GRADE_AUDIT_LOG;
+DROP TABLE IF EXISTS COURSE_GRADE;
+DROP TABLE IF EXISTS ASSESSMENT_MARK;
+DROP TABLE IF EXISTS ASSESSMENT_COMPONENT;
@@ -0,0 +1,59 @@
+-- Grading Setup
+-- Component-based assessment. Teachers define a weighted scheme per course, enter
+-- marks per component, and publish the computed letter grade into ENROLLS_IN.grade.
+-- ENROLLS_IN.grade only ever holds published grades, so students, CGPA and the
+-- teacher dashboards never see a draft.
+
+-- 1. Assessment scheme per course (weights must total 100)
+CREATE TABLE IF NOT EXISTS ASSESSMENT_COMPONENT (
+    component_id SERIAL PRIMARY KEY,
+    course_id VARCHAR(10) NOT NULL,
+    name VARCHAR(50) NOT NULL,        -- e.g. 'CIE 1', 'Lab Record', 'SEE'
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('internal', 'assignment', 'lab', 'final')),
+    max_marks NUMERIC(6,2) NOT NULL CHECK (max_marks > 0),
+    weight NUMERIC(5,2) NOT NULL CHECK (weight > 0 AND weight <= 100), -- percent of the final total
+    CONSTRAINT uq_component_name UNIQUE (course_id, name),
+    CONSTRAINT fk_component_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id) ON DELETE CASCADE
+);
+
+-- 2. Marks scored by a student in a component
+CREATE TABLE IF NOT EXISTS ASSESSMENT_MARK (
+    component_id INT NOT NULL,
+    student_id VARCHAR(20) NOT NULL,
+    marks NUMERIC(6,2) NOT NULL CHECK (marks >= 0),
+    entered_by VARCHAR(20) NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (component_id, student_id),
+    CONSTRAINT fk_mark_component FOREIGN KEY (component_id) REFERENCES ASSESSMENT_COMPONENT(component_id) ON DELETE CASCADE,
+    CONSTRAINT fk_mark_student FOREIGN KEY (student_id) REFERENCES STUDENT(student_id)
+);
+
+-- 3. Computed grade per enrollment. 'draft' means it differs from (or has never been
+-- copied to) ENROLLS_IN.grade; 'published' means students can see it.
+CREATE TABLE IF NOT EXISTS COURSE_GRADE (
+    student_id VARCHAR(20) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    total NUMERIC(5,2),               -- weighted percentage, NULL until every component is marked
+    grade VARCHAR(2),                 -- letter grade derived from total
+    state VARCHAR(10) NOT NULL DEFAULT 'draft' CHECK (state IN ('draft', 'published')),
+    computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP,
+    published_by VARCHAR(20),
+    PRIMARY KEY (student_id, course_id),
+    CONSTRAINT fk_course_grade_enrollment FOREIGN KEY (student_id, course_id) REFERENCES ENROLLS_IN(student_id, course_id) ON DELETE CASCADE
+);
+
+-- 4. Audit trail of scheme edits, mark entry and publication
+CREATE TABLE IF NOT EXISTS GRADE_AUDIT_LOG (
+    audit_id SERIAL PRIMARY KEY,
+    actor_id VARCHAR(20) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('scheme', 'mark', 'publish', 'unpublish')),
+    course_id VARCHAR(10) NOT NULL,
+    student_id VARCHAR(20),           -- NULL for course-wide actions
+    old_data JSONB,
+    new_data JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_grade_audit_course ON GRADE_AUDIT_LOG (course_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_assessment_mark_student ON ASSESSMENT_MARK (student_id);
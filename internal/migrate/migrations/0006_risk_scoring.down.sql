@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS STUDENT_RISK;
+DROP TABLE IF EXISTS QUIZ_ATTEMPT;
@@ -0,0 +1,32 @@
+-- Risk Scoring Setup
+-- A scheduled job combines grades, assessment trends, quiz performance, chat sentiment
+-- and engagement into a per-enrollment risk score. The contributing factors are stored
+-- alongside the score so teachers and students can see why a student was flagged.
+
+-- 1. Self-assessment quiz attempts (recorded by /ai/quiz-analysis)
+CREATE TABLE IF NOT EXISTS QUIZ_ATTEMPT (
+    attempt_id SERIAL PRIMARY KEY,
+    student_id VARCHAR(20) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    score INT NOT NULL CHECK (score >= 0),
+    total_questions INT NOT NULL CHECK (total_questions > 0),
+    taken_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_quiz_attempt_student FOREIGN KEY (student_id) REFERENCES STUDENT(student_id) ON DELETE CASCADE,
+    CONSTRAINT fk_quiz_attempt_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_quiz_attempt_student ON QUIZ_ATTEMPT (student_id, course_id, taken_at DESC);
+
+-- 2. Latest risk score per enrollment
+CREATE TABLE IF NOT EXISTS STUDENT_RISK (
+    student_id VARCHAR(20) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    score NUMERIC(5,2) NOT NULL CHECK (score >= 0 AND score <= 100), -- 100 = highest risk
+    level VARCHAR(10) NOT NULL CHECK (level IN ('high', 'medium', 'low')),
+    factors JSONB NOT NULL,           -- [{factor, weight, score, detail}], highest contribution first
+    computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (student_id, course_id),
+    CONSTRAINT fk_student_risk_enrollment FOREIGN KEY (student_id, course_id) REFERENCES ENROLLS_IN(student_id, course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_student_risk_course ON STUDENT_RISK (course_id, level);
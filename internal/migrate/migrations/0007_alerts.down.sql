@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS TEACHER_ALERT;
+DROP TABLE IF EXISTS STUDENT_QUESTION;
+DROP TABLE IF EXISTS ATTENDANCE;
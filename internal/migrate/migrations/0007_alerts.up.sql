@@ -0,0 +1,54 @@
+-- Alerts Setup
+-- Early-warning feed for teachers. Producers (risk scoring, quiz averages, attendance,
+-- unanswered questions) write one row per teaching faculty member; teachers mark
+-- alerts read or dismiss them.
+
+-- 1. Class attendance, recorded by teachers per class date
+CREATE TABLE IF NOT EXISTS ATTENDANCE (
+    student_id VARCHAR(20) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    class_date DATE NOT NULL,
+    present BOOLEAN NOT NULL,
+    recorded_by VARCHAR(20) NOT NULL,
+    recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (student_id, course_id, class_date),
+    CONSTRAINT fk_attendance_enrollment FOREIGN KEY (student_id, course_id) REFERENCES ENROLLS_IN(student_id, course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_attendance_course ON ATTENDANCE (course_id, class_date DESC);
+
+-- 2. Questions students address to the teachers of a course
+CREATE TABLE IF NOT EXISTS STUDENT_QUESTION (
+    question_id SERIAL PRIMARY KEY,
+    student_id VARCHAR(20) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    question TEXT NOT NULL,
+    answer TEXT,
+    answered_by VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    answered_at TIMESTAMP,
+    CONSTRAINT fk_question_student FOREIGN KEY (student_id) REFERENCES STUDENT(student_id) ON DELETE CASCADE,
+    CONSTRAINT fk_question_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_question_open ON STUDENT_QUESTION (course_id, created_at) WHERE answered_at IS NULL;
+
+-- 3. Alerts, one row per recipient
+CREATE TABLE IF NOT EXISTS TEACHER_ALERT (
+    alert_id SERIAL PRIMARY KEY,
+    faculty_id VARCHAR(20) NOT NULL,
+    course_id VARCHAR(10) NOT NULL,
+    student_id VARCHAR(20),           -- NULL for course-wide alerts
+    kind VARCHAR(30) NOT NULL CHECK (kind IN ('risk_threshold', 'low_quiz_average', 'attendance_drop', 'unanswered_question')),
+    severity VARCHAR(10) NOT NULL CHECK (severity IN ('high', 'medium', 'low')),
+    title VARCHAR(200) NOT NULL,
+    message TEXT NOT NULL,
+    dedupe_key VARCHAR(200) NOT NULL, -- Producers re-run freely; the same event is stored once
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    read_at TIMESTAMP,
+    dismissed_at TIMESTAMP,
+    CONSTRAINT uq_teacher_alert UNIQUE (faculty_id, dedupe_key),
+    CONSTRAINT fk_alert_faculty FOREIGN KEY (faculty_id) REFERENCES FACULTY(faculty_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_teacher_alert_feed ON TEACHER_ALERT (faculty_id, created_at DESC) WHERE dismissed_at IS NULL;
@@ -0,0 +1,26 @@
+-- Agent Personas Setup
+-- Chat agents are data: the built-in personas ship as YAML (internal/services/personas)
+-- and admins or teachers add or edit them here. Every edit inserts a new version; the
+-- highest version of an agent wins, including over a built-in with the same ID.
+
+CREATE TABLE IF NOT EXISTS AGENT_PERSONA (
+    agent_id VARCHAR(50) NOT NULL,
+    version INT NOT NULL CHECK (version > 0),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    system_prompt TEXT NOT NULL,
+    allowed_roles TEXT[] NOT NULL,
+    course_id VARCHAR(10), -- Set for a teacher's course-specific persona
+    retrieval_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    retrieval_top_k INT NOT NULL DEFAULT 3 CHECK (retrieval_top_k BETWEEN 1 AND 10),
+    temperature NUMERIC(3,2) CHECK (temperature >= 0 AND temperature <= 2),
+    model VARCHAR(100),
+    tools TEXT[] NOT NULL DEFAULT '{}', -- Empty = every tool the caller's role may use
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (agent_id, version),
+    CONSTRAINT fk_persona_course FOREIGN KEY (course_id) REFERENCES COURSE(course_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_persona_course ON AGENT_PERSONA (course_id) WHERE course_id IS NOT NULL;
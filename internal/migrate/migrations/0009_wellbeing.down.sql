@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS WELLBEING_ACCESS_LOG;
+DROP TABLE IF EXISTS WELLBEING_ALERT;
+DROP TABLE IF EXISTS SUPPORT_RESOURCE;
+DROP TABLE IF EXISTS COUNSELLOR;
@@ -0,0 +1,74 @@
+-- Wellbeing Setup
+-- Chat messages showing crisis language or sustained distress raise a confidential
+-- alert for a counsellor. Only counsellors (login role "counsellor") can read alerts;
+-- every access is logged.
+
+-- 1. Counsellor accounts. dept_id NULL serves the whole campus.
+CREATE TABLE IF NOT EXISTS COUNSELLOR (
+    counsellor_id VARCHAR(20) PRIMARY KEY,
+    c_first_name VARCHAR(50) NOT NULL,
+    c_last_name VARCHAR(50) NOT NULL,
+    c_email VARCHAR(100) UNIQUE NOT NULL,
+    dept_id VARCHAR(10),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    CONSTRAINT fk_counsellor_dept FOREIGN KEY (dept_id) REFERENCES DEPARTMENT(dept_id) ON DELETE SET NULL
+);
+
+-- 2. Support resources listed to distressed students
+CREATE TABLE IF NOT EXISTS SUPPORT_RESOURCE (
+    resource_id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL,
+    contact VARCHAR(200) NOT NULL,
+    hours VARCHAR(100),
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+-- 3. Confidential alerts. A repeat of the same trigger while the student's alert is
+-- unresolved raises occurrences instead of a new row.
+CREATE TABLE IF NOT EXISTS WELLBEING_ALERT (
+    alert_id SERIAL PRIMARY KEY,
+    student_id VARCHAR(20) NOT NULL,
+    counsellor_id VARCHAR(20),        -- NULL until a counsellor is available; any counsellor may claim it
+    trigger_kind VARCHAR(30) NOT NULL CHECK (trigger_kind IN ('crisis_language', 'sustained_distress')),
+    severity VARCHAR(10) NOT NULL CHECK (severity IN ('high', 'medium', 'low')),
+    distress NUMERIC(3, 2) NOT NULL,
+    excerpt TEXT NOT NULL,            -- The message that raised or last repeated the alert
+    conversation_id VARCHAR(40),
+    occurrences INTEGER NOT NULL DEFAULT 1,
+    status VARCHAR(15) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    acknowledged_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    CONSTRAINT fk_wellbeing_student FOREIGN KEY (student_id) REFERENCES STUDENT(student_id) ON DELETE CASCADE,
+    CONSTRAINT fk_wellbeing_counsellor FOREIGN KEY (counsellor_id) REFERENCES COUNSELLOR(counsellor_id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_wellbeing_alert_unresolved ON WELLBEING_ALERT (student_id, trigger_kind) WHERE status <> 'resolved';
+CREATE INDEX IF NOT EXISTS idx_wellbeing_alert_queue ON WELLBEING_ALERT (counsellor_id, status, created_at DESC);
+
+-- 4. Who read or changed what. Holds no message content.
+CREATE TABLE IF NOT EXISTS WELLBEING_ACCESS_LOG (
+    log_id SERIAL PRIMARY KEY,
+    actor_id VARCHAR(20) NOT NULL,    -- Counsellor ID, or 'system' for alerts raised by chat
+    action VARCHAR(20) NOT NULL CHECK (action IN ('raise', 'list', 'view', 'view_trend', 'acknowledge', 'resolve', 'reopen')),
+    alert_id INTEGER,
+    student_id VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_wellbeing_access_actor ON WELLBEING_ACCESS_LOG (actor_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_wellbeing_access_student ON WELLBEING_ACCESS_LOG (student_id, created_at DESC);
+
+-- Default counsellor and campus resources
+INSERT INTO COUNSELLOR (counsellor_id, c_first_name, c_last_name, c_email, dept_id) VALUES
+('C001', 'Campus', 'Counsellor', 'counsellor@rvce.edu.in', NULL)
+ON CONFLICT (counsellor_id) DO NOTHING;
+
+INSERT INTO SUPPORT_RESOURCE (name, description, contact, hours, sort_order) VALUES
+('Student Counselling Centre', 'Free, confidential one-to-one counselling for any student', 'counsellor@rvce.edu.in, Admin Block room 12', 'Mon-Sat 9:00-17:00', 1),
+('Tele-MANAS', 'National mental health helpline', '14416 or 1800-891-4416', '24x7', 2),
+('KIRAN', 'Mental health rehabilitation helpline', '1800-599-0019', '24x7', 3)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1 @@
+ALTER TABLE ADMIN DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,4 @@
+-- Admin Passwords
+-- Admins sign in with a bcrypt hash instead of the mock password check. An admin with
+-- no hash cannot log in until one is set with `go run ./cmd/passwd -role admin -id <id>`.
+ALTER TABLE ADMIN ADD COLUMN IF NOT EXISTS password_hash VARCHAR(100);
@@ -0,0 +1 @@
+ALTER TABLE COUNSELLOR DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,4 @@
+-- Counsellor Passwords
+-- Counsellors read confidential wellbeing alerts, so like admins they sign in with a
+-- bcrypt hash. Set one with `go run ./cmd/passwd -role counsellor -id <id>`.
+ALTER TABLE COUNSELLOR ADD COLUMN IF NOT EXISTS password_hash VARCHAR(100);
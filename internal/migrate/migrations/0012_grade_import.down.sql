@@ -0,0 +1,4 @@
+DELETE FROM GRADE_AUDIT_LOG WHERE action = 'import';
+ALTER TABLE GRADE_AUDIT_LOG DROP CONSTRAINT IF EXISTS grade_audit_log_action_check;
+ALTER TABLE GRADE_AUDIT_LOG ADD CONSTRAINT grade_audit_log_action_check
+    CHECK (action IN ('scheme', 'mark', 'publish', 'unpublish'));
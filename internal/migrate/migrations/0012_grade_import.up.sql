@@ -0,0 +1,6 @@
+-- Grade Import
+-- Grades imported from CSV become drafts in COURSE_GRADE, published like computed
+-- grades, and each one is recorded in the grade audit trail as 'import'.
+ALTER TABLE GRADE_AUDIT_LOG DROP CONSTRAINT IF EXISTS grade_audit_log_action_check;
+ALTER TABLE GRADE_AUDIT_LOG ADD CONSTRAINT grade_audit_log_action_check
+    CHECK (action IN ('scheme', 'mark', 'publish', 'unpublish', 'import'));
@@ -0,0 +1 @@
+ALTER TABLE FACULTY DROP COLUMN IF EXISTS password_hash;
@@ -0,0 +1,5 @@
+-- Faculty Passwords
+-- Teachers enter and publish grades, so like admins and counsellors they sign in with
+-- a bcrypt hash. Set one with `go run ./cmd/passwd -role teacher -id <id>`; until then
+-- the account cannot sign in.
+ALTER TABLE FACULTY ADD COLUMN IF NOT EXISTS password_hash VARCHAR(100);
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // PostgreSQL Entities
 
@@ -39,22 +42,428 @@ type Resource struct {
 	Link        string `json:"link"`
 }
 
+// CourseAnnouncement is an announcement as listed to students.
+type CourseAnnouncement struct {
+	Course  string `json:"course"`
+	Content string `json:"content"`
+	Date    string `json:"date"`
+}
+
+type EnrolledCourse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Enrollment is a student's enrollment in a course, with the course's title and credits.
+type Enrollment struct {
+	StudentID string
+	CourseID  string
+	Title     string
+	Credits   int
+	Status    string // "Enrolled", "Completed", ...
+	Grade     string // Empty until graded
+}
+
+// CourseTeacher is a teacher of one of the student's courses.
+type CourseTeacher struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Course   string `json:"course"`
+	CourseID string `json:"course_id"`
+}
+
+// Faculty dashboard
+
+type TaughtCourse struct {
+	CourseID string `json:"course_id"`
+	Title    string `json:"title"`
+	Section  string `json:"section"`
+}
+
+type StudentContact struct {
+	StudentID string `json:"student_id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+}
+
+// StudentStanding is a student's contact details and grade in one course.
+type StudentStanding struct {
+	StudentContact
+	Grade string // "N/A" until graded
+}
+
+type ClassHealth struct {
+	CourseID               string         `json:"course_id"`
+	Title                  string         `json:"title"`
+	AttendanceDistribution map[string]int `json:"attendance_distribution"`
+	PerformanceHeatmap     map[string]int `json:"performance_heatmap"`
+}
+
+type FacultyProfile struct {
+	FacultyID   string   `json:"faculty_id"`
+	FirstName   string   `json:"first_name"`
+	LastName    string   `json:"last_name"`
+	Email       string   `json:"email"`
+	PhoneNo     string   `json:"phone_no"`
+	Departments []string `json:"departments"`
+}
+
+// Master Data (managed through the admin API)
+
+type Department struct {
+	DeptID   string  `json:"dept_id"`
+	DeptName string  `json:"dept_name"`
+	HodID    *string `json:"hod_id"` // Nullable
+}
+
+type Faculty struct {
+	FacultyID string `json:"faculty_id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	PhoneNo   string `json:"phone_no"`
+}
+
+type Course struct {
+	CourseID    string `json:"course_id"`
+	Title       string `json:"title"`
+	Credits     int    `json:"credits"`
+	DeptID      string `json:"dept_id"`
+	Description string `json:"description"`
+}
+
+type Section struct {
+	SectionName string `json:"section_name"`
+	DeptID      string `json:"dept_id"`
+}
+
+type Teaches struct {
+	FacultyID   string `json:"faculty_id"`
+	CourseID    string `json:"course_id"`
+	SectionName string `json:"section_name"`
+}
+
+type SyllabusUnit struct {
+	UnitID   int    `json:"unit_id"`
+	UnitNo   int    `json:"unit_no"`
+	Topic    string `json:"topic"`
+	CourseID string `json:"course_id"`
+}
+
+type Schedule struct {
+	ScheduleID  int    `json:"schedule_id"`
+	CourseID    string `json:"course_id"`
+	SectionName string `json:"section_name"`
+	DayOfWeek   string `json:"day_of_week"`
+	StartTime   string `json:"start_time"` // HH:MM
+	EndTime     string `json:"end_time"`   // HH:MM
+	RoomNumber  string `json:"room_number"`
+}
+
+// Grading (component-based assessment)
+
+type AssessmentComponent struct {
+	ComponentID int     `json:"component_id"`
+	CourseID    string  `json:"course_id"`
+	Name        string  `json:"name"`
+	Kind        string  `json:"kind"` // "internal", "assignment", "lab", "final"
+	MaxMarks    float64 `json:"max_marks"`
+	Weight      float64 `json:"weight"` // Percent of the final total
+}
+
+type MarkEntry struct {
+	StudentID string   `json:"student_id"`
+	Marks     *float64 `json:"marks"` // nil clears the mark
+}
+
+type ComponentMark struct {
+	ComponentID int      `json:"component_id"`
+	Name        string   `json:"name"`
+	Kind        string   `json:"kind"`
+	MaxMarks    float64  `json:"max_marks"`
+	Weight      float64  `json:"weight"`
+	Marks       *float64 `json:"marks"`
+}
+
+type GradebookRow struct {
+	StudentID      string          `json:"student_id"`
+	Name           string          `json:"name"`
+	Marks          []ComponentMark `json:"marks"`
+	Total          *float64        `json:"total"`           // nil until every component is marked
+	Grade          *string         `json:"grade"`           // Computed (possibly unpublished) grade
+	State          string          `json:"state"`           // "draft", "published" or "" if nothing entered
+	PublishedGrade *string         `json:"published_grade"` // What students currently see
+}
+
+type StudentGrade struct {
+	CourseID    string          `json:"course_id"`
+	Title       string          `json:"title"`
+	Credits     int             `json:"credits"`
+	Grade       string          `json:"grade"`
+	Total       *float64        `json:"total,omitempty"`
+	Components  []ComponentMark `json:"components,omitempty"`
+	PublishedAt *time.Time      `json:"published_at,omitempty"`
+}
+
+type GradeAuditEntry struct {
+	AuditID   int             `json:"audit_id"`
+	ActorID   string          `json:"actor_id"`
+	Action    string          `json:"action"` // "scheme", "mark", "publish", "unpublish"
+	CourseID  string          `json:"course_id"`
+	StudentID *string         `json:"student_id,omitempty"`
+	OldData   json.RawMessage `json:"old_data,omitempty"`
+	NewData   json.RawMessage `json:"new_data,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Risk Scoring
+
+type RiskFactor struct {
+	Factor string  `json:"factor"` // "grades", "assessment_trend", "quiz", "sentiment", "engagement"
+	Weight float64 `json:"weight"` // Share of the overall score after renormalising for missing data
+	Score  float64 `json:"score"`  // 0 (no concern) to 1 (maximum concern)
+	Detail string  `json:"detail"`
+}
+
+type RiskScore struct {
+	StudentID  string       `json:"student_id"`
+	Name       string       `json:"name,omitempty"`
+	CourseID   string       `json:"course_id"`
+	Title      string       `json:"title,omitempty"`
+	Score      float64      `json:"score"` // 0-100, higher is riskier
+	Level      string       `json:"level"` // "high", "medium", "low"
+	Factors    []RiskFactor `json:"factors"`
+	ComputedAt time.Time    `json:"computed_at"`
+}
+
+// Alerts
+
+type Alert struct {
+	AlertID     int        `json:"alert_id"`
+	CourseID    string     `json:"course_id"`
+	StudentID   *string    `json:"student_id,omitempty"`
+	Kind        string     `json:"kind"`     // "risk_threshold", "low_quiz_average", "attendance_drop", "unanswered_question"
+	Severity    string     `json:"severity"` // "high", "medium", "low"
+	Title       string     `json:"title"`
+	Message     string     `json:"message"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ReadAt      *time.Time `json:"read_at,omitempty"`
+	DismissedAt *time.Time `json:"dismissed_at,omitempty"`
+}
+
+type AttendanceRecord struct {
+	StudentID string `json:"student_id"`
+	Present   bool   `json:"present"`
+}
+
+type StudentQuestion struct {
+	QuestionID int        `json:"question_id"`
+	StudentID  string     `json:"student_id"`
+	CourseID   string     `json:"course_id"`
+	Question   string     `json:"question"`
+	Answer     *string    `json:"answer"`
+	AnsweredBy *string    `json:"answered_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AnsweredAt *time.Time `json:"answered_at,omitempty"`
+}
+
+type AuditEntry struct {
+	AuditID   int             `json:"audit_id"`
+	ActorID   string          `json:"actor_id"`
+	Action    string          `json:"action"` // "create", "update", "delete"
+	Entity    string          `json:"entity"`
+	EntityKey string          `json:"entity_key"`
+	OldData   json.RawMessage `json:"old_data,omitempty"`
+	NewData   json.RawMessage `json:"new_data,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
 // MongoDB Entities
 
 type ChatLog struct {
-	StudentID string    `bson:"student_id" json:"student_id"`
-	Message   string    `bson:"message" json:"message"`
-	Intent    string    `bson:"intent" json:"intent"`
-	Sentiment string    `bson:"sentiment" json:"sentiment"`
-	Timestamp time.Time `bson:"timestamp" json:"timestamp"`
-	IsBot     bool      `bson:"is_bot" json:"is_bot"` // To distinguish user vs bot
+	ID             string     `bson:"_id,omitempty" json:"id"`
+	StudentID      string     `bson:"student_id" json:"student_id"` // Faculty ID for teacher chats
+	ConversationID string     `bson:"conversation_id,omitempty" json:"conversation_id,omitempty"`
+	AgentID        string     `bson:"agent_id,omitempty" json:"agent_id,omitempty"`
+	Message        string     `bson:"message" json:"message"`
+	Intent         string     `bson:"intent" json:"intent"`                                   // Classified intent; "reply" on bot messages
+	Topic          string     `bson:"topic,omitempty" json:"topic,omitempty"`                 // Course ID or short subject
+	IntentSource   string     `bson:"intent_source,omitempty" json:"intent_source,omitempty"` // "rules" or "model"
+	Route          string     `bson:"route,omitempty" json:"route,omitempty"`                 // Bot messages: "lookup", "cache", "model", "safety" or "degraded"
+	Sentiment      string     `bson:"sentiment" json:"sentiment"`
+	Citations      []Citation `bson:"citations,omitempty" json:"citations,omitempty"` // Bot messages only
+	Timestamp      time.Time  `bson:"timestamp" json:"timestamp"`
+	IsBot          bool       `bson:"is_bot" json:"is_bot"` // To distinguish user vs bot
+
+	Feedback *AnswerFeedback `bson:"feedback,omitempty" json:"feedback,omitempty"` // Bot messages only
+}
+
+// AnswerFeedback is the asker's rating of a bot answer.
+type AnswerFeedback struct {
+	Rating    string    `bson:"rating" json:"rating"` // "up" or "down"
+	Reason    string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	Comment   string    `bson:"comment,omitempty" json:"comment,omitempty"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// FeedbackBucket aggregates ratings for one course, agent or source file.
+type FeedbackBucket struct {
+	Key      string         `json:"key"`
+	CourseID string         `json:"course_id,omitempty"` // Set for source file buckets
+	Up       int            `json:"up"`
+	Down     int            `json:"down"`
+	DownRate float64        `json:"down_rate"` // Share of ratings that are negative
+	Reasons  map[string]int `json:"reasons,omitempty"`
+}
+
+type FeedbackComment struct {
+	MessageID string    `json:"message_id"`
+	AgentID   string    `json:"agent_id"`
+	Answer    string    `json:"answer"` // Truncated
+	Reason    string    `json:"reason,omitempty"`
+	Comment   string    `json:"comment"`
+	Sources   []string  `json:"sources,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FeedbackReport struct {
+	Total          int               `json:"total"`
+	Up             int               `json:"up"`
+	Down           int               `json:"down"`
+	ByCourse       []FeedbackBucket  `json:"by_course"`
+	ByAgent        []FeedbackBucket  `json:"by_agent"`
+	BySource       []FeedbackBucket  `json:"by_source"`
+	RecentNegative []FeedbackComment `json:"recent_negative"`
+}
+
+// Citation points at a course material chunk an answer was grounded in.
+type Citation struct {
+	CourseID   string  `bson:"course_id" json:"course_id"`
+	UnitNo     int     `bson:"unit_no" json:"unit_no"`
+	SourceFile string  `bson:"source_file,omitempty" json:"source_file,omitempty"`
+	Score      float64 `bson:"score" json:"score"`
+}
+
+// Conversation is a chat thread; its messages are the ChatLogs with its ID.
+type Conversation struct {
+	ID           string    `bson:"_id,omitempty" json:"id"`
+	UserID       string    `bson:"user_id" json:"user_id"`
+	Title        string    `bson:"title" json:"title"`
+	AgentID      string    `bson:"agent_id,omitempty" json:"agent_id,omitempty"`
+	MessageCount int       `bson:"message_count" json:"message_count"`
+	CreatedAt    time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// SafetyIncident records a guardrail firing on a chat: a moderation flag, suspected
+// prompt injection in a message or course material, or redacted personal data.
+type SafetyIncident struct {
+	ID             string    `bson:"_id,omitempty" json:"id"`
+	UserID         string    `bson:"user_id" json:"user_id"`
+	Role           string    `bson:"role" json:"role"`
+	ConversationID string    `bson:"conversation_id,omitempty" json:"conversation_id,omitempty"`
+	Kind           string    `bson:"kind" json:"kind"`
+	Source         string    `bson:"source" json:"source"` // "message", "material" or "response"
+	Severity       string    `bson:"severity" json:"severity"`
+	Detail         string    `bson:"detail,omitempty" json:"detail,omitempty"`   // Patterns or categories matched
+	Excerpt        string    `bson:"excerpt,omitempty" json:"excerpt,omitempty"` // Start of the offending text
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+// SupportResource is a campus or national support service offered to distressed students.
+type SupportResource struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Contact     string `json:"contact"`
+	Hours       string `json:"hours,omitempty"`
+}
+
+// WellbeingAlert is a confidential alert for a counsellor about a student's chats.
+type WellbeingAlert struct {
+	AlertID        int        `json:"alert_id"`
+	StudentID      string     `json:"student_id"`
+	StudentName    string     `json:"student_name"`
+	StudentEmail   string     `json:"student_email"`
+	StudentPhone   string     `json:"student_phone,omitempty"`
+	DeptID         string     `json:"dept_id"`
+	CounsellorID   *string    `json:"counsellor_id,omitempty"` // nil while unassigned
+	Trigger        string     `json:"trigger"`                 // "crisis_language" or "sustained_distress"
+	Severity       string     `json:"severity"`
+	Distress       float64    `json:"distress"`
+	Excerpt        string     `json:"excerpt,omitempty"` // Detail view only
+	ConversationID string     `json:"conversation_id,omitempty"`
+	Occurrences    int        `json:"occurrences"`
+	Status         string     `json:"status"` // "open", "acknowledged" or "resolved"
+	Note           string     `json:"note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+}
+
+// WellbeingAccessEntry is one row of the wellbeing access log. It holds no content.
+type WellbeingAccessEntry struct {
+	LogID     int       `json:"log_id"`
+	ActorID   string    `json:"actor_id"`
+	Action    string    `json:"action"`
+	AlertID   *int      `json:"alert_id,omitempty"`
+	StudentID *string   `json:"student_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AgentPersona is one version of a chat agent: built in (internal/services/personas/*.yaml)
+// or stored in AGENT_PERSONA. Tools empty means every tool the caller's role may use.
+type AgentPersona struct {
+	ID           string           `json:"id" yaml:"id"`
+	Version      int              `json:"version" yaml:"version"`
+	Name         string           `json:"name" yaml:"name"`
+	Description  string           `json:"description" yaml:"description"`
+	SystemPrompt string           `json:"system_prompt" yaml:"system_prompt"`
+	Roles        []string         `json:"roles" yaml:"roles"`
+	Aliases      []string         `json:"aliases,omitempty" yaml:"aliases"`
+	CourseID     string           `json:"course_id,omitempty" yaml:"-"` // Course-specific persona
+	Retrieval    PersonaRetrieval `json:"retrieval" yaml:"retrieval"`
+	Temperature  *float64         `json:"temperature,omitempty" yaml:"temperature"`
+	Model        string           `json:"model,omitempty" yaml:"model"`
+	Tools        []string         `json:"tools,omitempty" yaml:"tools"`
+	Active       bool             `json:"active" yaml:"-"`
+	Source       string           `json:"source" yaml:"-"` // "builtin" or "custom"
+	CreatedBy    string           `json:"created_by,omitempty" yaml:"-"`
+	CreatedAt    *time.Time       `json:"created_at,omitempty" yaml:"-"`
+}
+
+// PersonaRetrieval controls course material search for a persona.
+type PersonaRetrieval struct {
+	Enabled bool `json:"enabled" yaml:"enabled"`
+	TopK    int  `json:"top_k" yaml:"top_k"`
 }
 
 type ChatContext struct {
-	StudentID       string    `bson:"student_id" json:"student_id"`
-	LastTopic       string    `bson:"last_topic" json:"last_topic"`
-	Emotion         string    `bson:"emotion" json:"emotion"`
-	LastInteraction time.Time `bson:"last_interaction" json:"last_interaction"`
+	StudentID       string      `bson:"student_id" json:"student_id"`
+	LastTopic       string      `bson:"last_topic" json:"last_topic"`
+	LastIntent      string      `bson:"last_intent" json:"last_intent"`
+	Emotion         string      `bson:"emotion" json:"emotion"`
+	LastInteraction time.Time   `bson:"last_interaction" json:"last_interaction"`
+	Mood            []MoodEntry `bson:"mood,omitempty" json:"mood,omitempty"` // Students' most recent message scores, oldest first
+}
+
+// MoodEntry is the sentiment score of one chat message.
+type MoodEntry struct {
+	At       time.Time `bson:"at" json:"at"`
+	Score    float64   `bson:"score" json:"score"`       // -1 to 1
+	Distress float64   `bson:"distress" json:"distress"` // 0 to 1
+}
+
+// MoodTrend summarises a student's recent chat mood.
+type MoodTrend struct {
+	StudentID   string      `json:"student_id"`
+	Messages    int         `json:"messages"` // Scored messages in the window
+	AvgScore    float64     `json:"avg_score"`
+	AvgDistress float64     `json:"avg_distress"`
+	Distressed  int         `json:"distressed"` // Messages at or above the concern level
+	Direction   string      `json:"direction"`  // "improving", "declining" or "steady"
+	Sustained   bool        `json:"sustained"`  // Distress persisted across the window
+	Entries     []MoodEntry `json:"entries"`
 }
 
 type Quiz struct {
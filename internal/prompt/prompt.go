@@ -0,0 +1,182 @@
+// Package prompt renders LLM prompts from named text/template sections and fits them
+// into a token budget, cutting the least important sections first.
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ErrOverBudget is returned when the required sections alone exceed the budget.
+var ErrOverBudget = errors.New("prompt does not fit the token budget")
+
+// defaultMinTokens is the smallest useful remainder of a truncated section; below it
+// the section is dropped instead.
+const defaultMinTokens = 32
+
+// Section is one named part of a prompt. Its Template is executed with the data passed
+// to Render and its output is available to the layout as {{.<Name>}}.
+type Section struct {
+	Name      string
+	Template  string
+	Priority  int  // Lower priorities are cut first
+	Required  bool // Never cut
+	MinTokens int  // Drop rather than truncate below this; 0 means 32
+}
+
+type section struct {
+	Section
+	index int
+	tmpl  *template.Template
+}
+
+// Template is a layout plus its sections.
+type Template struct {
+	name     string
+	layout   *template.Template
+	sections []section
+}
+
+// Funcs available to section templates.
+var funcs = template.FuncMap{
+	"join": strings.Join,
+	"trim": strings.TrimSpace,
+}
+
+// New parses the layout and section templates. The layout sees each section's
+// rendered text by name; a section that was dropped renders as "".
+func New(name, layout string, sections ...Section) (*Template, error) {
+	t := &Template{name: name}
+	var err error
+	if t.layout, err = template.New(name).Option("missingkey=error").Parse(layout); err != nil {
+		return nil, fmt.Errorf("parsing %s layout: %w", name, err)
+	}
+	seen := map[string]bool{}
+	for i, s := range sections {
+		if s.Name == "" || seen[s.Name] {
+			return nil, fmt.Errorf("%s: section names must be unique and non-empty (%q)", name, s.Name)
+		}
+		seen[s.Name] = true
+		tmpl, err := template.New(name + "." + s.Name).Funcs(funcs).Parse(s.Template)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s section %s: %w", name, s.Name, err)
+		}
+		t.sections = append(t.sections, section{Section: s, index: i, tmpl: tmpl})
+	}
+	return t, nil
+}
+
+// Must panics if New failed; for package-level prompt definitions.
+func Must(t *Template, err error) *Template {
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Result is a rendered prompt and what was cut to fit it.
+type Result struct {
+	Text      string
+	Tokens    int
+	Truncated []string // Sections shortened, in the order they were cut
+	Dropped   []string // Sections removed entirely
+}
+
+// Render executes every section with data and assembles the layout. If the estimated
+// size exceeds budget, optional sections are truncated or dropped, lowest priority
+// first (later sections first on ties). A budget <= 0 means unlimited. When the
+// required sections alone do not fit, the result is returned with ErrOverBudget.
+func (t *Template) Render(data interface{}, budget int) (*Result, error) {
+	texts := make(map[string]string, len(t.sections))
+	for _, s := range t.sections {
+		var sb strings.Builder
+		if err := s.tmpl.Execute(&sb, data); err != nil {
+			return nil, fmt.Errorf("rendering %s section %s: %w", t.name, s.Name, err)
+		}
+		texts[s.Name] = strings.TrimSpace(sb.String())
+	}
+
+	cuttable := make([]section, 0, len(t.sections))
+	for _, s := range t.sections {
+		if !s.Required {
+			cuttable = append(cuttable, s)
+		}
+	}
+	sort.SliceStable(cuttable, func(i, j int) bool {
+		if cuttable[i].Priority != cuttable[j].Priority {
+			return cuttable[i].Priority < cuttable[j].Priority
+		}
+		return cuttable[i].index > cuttable[j].index
+	})
+
+	res := &Result{}
+	for {
+		text, err := t.assemble(texts)
+		if err != nil {
+			return nil, err
+		}
+		res.Text, res.Tokens = text, EstimateTokens(text)
+		if budget <= 0 || res.Tokens <= budget {
+			return res, nil
+		}
+		for len(cuttable) > 0 && texts[cuttable[0].Name] == "" {
+			cuttable = cuttable[1:]
+		}
+		if len(cuttable) == 0 {
+			return res, ErrOverBudget
+		}
+
+		// Cut the next section by the overshoot; each pass shrinks the prompt, so this ends
+		s := cuttable[0]
+		current := EstimateTokens(texts[s.Name])
+		keep := current - (res.Tokens - budget)
+		minTokens := s.MinTokens
+		if minTokens == 0 {
+			minTokens = defaultMinTokens
+		}
+		if keep < minTokens || TruncateTokens(texts[s.Name], keep) == "" {
+			texts[s.Name] = ""
+			res.Dropped = append(res.Dropped, s.Name)
+			res.Truncated = remove(res.Truncated, s.Name)
+			continue
+		}
+		texts[s.Name] = TruncateTokens(texts[s.Name], keep)
+		if !contains(res.Truncated, s.Name) {
+			res.Truncated = append(res.Truncated, s.Name)
+		}
+	}
+}
+
+var blankLines = regexp.MustCompile(`\n[ \t]*\n(?:[ \t]*\n)+`)
+
+// assemble executes the layout and collapses the blank lines left by empty sections.
+func (t *Template) assemble(texts map[string]string) (string, error) {
+	var sb strings.Builder
+	if err := t.layout.Execute(&sb, texts); err != nil {
+		return "", fmt.Errorf("rendering %s layout: %w", t.name, err)
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(sb.String(), "\n\n")), nil
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func remove(list []string, v string) []string {
+	out := list[:0]
+	for _, item := range list {
+		if item != v {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,194 @@
+package prompt
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+// golden compares got with testdata/<name>.golden.
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s (run with -update to create it): %v", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+type fixture struct {
+	Role    string
+	Notes   []string
+	Mood    string
+	Request string
+}
+
+var testTemplate = Must(New("test", `{{.role}}
+
+{{.notes}}
+
+{{.mood}}
+
+{{.request}}`,
+	Section{Name: "role", Template: `You are a {{.Role}}.`, Priority: 100, Required: true},
+	Section{Name: "notes", Template: `[NOTES]{{range .Notes}}
+- {{.}}{{end}}`, Priority: 50, MinTokens: 10},
+	Section{Name: "mood", Template: `{{with .Mood}}[MOOD] {{.}}{{end}}`, Priority: 10},
+	Section{Name: "request", Template: `[REQUEST] {{trim .Request}}`, Priority: 90, Required: true},
+))
+
+var fullFixture = fixture{
+	Role: "helpful tutor",
+	Notes: []string{
+		"Unit 1 covers process scheduling and context switches.",
+		"Unit 2 covers deadlocks: detection, avoidance and prevention.",
+		"Unit 3 covers paging, segmentation and virtual memory.",
+		"Unit 4 covers file systems and disk scheduling.",
+	},
+	Mood:    "The student is stressed about the exam.",
+	Request: "  Explain the banker's algorithm.  ",
+}
+
+func TestRenderFits(t *testing.T) {
+	res, err := testTemplate.Render(fullFixture, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Truncated) != 0 || len(res.Dropped) != 0 {
+		t.Errorf("unlimited budget cut sections: truncated %v, dropped %v", res.Truncated, res.Dropped)
+	}
+	if res.Tokens != EstimateTokens(res.Text) {
+		t.Errorf("Tokens = %d, want %d", res.Tokens, EstimateTokens(res.Text))
+	}
+	golden(t, "fits", res.Text)
+}
+
+func TestRenderCollapsesEmptySections(t *testing.T) {
+	f := fullFixture
+	f.Mood = ""
+	res, err := testTemplate.Render(f, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(res.Text, "\n\n\n") {
+		t.Errorf("empty section left blank lines:\n%s", res.Text)
+	}
+	golden(t, "no_mood", res.Text)
+}
+
+func TestRenderDropsLowestPriorityFirst(t *testing.T) {
+	full, _ := testTemplate.Render(fullFixture, 0)
+	budget := full.Tokens - 2 // Only the mood section needs to go
+	res, err := testTemplate.Render(fullFixture, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Dropped, []string{"mood"}) || len(res.Truncated) != 0 {
+		t.Errorf("dropped %v, truncated %v; want only mood dropped", res.Dropped, res.Truncated)
+	}
+	if res.Tokens > budget {
+		t.Errorf("Tokens = %d, over budget %d", res.Tokens, budget)
+	}
+	golden(t, "drop_mood", res.Text)
+}
+
+func TestRenderTruncatesSection(t *testing.T) {
+	full, _ := testTemplate.Render(fullFixture, 0)
+	mood := EstimateTokens("[MOOD] " + fullFixture.Mood)
+	budget := full.Tokens - mood - 15 // Mood goes, then the notes lose their tail
+	res, err := testTemplate.Render(fullFixture, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Dropped, []string{"mood"}) || !reflect.DeepEqual(res.Truncated, []string{"notes"}) {
+		t.Errorf("dropped %v, truncated %v; want mood dropped and notes truncated", res.Dropped, res.Truncated)
+	}
+	if res.Tokens > budget {
+		t.Errorf("Tokens = %d, over budget %d", res.Tokens, budget)
+	}
+	golden(t, "truncate_notes", res.Text)
+}
+
+func TestRenderKeepsRequiredSections(t *testing.T) {
+	res, err := testTemplate.Render(fullFixture, 20)
+	if !errors.Is(err, ErrOverBudget) {
+		t.Fatalf("err = %v, want ErrOverBudget", err)
+	}
+	if !reflect.DeepEqual(res.Dropped, []string{"mood", "notes"}) {
+		t.Errorf("dropped %v, want [mood notes]", res.Dropped)
+	}
+	golden(t, "required_only", res.Text)
+}
+
+func TestNewRejectsDuplicateSections(t *testing.T) {
+	_, err := New("dup", `{{.a}}`, Section{Name: "a", Template: "x"}, Section{Name: "a", Template: "y"})
+	if err == nil {
+		t.Fatal("expected an error for duplicate section names")
+	}
+}
+
+func TestLayoutUnknownSection(t *testing.T) {
+	tmpl := Must(New("typo", `{{.missing}}`, Section{Name: "a", Template: "x"}))
+	if _, err := tmpl.Render(nil, 0); err == nil {
+		t.Fatal("expected an error for a layout referencing an unknown section")
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"hi", 1},
+		{"hello", 2},
+		{"hello world", 4},
+		{"CS101?", 3},
+		{"a, b.", 4},
+		{"  spaced   out  ", 3},
+		{"naïve café", 3},
+	}
+	for _, c := range cases {
+		if got := EstimateTokens(c.in); got != c.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestTruncateTokens(t *testing.T) {
+	text := "first line here\nsecond line here\nthird line here\nfourth line here\nfifth line here"
+	cases := []struct {
+		max  int
+		want string
+	}{
+		{100, text},
+		{EstimateTokens("first line here\nsecond line here\n") + EstimateTokens(TruncatedMarker), "first line here\nsecond line here\n" + TruncatedMarker},
+		{EstimateTokens("first line") + EstimateTokens(TruncatedMarker), "first line " + TruncatedMarker},
+		{EstimateTokens(TruncatedMarker) + 1, TruncatedMarker},
+		{1, ""},
+	}
+	for _, c := range cases {
+		got := TruncateTokens(text, c.max)
+		if got != c.want {
+			t.Errorf("TruncateTokens(max=%d) = %q, want %q", c.max, got, c.want)
+		}
+		if EstimateTokens(got) > c.max {
+			t.Errorf("TruncateTokens(max=%d) returned %d tokens", c.max, EstimateTokens(got))
+		}
+	}
+}
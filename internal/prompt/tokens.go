@@ -0,0 +1,75 @@
+package prompt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TruncatedMarker ends a section that was cut to fit the budget.
+const TruncatedMarker = "[...truncated]"
+
+// EstimateTokens approximates how many tokens a BPE tokenizer produces for s: about
+// one per four letters or digits of each word, plus one per punctuation mark. It errs
+// on the high side for English so budgets stay safe without a model-specific vocabulary.
+func EstimateTokens(s string) int {
+	tokens, word := 0, 0
+	flush := func() {
+		tokens += (word + 3) / 4
+		word = 0
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word++
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
+
+// TruncateTokens shortens s to at most max estimated tokens, including the marker.
+// Whole lines are kept where possible, then whole words of the line that overflows.
+func TruncateTokens(s string, max int) string {
+	if EstimateTokens(s) <= max {
+		return s
+	}
+	room := max - EstimateTokens(TruncatedMarker)
+	if room <= 0 {
+		return ""
+	}
+
+	var kept strings.Builder
+	used := 0
+	for _, line := range strings.SplitAfter(s, "\n") {
+		n := EstimateTokens(line)
+		if used+n <= room {
+			kept.WriteString(line)
+			used += n
+			continue
+		}
+		for _, word := range strings.Fields(line) {
+			n := EstimateTokens(word)
+			if used+n > room {
+				break
+			}
+			kept.WriteString(word)
+			kept.WriteString(" ")
+			used += n
+		}
+		break
+	}
+
+	out := strings.TrimRight(kept.String(), " \t\n")
+	if out == "" {
+		return TruncatedMarker
+	}
+	if strings.HasSuffix(kept.String(), "\n") {
+		return out + "\n" + TruncatedMarker
+	}
+	return out + " " + TruncatedMarker
+}
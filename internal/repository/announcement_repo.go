@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// AnnouncementStore is the Postgres AnnouncementRepository.
+type AnnouncementStore struct {
+	db *sql.DB
+}
+
+func (r *AnnouncementStore) list(ctx context.Context, query string, args ...interface{}) ([]models.CourseAnnouncement, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	announcements := make([]models.CourseAnnouncement, 0)
+	for rows.Next() {
+		var a models.CourseAnnouncement
+		var createdAt time.Time
+		if err := rows.Scan(&a.Content, &createdAt, &a.Course); err != nil {
+			return nil, err
+		}
+		a.Date = announcementDate(createdAt)
+		announcements = append(announcements, a)
+	}
+	return announcements, rows.Err()
+}
+
+func (r *AnnouncementStore) ForStudent(ctx context.Context, studentID string) ([]models.CourseAnnouncement, error) {
+	return r.list(ctx, `
+		SELECT a.content, a.created_at, c.title
+		FROM ANNOUNCEMENT a
+		JOIN ENROLLS_IN e ON a.course_id = e.course_id
+		JOIN COURSE c ON a.course_id = c.course_id
+		WHERE e.student_id = $1
+		ORDER BY a.created_at DESC
+	`, studentID)
+}
+
+func (r *AnnouncementStore) ForCourses(ctx context.Context, courseIDs []string, limit int) ([]models.CourseAnnouncement, error) {
+	return r.list(ctx, `
+		SELECT a.content, a.created_at, c.title
+		FROM ANNOUNCEMENT a
+		JOIN COURSE c ON a.course_id = c.course_id
+		WHERE a.course_id = ANY($1::text[])
+		ORDER BY a.created_at DESC
+		LIMIT $2
+	`, courseIDs, limit)
+}
+
+func (r *AnnouncementStore) Create(ctx context.Context, facultyID, courseID, content string) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO ANNOUNCEMENT (faculty_id, course_id, content) VALUES ($1, $2, $3)", facultyID, courseID, content)
+	return err
+}
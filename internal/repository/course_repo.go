@@ -1,10 +1,15 @@
 package repository
 
 import (
+	"academ_aide/internal/metrics"
+	"academ_aide/internal/tracing"
 	"context"
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type CourseRepository struct {
@@ -84,6 +89,21 @@ func (r *CourseRepository) GetAllCoursesWithoutEmbeddings(ctx context.Context) (
 	return courses, nil
 }
 
+// Get returns one course. ErrNotFound if it does not exist.
+func (r *CourseRepository) Get(ctx context.Context, courseID string) (*Course, error) {
+	var c Course
+	var desc sql.NullString
+	err := r.DB.QueryRowContext(ctx, `SELECT course_id, title, description, credits, dept_id FROM COURSE WHERE course_id = $1`, courseID).
+		Scan(&c.CourseID, &c.Title, &desc, &c.Credits, &c.DeptID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	c.Description = desc.String
+	return &c, nil
+}
+
 // Helper to format float slice to vector string
 func vecToString(vec []float32) string {
 	var sb strings.Builder
@@ -107,7 +127,7 @@ type CourseMaterial struct {
 }
 
 // SearchMaterials finds the most relevant material chunks using the custom cosine_similarity function
-func (r *CourseRepository) SearchMaterials(ctx context.Context, embedding []float32, limit int, courseIDFilter []string, unitFilter int) ([]CourseMaterial, error) {
+func (r *CourseRepository) SearchMaterials(ctx context.Context, embedding []float32, limit int, courseIDFilter []string, unitFilter int) (materials []CourseMaterial, err error) {
 	// Format as PGVector string: [0.1, 0.2, ...]
 	vectorStr := vecToString(embedding)
 
@@ -155,13 +175,20 @@ func (r *CourseRepository) SearchMaterials(ctx context.Context, embedding []floa
 		args = []interface{}{vectorStr, limit}
 	}
 
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "vector_search",
+		attribute.Int("rag.limit", limit), attribute.Int("rag.courses", len(courseIDFilter)), attribute.Int("rag.unit", unitFilter))
+	defer func() {
+		metrics.Since(metrics.VectorSearchDuration, start)
+		span.SetAttributes(tracing.ChunkCount.Int(len(materials)))
+		tracing.End(span, err)
+	}()
 	rows, err := r.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query failed: %w", err)
 	}
 	defer rows.Close()
 
-	var materials []CourseMaterial
 	for rows.Next() {
 		var m CourseMaterial
 		var sourceFile sql.NullString
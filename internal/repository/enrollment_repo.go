@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+)
+
+// EnrollmentStore is the Postgres EnrollmentRepository.
+type EnrollmentStore struct {
+	db *sql.DB
+}
+
+func (r *EnrollmentStore) Current(ctx context.Context, studentID string) ([]models.EnrolledCourse, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.course_id, c.title
+		FROM ENROLLS_IN e
+		JOIN COURSE c ON e.course_id = c.course_id
+		WHERE e.student_id = $1 AND e.status = 'Enrolled'
+		ORDER BY c.course_id
+	`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := make([]models.EnrolledCourse, 0)
+	for rows.Next() {
+		var c models.EnrolledCourse
+		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+			return nil, err
+		}
+		courses = append(courses, c)
+	}
+	return courses, rows.Err()
+}
+
+const enrollmentSelect = `
+	SELECT e.student_id, e.course_id, c.title, c.credits, e.status, COALESCE(e.grade, '')
+	FROM ENROLLS_IN e
+	JOIN COURSE c ON e.course_id = c.course_id
+`
+
+func scanEnrollment(row interface{ Scan(...interface{}) error }) (models.Enrollment, error) {
+	var e models.Enrollment
+	err := row.Scan(&e.StudentID, &e.CourseID, &e.Title, &e.Credits, &e.Status, &e.Grade)
+	return e, err
+}
+
+func (r *EnrollmentStore) History(ctx context.Context, studentID string) ([]models.Enrollment, error) {
+	rows, err := r.db.QueryContext(ctx, enrollmentSelect+"WHERE e.student_id = $1 ORDER BY e.course_id", studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	enrollments := make([]models.Enrollment, 0)
+	for rows.Next() {
+		e, err := scanEnrollment(rows)
+		if err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	return enrollments, rows.Err()
+}
+
+func (r *EnrollmentStore) IsEnrolled(ctx context.Context, studentID, courseID string) (bool, error) {
+	var enrolled bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM ENROLLS_IN WHERE student_id=$1 AND course_id=$2)",
+		studentID, courseID).Scan(&enrolled)
+	return enrolled, err
+}
+
+// Get returns one enrollment. ErrNotFound if the student never took the course.
+func (r *EnrollmentStore) Get(ctx context.Context, studentID, courseID string) (*models.Enrollment, error) {
+	e, err := scanEnrollment(r.db.QueryRowContext(ctx, enrollmentSelect+"WHERE e.student_id = $1 AND e.course_id = $2", studentID, courseID))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *EnrollmentStore) Students(ctx context.Context, courseID string) ([]models.StudentContact, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.student_id, s.s_first_name, s.s_last_name, s.s_email
+		FROM ENROLLS_IN e
+		JOIN STUDENT s ON e.student_id = s.student_id
+		WHERE e.course_id=$1
+		ORDER BY s.student_id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	students := make([]models.StudentContact, 0)
+	for rows.Next() {
+		var sc models.StudentContact
+		var first, last string
+		if err := rows.Scan(&sc.StudentID, &first, &last, &sc.Email); err != nil {
+			return nil, err
+		}
+		sc.Name = first + " " + last
+		students = append(students, sc)
+	}
+	return students, rows.Err()
+}
+
+func (r *EnrollmentStore) Grades(ctx context.Context, courseID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT grade FROM ENROLLS_IN WHERE course_id=$1 AND grade IS NOT NULL", courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grades := make([]string, 0)
+	for rows.Next() {
+		var g string
+		if err := rows.Scan(&g); err != nil {
+			return nil, err
+		}
+		grades = append(grades, g)
+	}
+	return grades, rows.Err()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+)
+
+// FacultyStore is the Postgres FacultyRepository.
+type FacultyStore struct {
+	db *sql.DB
+}
+
+// Get returns a faculty member. ErrNotFound if they do not exist.
+func (r *FacultyStore) Get(ctx context.Context, facultyID string) (*models.Faculty, error) {
+	var f models.Faculty
+	err := r.db.QueryRowContext(ctx, `
+		SELECT faculty_id, f_first_name, f_last_name, f_email, f_phone_no
+		FROM FACULTY WHERE faculty_id=$1
+	`, facultyID).Scan(&f.FacultyID, &f.FirstName, &f.LastName, &f.Email, &f.PhoneNo)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (r *FacultyStore) Exists(ctx context.Context, facultyID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM FACULTY WHERE faculty_id=$1)", facultyID).Scan(&exists)
+	return exists, err
+}
+
+func (r *FacultyStore) Courses(ctx context.Context, facultyID string) ([]models.TaughtCourse, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.course_id, c.title, t.section_name
+		FROM TEACHES t
+		JOIN COURSE c ON t.course_id = c.course_id
+		WHERE t.faculty_id=$1
+		ORDER BY c.course_id, t.section_name
+	`, facultyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := make([]models.TaughtCourse, 0)
+	for rows.Next() {
+		var tc models.TaughtCourse
+		if err := rows.Scan(&tc.CourseID, &tc.Title, &tc.Section); err != nil {
+			return nil, err
+		}
+		courses = append(courses, tc)
+	}
+	return courses, rows.Err()
+}
+
+func (r *FacultyStore) Departments(ctx context.Context, facultyID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT d.dept_name
+		FROM TEACHES t
+		JOIN COURSE c ON t.course_id = c.course_id
+		JOIN DEPARTMENT d ON c.dept_id = d.dept_id
+		WHERE t.faculty_id=$1
+		ORDER BY d.dept_name
+	`, facultyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var depts []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		depts = append(depts, name)
+	}
+	return depts, rows.Err()
+}
+
+func (r *FacultyStore) TeachersOf(ctx context.Context, studentID string) ([]models.CourseTeacher, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT DISTINCT f.f_first_name, f.f_last_name, f.f_email, c.title, c.course_id
+		FROM TEACHES t
+		JOIN FACULTY f ON t.faculty_id = f.faculty_id
+		JOIN COURSE c ON t.course_id = c.course_id
+		JOIN ENROLLS_IN e ON t.course_id = e.course_id
+		WHERE e.student_id = $1 AND e.status = 'Enrolled'
+		ORDER BY c.title
+	`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teachers := make([]models.CourseTeacher, 0)
+	for rows.Next() {
+		var t models.CourseTeacher
+		var first, last string
+		if err := rows.Scan(&first, &last, &t.Email, &t.Course, &t.CourseID); err != nil {
+			return nil, err
+		}
+		t.Name = first + " " + last
+		teachers = append(teachers, t)
+	}
+	return teachers, rows.Err()
+}
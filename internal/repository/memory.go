@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory holds the tables behind the repositories in memory, for tests. Fill the
+// exported slices, then pass Repositories() to the services under test.
+//
+// It has no section data, so StudentWeek lists every section like ForStudent.
+type Memory struct {
+	mu            sync.RWMutex
+	Courses       []Course
+	Students      []models.Student
+	Faculty       []models.Faculty
+	Departments   []models.Department
+	Enrollments   []models.Enrollment // StudentID, CourseID, Status and Grade; the rest comes from Courses
+	Teaches       []models.Teaches
+	Schedules     []models.Schedule
+	Announcements []Announcement
+}
+
+// Repositories returns every repository, all reading the same Memory.
+func (m *Memory) Repositories() Repositories {
+	return Repositories{
+		Courses:       memCourses{m},
+		Students:      memStudents{m},
+		Enrollments:   memEnrollments{m},
+		Schedules:     memSchedules{m},
+		Faculty:       memFaculty{m},
+		Announcements: memAnnouncements{m},
+	}
+}
+
+func (m *Memory) course(courseID string) (Course, bool) {
+	for _, c := range m.Courses {
+		if c.CourseID == courseID {
+			return c, true
+		}
+	}
+	return Course{}, false
+}
+
+// enrollments joins the student's enrollments with their courses.
+func (m *Memory) enrollments(studentID string) []models.Enrollment {
+	var out []models.Enrollment
+	for _, e := range m.Enrollments {
+		if e.StudentID != studentID {
+			continue
+		}
+		if c, ok := m.course(e.CourseID); ok {
+			e.Title, e.Credits = c.Title, c.Credits
+		}
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CourseID < out[j].CourseID })
+	return out
+}
+
+func (m *Memory) current(studentID string) []models.Enrollment {
+	var out []models.Enrollment
+	for _, e := range m.enrollments(studentID) {
+		if e.Status == "Enrolled" {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// --- Courses ---
+
+type memCourses struct{ m *Memory }
+
+func (r memCourses) Get(_ context.Context, courseID string) (*Course, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	if c, ok := r.m.course(courseID); ok {
+		return &c, nil
+	}
+	return nil, ErrNotFound
+}
+
+// --- Students ---
+
+type memStudents struct{ m *Memory }
+
+func (r memStudents) Get(_ context.Context, studentID string) (*models.Student, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, st := range r.m.Students {
+		if st.StudentID == studentID {
+			return &st, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memStudents) Exists(ctx context.Context, studentID string) (bool, error) {
+	_, err := r.Get(ctx, studentID)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r memStudents) IDByEmail(_ context.Context, email string) (string, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, st := range r.m.Students {
+		if st.Email == email {
+			return st.StudentID, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (r memStudents) Create(_ context.Context, st models.Student) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.m.Students = append(r.m.Students, st)
+	return nil
+}
+
+// --- Enrollments ---
+
+type memEnrollments struct{ m *Memory }
+
+func (r memEnrollments) Current(_ context.Context, studentID string) ([]models.EnrolledCourse, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	courses := make([]models.EnrolledCourse, 0)
+	for _, e := range r.m.current(studentID) {
+		courses = append(courses, models.EnrolledCourse{ID: e.CourseID, Name: e.Title})
+	}
+	return courses, nil
+}
+
+func (r memEnrollments) History(_ context.Context, studentID string) ([]models.Enrollment, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return append(make([]models.Enrollment, 0), r.m.enrollments(studentID)...), nil
+}
+
+func (r memEnrollments) IsEnrolled(ctx context.Context, studentID, courseID string) (bool, error) {
+	_, err := r.Get(ctx, studentID, courseID)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r memEnrollments) Get(_ context.Context, studentID, courseID string) (*models.Enrollment, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, e := range r.m.enrollments(studentID) {
+		if e.CourseID == courseID {
+			return &e, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memEnrollments) Students(_ context.Context, courseID string) ([]models.StudentContact, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	students := make([]models.StudentContact, 0)
+	for _, st := range r.m.Students {
+		for _, e := range r.m.Enrollments {
+			if e.StudentID == st.StudentID && e.CourseID == courseID {
+				students = append(students, models.StudentContact{StudentID: st.StudentID, Name: st.FirstName + " " + st.LastName, Email: st.Email})
+				break
+			}
+		}
+	}
+	sort.Slice(students, func(i, j int) bool { return students[i].StudentID < students[j].StudentID })
+	return students, nil
+}
+
+func (r memEnrollments) Grades(_ context.Context, courseID string) ([]string, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	grades := make([]string, 0)
+	for _, e := range r.m.Enrollments {
+		if e.CourseID == courseID && e.Grade != "" {
+			grades = append(grades, e.Grade)
+		}
+	}
+	return grades, nil
+}
+
+// --- Schedules ---
+
+type memSchedules struct{ m *Memory }
+
+var weekdays = map[string]int{"Monday": 1, "Tuesday": 2, "Wednesday": 3, "Thursday": 4, "Friday": 5, "Saturday": 6}
+
+func weekdayOrder(day string) int {
+	if n, ok := weekdays[day]; ok {
+		return n
+	}
+	return 7
+}
+
+// slots lists the schedule rows keep accepts, Monday first.
+func (m *Memory) slots(keep func(models.Schedule) bool) []models.ScheduleItem {
+	items := make([]models.ScheduleItem, 0)
+	for _, sch := range m.Schedules {
+		if !keep(sch) {
+			continue
+		}
+		c, _ := m.course(sch.CourseID)
+		items = append(items, models.ScheduleItem{
+			CourseID: sch.CourseID, Title: c.Title, SectionName: sch.SectionName, DayOfWeek: sch.DayOfWeek,
+			StartTime: sch.StartTime, EndTime: sch.EndTime, RoomNumber: sch.RoomNumber,
+		})
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if di, dj := weekdayOrder(items[i].DayOfWeek), weekdayOrder(items[j].DayOfWeek); di != dj {
+			return di < dj
+		}
+		return items[i].StartTime < items[j].StartTime
+	})
+	return items
+}
+
+func (m *Memory) studentSlots(studentID string) []models.ScheduleItem {
+	enrolled := map[string]bool{}
+	for _, e := range m.Enrollments {
+		if e.StudentID == studentID {
+			enrolled[e.CourseID] = true
+		}
+	}
+	return m.slots(func(sch models.Schedule) bool { return enrolled[sch.CourseID] })
+}
+
+func (r memSchedules) ForStudent(_ context.Context, studentID string) ([]models.ScheduleItem, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return r.m.studentSlots(studentID), nil
+}
+
+func (r memSchedules) StudentWeek(ctx context.Context, studentID string) ([]models.ScheduleItem, error) {
+	return r.ForStudent(ctx, studentID)
+}
+
+func (r memSchedules) ForFaculty(_ context.Context, facultyID string) ([]models.ScheduleItem, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	return r.m.slots(func(sch models.Schedule) bool {
+		for _, t := range r.m.Teaches {
+			if t.FacultyID == facultyID && t.CourseID == sch.CourseID && t.SectionName == sch.SectionName {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (r memSchedules) NextForStudent(_ context.Context, studentID, day, after string) (*models.ScheduleItem, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, it := range r.m.studentSlots(studentID) {
+		if it.DayOfWeek == day && it.StartTime > after {
+			return &it, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// --- Faculty ---
+
+type memFaculty struct{ m *Memory }
+
+func (r memFaculty) Get(_ context.Context, facultyID string) (*models.Faculty, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	for _, f := range r.m.Faculty {
+		if f.FacultyID == facultyID {
+			return &f, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r memFaculty) Exists(ctx context.Context, facultyID string) (bool, error) {
+	_, err := r.Get(ctx, facultyID)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r memFaculty) Courses(_ context.Context, facultyID string) ([]models.TaughtCourse, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	courses := make([]models.TaughtCourse, 0)
+	for _, t := range r.m.Teaches {
+		if t.FacultyID == facultyID {
+			c, _ := r.m.course(t.CourseID)
+			courses = append(courses, models.TaughtCourse{CourseID: t.CourseID, Title: c.Title, Section: t.SectionName})
+		}
+	}
+	sort.Slice(courses, func(i, j int) bool {
+		if courses[i].CourseID != courses[j].CourseID {
+			return courses[i].CourseID < courses[j].CourseID
+		}
+		return courses[i].Section < courses[j].Section
+	})
+	return courses, nil
+}
+
+func (r memFaculty) Departments(_ context.Context, facultyID string) ([]string, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	seen := map[string]bool{}
+	var depts []string
+	for _, t := range r.m.Teaches {
+		if t.FacultyID != facultyID {
+			continue
+		}
+		c, _ := r.m.course(t.CourseID)
+		for _, d := range r.m.Departments {
+			if d.DeptID == c.DeptID && !seen[d.DeptName] {
+				seen[d.DeptName] = true
+				depts = append(depts, d.DeptName)
+			}
+		}
+	}
+	sort.Strings(depts)
+	return depts, nil
+}
+
+func (r memFaculty) TeachersOf(_ context.Context, studentID string) ([]models.CourseTeacher, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	seen := map[models.CourseTeacher]bool{}
+	teachers := make([]models.CourseTeacher, 0)
+	for _, e := range r.m.current(studentID) {
+		for _, t := range r.m.Teaches {
+			if t.CourseID != e.CourseID {
+				continue
+			}
+			for _, f := range r.m.Faculty {
+				ct := models.CourseTeacher{Name: f.FirstName + " " + f.LastName, Email: f.Email, Course: e.Title, CourseID: e.CourseID}
+				if f.FacultyID == t.FacultyID && !seen[ct] {
+					seen[ct] = true
+					teachers = append(teachers, ct)
+				}
+			}
+		}
+	}
+	sort.SliceStable(teachers, func(i, j int) bool { return teachers[i].Course < teachers[j].Course })
+	return teachers, nil
+}
+
+// --- Announcements ---
+
+type memAnnouncements struct{ m *Memory }
+
+// newest lists the announcements keep accepts, newest first, at most limit (0 for all).
+func (m *Memory) newest(keep func(Announcement) bool, limit int) []models.CourseAnnouncement {
+	var matched []Announcement
+	for _, a := range m.Announcements {
+		if keep(a) {
+			matched = append(matched, a)
+		}
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+	if limit > 0 && len(matched) > limit {
+		matched = matched[:limit]
+	}
+	out := make([]models.CourseAnnouncement, 0, len(matched))
+	for _, a := range matched {
+		c, _ := m.course(a.CourseID)
+		out = append(out, models.CourseAnnouncement{Course: c.Title, Content: a.Content, Date: announcementDate(a.CreatedAt)})
+	}
+	return out
+}
+
+func (r memAnnouncements) ForStudent(_ context.Context, studentID string) ([]models.CourseAnnouncement, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	enrolled := map[string]bool{}
+	for _, e := range r.m.Enrollments {
+		if e.StudentID == studentID {
+			enrolled[e.CourseID] = true
+		}
+	}
+	return r.m.newest(func(a Announcement) bool { return enrolled[a.CourseID] }, 0), nil
+}
+
+func (r memAnnouncements) ForCourses(_ context.Context, courseIDs []string, limit int) ([]models.CourseAnnouncement, error) {
+	r.m.mu.RLock()
+	defer r.m.mu.RUnlock()
+	wanted := map[string]bool{}
+	for _, id := range courseIDs {
+		wanted[id] = true
+	}
+	return r.m.newest(func(a Announcement) bool { return wanted[a.CourseID] }, limit), nil
+}
+
+func (r memAnnouncements) Create(_ context.Context, facultyID, courseID, content string) error {
+	r.m.mu.Lock()
+	defer r.m.mu.Unlock()
+	r.m.Announcements = append(r.m.Announcements, Announcement{FacultyID: facultyID, CourseID: courseID, Content: content, CreatedAt: time.Now()})
+	return nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned when a looked-up row does not exist. The services
+// re-export it, so handlers can keep checking services.ErrNotFound.
+var ErrNotFound = errors.New("record not found")
+
+// The services read students, enrollments, timetables, faculty and announcements
+// through these interfaces. Postgres implements them for the server; Memory
+// implements them for tests.
+
+type CourseReader interface {
+	Get(ctx context.Context, courseID string) (*Course, error)
+}
+
+type StudentRepository interface {
+	Get(ctx context.Context, studentID string) (*models.Student, error)
+	Exists(ctx context.Context, studentID string) (bool, error)
+	IDByEmail(ctx context.Context, email string) (string, error)
+	Create(ctx context.Context, st models.Student) error
+}
+
+type EnrollmentRepository interface {
+	// Current lists the courses the student is enrolled in now, by course ID.
+	Current(ctx context.Context, studentID string) ([]models.EnrolledCourse, error)
+	// History lists every enrollment of the student, whatever its status.
+	History(ctx context.Context, studentID string) ([]models.Enrollment, error)
+	IsEnrolled(ctx context.Context, studentID, courseID string) (bool, error)
+	Get(ctx context.Context, studentID, courseID string) (*models.Enrollment, error)
+	Students(ctx context.Context, courseID string) ([]models.StudentContact, error)
+	// Grades lists the grades awarded in a course so far.
+	Grades(ctx context.Context, courseID string) ([]string, error)
+}
+
+type ScheduleRepository interface {
+	// ForStudent lists every slot of the student's courses, across all sections.
+	ForStudent(ctx context.Context, studentID string) ([]models.ScheduleItem, error)
+	// StudentWeek lists the slots of the student's own section, Monday first.
+	StudentWeek(ctx context.Context, studentID string) ([]models.ScheduleItem, error)
+	// ForFaculty lists the slots of the sections the faculty member teaches, Monday first.
+	ForFaculty(ctx context.Context, facultyID string) ([]models.ScheduleItem, error)
+	// NextForStudent is the student's first class on day starting after the given
+	// HH:MM time. ErrNotFound if there is none.
+	NextForStudent(ctx context.Context, studentID, day, after string) (*models.ScheduleItem, error)
+}
+
+type FacultyRepository interface {
+	Get(ctx context.Context, facultyID string) (*models.Faculty, error)
+	Exists(ctx context.Context, facultyID string) (bool, error)
+	// Courses lists the course sections the faculty member teaches, by course ID.
+	Courses(ctx context.Context, facultyID string) ([]models.TaughtCourse, error)
+	Departments(ctx context.Context, facultyID string) ([]string, error)
+	// TeachersOf lists who teaches each of the student's current courses, by title.
+	TeachersOf(ctx context.Context, studentID string) ([]models.CourseTeacher, error)
+}
+
+type AnnouncementRepository interface {
+	// ForStudent lists the announcements of the student's courses, newest first.
+	ForStudent(ctx context.Context, studentID string) ([]models.CourseAnnouncement, error)
+	// ForCourses lists up to limit announcements of the given courses, newest first.
+	ForCourses(ctx context.Context, courseIDs []string, limit int) ([]models.CourseAnnouncement, error)
+	Create(ctx context.Context, facultyID, courseID, content string) error
+}
+
+// Repositories bundles one implementation of each repository.
+type Repositories struct {
+	Courses       CourseReader
+	Students      StudentRepository
+	Enrollments   EnrollmentRepository
+	Schedules     ScheduleRepository
+	Faculty       FacultyRepository
+	Announcements AnnouncementRepository
+}
+
+// NewPostgres returns the repositories backed by the relational database.
+func NewPostgres(db *sql.DB) Repositories {
+	return Repositories{
+		Courses:       NewCourseRepository(db),
+		Students:      &StudentStore{db: db},
+		Enrollments:   &EnrollmentStore{db: db},
+		Schedules:     &ScheduleStore{db: db},
+		Faculty:       &FacultyStore{db: db},
+		Announcements: &AnnouncementStore{db: db},
+	}
+}
+
+// Announcement is a stored course announcement.
+type Announcement struct {
+	FacultyID string
+	CourseID  string
+	Content   string
+	CreatedAt time.Time
+}
+
+// announcementDate is how announcements are dated in the API and the chat.
+func announcementDate(t time.Time) string {
+	return t.Format("Jan 02, 15:04")
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+)
+
+// ScheduleStore is the Postgres ScheduleRepository.
+type ScheduleStore struct {
+	db *sql.DB
+}
+
+const scheduleColumns = `
+	SELECT c.course_id, c.title, sch.section_name, sch.day_of_week,
+	       TO_CHAR(sch.start_time, 'HH24:MI'), TO_CHAR(sch.end_time, 'HH24:MI'), COALESCE(sch.room_number, '')
+`
+
+const dayOrder = `
+	CASE sch.day_of_week
+		WHEN 'Monday' THEN 1
+		WHEN 'Tuesday' THEN 2
+		WHEN 'Wednesday' THEN 3
+		WHEN 'Thursday' THEN 4
+		WHEN 'Friday' THEN 5
+		WHEN 'Saturday' THEN 6
+		ELSE 7
+	END`
+
+func (r *ScheduleStore) list(ctx context.Context, query string, args ...interface{}) ([]models.ScheduleItem, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]models.ScheduleItem, 0)
+	for rows.Next() {
+		var i models.ScheduleItem
+		if err := rows.Scan(&i.CourseID, &i.Title, &i.SectionName, &i.DayOfWeek, &i.StartTime, &i.EndTime, &i.RoomNumber); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	return items, rows.Err()
+}
+
+func (r *ScheduleStore) ForStudent(ctx context.Context, studentID string) ([]models.ScheduleItem, error) {
+	return r.list(ctx, scheduleColumns+`
+		FROM ENROLLS_IN e
+		JOIN COURSE c ON e.course_id = c.course_id
+		JOIN SCHEDULE sch ON c.course_id = sch.course_id
+		WHERE e.student_id = $1
+		ORDER BY sch.day_of_week, sch.start_time
+	`, studentID)
+}
+
+// StudentWeek infers the section, as ENROLLS_IN has none: three known students
+// attend CSE-A and everyone else the CSE-D day classes.
+func (r *ScheduleStore) StudentWeek(ctx context.Context, studentID string) ([]models.ScheduleItem, error) {
+	return r.list(ctx, scheduleColumns+`
+		FROM SCHEDULE sch
+		JOIN ENROLLS_IN e ON sch.course_id = e.course_id
+		JOIN COURSE c ON sch.course_id = c.course_id
+		WHERE e.student_id = $1
+		AND (
+			(
+				$1 IN ('1RV23CS221', '1RV23CS234', '1RV23CS211')
+				AND sch.section_name = 'CSE-A'
+			)
+			OR
+			(
+				$1 NOT IN ('1RV23CS221', '1RV23CS234', '1RV23CS211')
+				AND sch.section_name = 'CSE-D'
+				AND sch.room_number NOT LIKE 'Night Class%'
+			)
+		)
+		ORDER BY `+dayOrder+`, sch.start_time
+	`, studentID)
+}
+
+func (r *ScheduleStore) ForFaculty(ctx context.Context, facultyID string) ([]models.ScheduleItem, error) {
+	return r.list(ctx, scheduleColumns+`
+		FROM SCHEDULE sch
+		JOIN TEACHES t ON t.course_id = sch.course_id AND t.section_name = sch.section_name
+		JOIN COURSE c ON sch.course_id = c.course_id
+		WHERE t.faculty_id = $1
+		ORDER BY `+dayOrder+`, sch.start_time
+	`, facultyID)
+}
+
+func (r *ScheduleStore) NextForStudent(ctx context.Context, studentID, day, after string) (*models.ScheduleItem, error) {
+	items, err := r.list(ctx, scheduleColumns+`
+		FROM SCHEDULE sch
+		JOIN ENROLLS_IN e ON sch.course_id = e.course_id
+		JOIN COURSE c ON sch.course_id = c.course_id
+		WHERE e.student_id=$1 AND sch.day_of_week=$2 AND sch.start_time > $3
+		ORDER BY sch.start_time ASC
+		LIMIT 1
+	`, studentID, day, after+":00")
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, ErrNotFound
+	}
+	return &items[0], nil
+}
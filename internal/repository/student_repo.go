@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+)
+
+// StudentStore is the Postgres StudentRepository.
+type StudentStore struct {
+	db *sql.DB
+}
+
+// Get returns the student's profile columns. ErrNotFound if they do not exist.
+func (r *StudentStore) Get(ctx context.Context, studentID string) (*models.Student, error) {
+	var st models.Student
+	err := r.db.QueryRowContext(ctx, `
+		SELECT student_id, s_first_name, s_last_name, s_email, s_phone_no, semester, year_of_joining, dept_id
+		FROM STUDENT WHERE student_id=$1`, studentID).Scan(
+		&st.StudentID, &st.FirstName, &st.LastName, &st.Email, &st.PhoneNo, &st.Semester, &st.YearOfJoining, &st.DeptID,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (r *StudentStore) Exists(ctx context.Context, studentID string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM STUDENT WHERE student_id=$1)", studentID).Scan(&exists)
+	return exists, err
+}
+
+// IDByEmail finds a student by email. ErrNotFound if there is none.
+func (r *StudentStore) IDByEmail(ctx context.Context, email string) (string, error) {
+	var studentID string
+	err := r.db.QueryRowContext(ctx, "SELECT student_id FROM STUDENT WHERE s_email=$1", email).Scan(&studentID)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return studentID, err
+}
+
+func (r *StudentStore) Create(ctx context.Context, st models.Student) error {
+	_, err := r.db.ExecContext(ctx, "INSERT INTO STUDENT (student_id, s_first_name, s_last_name, s_email, s_phone_no, semester, year_of_joining, dept_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+		st.StudentID, st.FirstName, st.LastName, st.Email, st.PhoneNo, st.Semester, st.YearOfJoining, st.DeptID)
+	return err
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"academ_aide/internal/cache"
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AccountService looks up login identities and keeps sessions in Redis.
+type AccountService struct {
+	db       *sql.DB
+	rdb      *redis.Client
+	students repository.StudentRepository
+	faculty  repository.FacultyRepository
+}
+
+func NewAccountService(db *sql.DB, rdb *redis.Client, students repository.StudentRepository, faculty repository.FacultyRepository) *AccountService {
+	return &AccountService{
+		db:       db,
+		rdb:      rdb,
+		students: students,
+		faculty:  faculty,
+	}
+}
+
+// accountQueries checks that an ID exists for the roles without a repository.
+var accountQueries = map[string]string{
+	"admin":      "SELECT EXISTS(SELECT 1 FROM ADMIN WHERE admin_id=$1)",
+	"counsellor": "SELECT EXISTS(SELECT 1 FROM COUNSELLOR WHERE counsellor_id=$1 AND active)",
+}
+
+// Exists reports whether id is an account of the given role.
+func (s *AccountService) Exists(ctx context.Context, role, id string) (bool, error) {
+	switch role {
+	case "student":
+		return s.students.Exists(ctx, id)
+	case "teacher":
+		return s.faculty.Exists(ctx, id)
+	}
+	query, ok := accountQueries[role]
+	if !ok {
+		return false, fmt.Errorf("unknown role %q", role)
+	}
+	var exists bool
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&exists)
+	return exists, err
+}
+
+// passwordColumns are the tables holding a bcrypt password hash per role. Other roles
+// have no stored credential.
+var passwordColumns = map[string]struct{ table, id string }{
+	"admin":      {"ADMIN", "admin_id"},
+	"counsellor": {"COUNSELLOR", "counsellor_id"},
+	"teacher":    {"FACULTY", "faculty_id"},
+}
+
+// HasPassword reports whether accounts of the role sign in with a stored password.
+func HasPassword(role string) bool {
+	_, ok := passwordColumns[role]
+	return ok
+}
+
+// CheckPassword reports whether password matches the stored hash of the account. An
+// account without a hash never matches.
+func (s *AccountService) CheckPassword(ctx context.Context, role, id, password string) (bool, error) {
+	col, ok := passwordColumns[role]
+	if !ok {
+		return false, fmt.Errorf("role %q has no stored password", role)
+	}
+	var hash sql.NullString
+	err := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT password_hash FROM %s WHERE %s=$1", col.table, col.id), id).Scan(&hash)
+	if err == sql.ErrNoRows || (err == nil && !hash.Valid) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(hash.String), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SetPassword stores a bcrypt hash of password for the account.
+func (s *AccountService) SetPassword(ctx context.Context, role, id, password string) error {
+	col, ok := passwordColumns[role]
+	if !ok {
+		return fmt.Errorf("role %q has no stored password", role)
+	}
+	if len(password) < 8 {
+		return &ValidationError{Field: "password", Message: "must be at least 8 characters"}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET password_hash=$1 WHERE %s=$2", col.table, col.id), string(hash), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// StoreSession records the user's current token.
+func (s *AccountService) StoreSession(ctx context.Context, userID, token string, ttl time.Duration) error {
+	return s.rdb.Set(ctx, cache.SessionPrefix+userID, token, ttl).Err()
+}
+
+// StudentIDByEmail finds a student by email. ErrNotFound if there is none.
+func (s *AccountService) StudentIDByEmail(ctx context.Context, email string) (string, error) {
+	return s.students.IDByEmail(ctx, email)
+}
+
+// RegisterStudent creates the STUDENT row of a self-registered student.
+func (s *AccountService) RegisterStudent(ctx context.Context, st models.Student) error {
+	return s.students.Create(ctx, st)
+}
@@ -0,0 +1,930 @@
+package services
+
+import (
+	"academ_aide/internal/cache"
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// Errors returned by the admin service. Handlers map them to HTTP status codes.
+var (
+	ErrNotFound   = repository.ErrNotFound
+	ErrDuplicate  = errors.New("record already exists")
+	ErrReferenced = errors.New("record is referenced by other data or references missing data")
+)
+
+// ValidationError describes a single invalid field in an admin request.
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidDays mirrors the CHECK constraint on SCHEDULE.day_of_week
+var ValidDays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditImport = "import"
+)
+
+type AdminService struct {
+	db  *sql.DB
+	rdb *redis.Client
+}
+
+func NewAdminService(db *sql.DB, rdb *redis.Client) *AdminService {
+	return &AdminService{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// --- Helpers ---
+
+// translatePgError converts constraint violations into the service's sentinel errors.
+func translatePgError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505": // unique_violation
+			return fmt.Errorf("%w: %s", ErrDuplicate, pgErr.Detail)
+		case "23503": // foreign_key_violation
+			return fmt.Errorf("%w: %s", ErrReferenced, pgErr.Detail)
+		case "23514": // check_violation
+			return &ValidationError{Field: pgErr.ConstraintName, Message: pgErr.Message}
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// writeAudit records a change inside the caller's transaction so the audit row
+// is committed (or rolled back) together with the change itself.
+func writeAudit(ctx context.Context, tx *sql.Tx, actorID, action, entity, key string, before, after interface{}) error {
+	var oldData, newData []byte
+	var err error
+	if before != nil {
+		if oldData, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if newData, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO ADMIN_AUDIT_LOG (actor_id, action, entity, entity_key, old_data, new_data)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, actorID, action, entity, key, nullableJSON(oldData), nullableJSON(newData))
+	return err
+}
+
+func nullableJSON(b []byte) interface{} {
+	if b == nil {
+		return nil
+	}
+	return string(b)
+}
+
+// inTx runs fn in a transaction and commits only if fn succeeds.
+func (s *AdminService) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return withTx(ctx, s.db, fn)
+}
+
+// withTx runs fn in a transaction on db, translating constraint violations.
+func withTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return translatePgError(err)
+	}
+	return translatePgError(tx.Commit())
+}
+
+// enrolledStudents returns the students enrolled in any of the given courses.
+func enrolledStudents(ctx context.Context, db *sql.DB, courseIDs ...string) []string {
+	rows, err := db.QueryContext(ctx, "SELECT DISTINCT student_id FROM ENROLLS_IN WHERE course_id = ANY($1::text[])", courseIDs)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// invalidateCourses clears the cached profile/timetable/chat brief of everyone enrolled
+// in the affected courses, and every teacher's chat brief. Exact chat answers are keyed
+// on their context and would miss anyway, but semantic answers are not, so all cached
+// answers are flushed.
+func invalidateCourses(ctx context.Context, db *sql.DB, rdb *redis.Client, courseIDs ...string) {
+	if len(courseIDs) > 0 {
+		cache.InvalidateStudents(ctx, rdb, enrolledStudents(ctx, db, courseIDs...)...)
+	}
+	cache.InvalidateTeachers(ctx, rdb)
+	cache.InvalidateResponses(ctx, rdb)
+}
+
+func requireID(field, value string, maxLen int) error {
+	if strings.TrimSpace(value) == "" {
+		return &ValidationError{Field: field, Message: "is required"}
+	}
+	if len(value) > maxLen {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("must be at most %d characters", maxLen)}
+	}
+	if strings.ContainsAny(value, " \t\n") {
+		return &ValidationError{Field: field, Message: "must not contain whitespace"}
+	}
+	return nil
+}
+
+func requireText(field, value string, maxLen int) error {
+	if strings.TrimSpace(value) == "" {
+		return &ValidationError{Field: field, Message: "is required"}
+	}
+	if len(value) > maxLen {
+		return &ValidationError{Field: field, Message: fmt.Sprintf("must be at most %d characters", maxLen)}
+	}
+	return nil
+}
+
+func validateEmail(field, value string) error {
+	if err := requireText(field, value, 100); err != nil {
+		return err
+	}
+	if _, err := mail.ParseAddress(value); err != nil {
+		return &ValidationError{Field: field, Message: "is not a valid email address"}
+	}
+	return nil
+}
+
+// ParseClock accepts "HH:MM" or "HH:MM:SS".
+func ParseClock(value string) (time.Time, error) {
+	if t, err := time.Parse("15:04", value); err == nil {
+		return t, nil
+	}
+	return time.Parse("15:04:05", value)
+}
+
+// IsValidDay reports whether day is accepted by SCHEDULE.day_of_week.
+func IsValidDay(day string) bool {
+	for _, d := range ValidDays {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Departments ---
+
+func validateDepartment(d models.Department) error {
+	if err := requireID("dept_id", d.DeptID, 10); err != nil {
+		return err
+	}
+	if err := requireText("dept_name", d.DeptName, 100); err != nil {
+		return err
+	}
+	if d.HodID != nil && *d.HodID != "" {
+		return requireID("hod_id", *d.HodID, 20)
+	}
+	return nil
+}
+
+func normalizeHod(d *models.Department) {
+	if d.HodID != nil && strings.TrimSpace(*d.HodID) == "" {
+		d.HodID = nil
+	}
+}
+
+func (s *AdminService) ListDepartments(ctx context.Context) ([]models.Department, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT dept_id, dept_name, hod_id FROM DEPARTMENT ORDER BY dept_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	depts := make([]models.Department, 0)
+	for rows.Next() {
+		var d models.Department
+		var hod sql.NullString
+		if err := rows.Scan(&d.DeptID, &d.DeptName, &hod); err != nil {
+			return nil, err
+		}
+		if hod.Valid {
+			d.HodID = &hod.String
+		}
+		depts = append(depts, d)
+	}
+	return depts, rows.Err()
+}
+
+func getDepartment(ctx context.Context, tx *sql.Tx, deptID string) (*models.Department, error) {
+	var d models.Department
+	var hod sql.NullString
+	err := tx.QueryRowContext(ctx, "SELECT dept_id, dept_name, hod_id FROM DEPARTMENT WHERE dept_id=$1 FOR UPDATE", deptID).
+		Scan(&d.DeptID, &d.DeptName, &hod)
+	if err != nil {
+		return nil, err
+	}
+	if hod.Valid {
+		d.HodID = &hod.String
+	}
+	return &d, nil
+}
+
+func (s *AdminService) CreateDepartment(ctx context.Context, actorID string, d models.Department) error {
+	normalizeHod(&d)
+	if err := validateDepartment(d); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO DEPARTMENT (dept_id, dept_name, hod_id) VALUES ($1, $2, $3)", d.DeptID, d.DeptName, d.HodID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditCreate, "DEPARTMENT", d.DeptID, nil, d)
+	})
+}
+
+func (s *AdminService) UpdateDepartment(ctx context.Context, actorID string, d models.Department) error {
+	normalizeHod(&d)
+	if err := validateDepartment(d); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getDepartment(ctx, tx, d.DeptID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE DEPARTMENT SET dept_name=$2, hod_id=$3 WHERE dept_id=$1", d.DeptID, d.DeptName, d.HodID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditUpdate, "DEPARTMENT", d.DeptID, before, d)
+	})
+}
+
+func (s *AdminService) DeleteDepartment(ctx context.Context, actorID, deptID string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getDepartment(ctx, tx, deptID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM DEPARTMENT WHERE dept_id=$1", deptID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditDelete, "DEPARTMENT", deptID, before, nil)
+	})
+}
+
+// --- Faculty ---
+
+func validateFaculty(f models.Faculty) error {
+	if err := requireID("faculty_id", f.FacultyID, 20); err != nil {
+		return err
+	}
+	if err := requireText("first_name", f.FirstName, 50); err != nil {
+		return err
+	}
+	if err := requireText("last_name", f.LastName, 50); err != nil {
+		return err
+	}
+	if err := validateEmail("email", f.Email); err != nil {
+		return err
+	}
+	if len(f.PhoneNo) > 15 {
+		return &ValidationError{Field: "phone_no", Message: "must be at most 15 characters"}
+	}
+	return nil
+}
+
+func (s *AdminService) ListFaculty(ctx context.Context) ([]models.Faculty, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT faculty_id, f_first_name, f_last_name, f_email, f_phone_no FROM FACULTY ORDER BY faculty_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	faculty := make([]models.Faculty, 0)
+	for rows.Next() {
+		var f models.Faculty
+		var phone sql.NullString
+		if err := rows.Scan(&f.FacultyID, &f.FirstName, &f.LastName, &f.Email, &phone); err != nil {
+			return nil, err
+		}
+		f.PhoneNo = phone.String
+		faculty = append(faculty, f)
+	}
+	return faculty, rows.Err()
+}
+
+func getFaculty(ctx context.Context, tx *sql.Tx, facultyID string) (*models.Faculty, error) {
+	var f models.Faculty
+	var phone sql.NullString
+	err := tx.QueryRowContext(ctx, "SELECT faculty_id, f_first_name, f_last_name, f_email, f_phone_no FROM FACULTY WHERE faculty_id=$1 FOR UPDATE", facultyID).
+		Scan(&f.FacultyID, &f.FirstName, &f.LastName, &f.Email, &phone)
+	if err != nil {
+		return nil, err
+	}
+	f.PhoneNo = phone.String
+	return &f, nil
+}
+
+func (s *AdminService) CreateFaculty(ctx context.Context, actorID string, f models.Faculty) error {
+	if err := validateFaculty(f); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO FACULTY (faculty_id, f_first_name, f_last_name, f_email, f_phone_no) VALUES ($1, $2, $3, $4, $5)",
+			f.FacultyID, f.FirstName, f.LastName, f.Email, f.PhoneNo); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditCreate, "FACULTY", f.FacultyID, nil, f)
+	})
+}
+
+func (s *AdminService) UpdateFaculty(ctx context.Context, actorID string, f models.Faculty) error {
+	if err := validateFaculty(f); err != nil {
+		return err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getFaculty(ctx, tx, f.FacultyID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE FACULTY SET f_first_name=$2, f_last_name=$3, f_email=$4, f_phone_no=$5 WHERE faculty_id=$1",
+			f.FacultyID, f.FirstName, f.LastName, f.Email, f.PhoneNo); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditUpdate, "FACULTY", f.FacultyID, before, f)
+	})
+	if err == nil {
+		invalidateCourses(ctx, s.db, s.rdb)
+	}
+	return err
+}
+
+func (s *AdminService) DeleteFaculty(ctx context.Context, actorID, facultyID string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getFaculty(ctx, tx, facultyID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM FACULTY WHERE faculty_id=$1", facultyID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditDelete, "FACULTY", facultyID, before, nil)
+	})
+}
+
+// --- Courses ---
+
+func validateCourse(c models.Course) error {
+	if err := requireID("course_id", c.CourseID, 10); err != nil {
+		return err
+	}
+	if err := requireText("title", c.Title, 100); err != nil {
+		return err
+	}
+	if c.Credits <= 0 {
+		return &ValidationError{Field: "credits", Message: "must be greater than 0"}
+	}
+	return requireID("dept_id", c.DeptID, 10)
+}
+
+func (s *AdminService) ListCourses(ctx context.Context) ([]models.Course, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT course_id, title, credits, dept_id, description FROM COURSE ORDER BY course_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	courses := make([]models.Course, 0)
+	for rows.Next() {
+		var c models.Course
+		var desc sql.NullString
+		if err := rows.Scan(&c.CourseID, &c.Title, &c.Credits, &c.DeptID, &desc); err != nil {
+			return nil, err
+		}
+		c.Description = desc.String
+		courses = append(courses, c)
+	}
+	return courses, rows.Err()
+}
+
+func getCourse(ctx context.Context, tx *sql.Tx, courseID string) (*models.Course, error) {
+	var c models.Course
+	var desc sql.NullString
+	err := tx.QueryRowContext(ctx, "SELECT course_id, title, credits, dept_id, description FROM COURSE WHERE course_id=$1 FOR UPDATE", courseID).
+		Scan(&c.CourseID, &c.Title, &c.Credits, &c.DeptID, &desc)
+	if err != nil {
+		return nil, err
+	}
+	c.Description = desc.String
+	return &c, nil
+}
+
+func (s *AdminService) CreateCourse(ctx context.Context, actorID string, c models.Course) error {
+	if err := validateCourse(c); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO COURSE (course_id, title, credits, dept_id, description) VALUES ($1, $2, $3, $4, NULLIF($5, ''))",
+			c.CourseID, c.Title, c.Credits, c.DeptID, c.Description); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditCreate, "COURSE", c.CourseID, nil, c)
+	})
+}
+
+// UpdateCourse replaces a course. If the description changes the embedding is
+// cleared so cmd/backfill regenerates it.
+func (s *AdminService) UpdateCourse(ctx context.Context, actorID string, c models.Course) error {
+	if err := validateCourse(c); err != nil {
+		return err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getCourse(ctx, tx, c.CourseID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE COURSE SET title=$2, credits=$3, dept_id=$4, description=NULLIF($5, ''),
+				embedding = CASE WHEN description IS DISTINCT FROM NULLIF($5, '') THEN NULL ELSE embedding END
+			WHERE course_id=$1
+		`, c.CourseID, c.Title, c.Credits, c.DeptID, c.Description); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditUpdate, "COURSE", c.CourseID, before, c)
+	})
+	if err == nil {
+		invalidateCourses(ctx, s.db, s.rdb, c.CourseID)
+	}
+	return err
+}
+
+// DeleteCourse removes a course. Enrollments, schedules and teaching assignments
+// reference it, so those must be removed first or the delete fails with ErrReferenced.
+func (s *AdminService) DeleteCourse(ctx context.Context, actorID, courseID string) error {
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getCourse(ctx, tx, courseID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM COURSE WHERE course_id=$1", courseID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditDelete, "COURSE", courseID, before, nil)
+	})
+	if err == nil {
+		invalidateCourses(ctx, s.db, s.rdb)
+	}
+	return err
+}
+
+// --- Sections ---
+
+func validateSection(sec models.Section) error {
+	if err := requireID("section_name", sec.SectionName, 10); err != nil {
+		return err
+	}
+	return requireID("dept_id", sec.DeptID, 10)
+}
+
+func (s *AdminService) ListSections(ctx context.Context) ([]models.Section, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT section_name, dept_id FROM SECTION ORDER BY section_name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sections := make([]models.Section, 0)
+	for rows.Next() {
+		var sec models.Section
+		if err := rows.Scan(&sec.SectionName, &sec.DeptID); err != nil {
+			return nil, err
+		}
+		sections = append(sections, sec)
+	}
+	return sections, rows.Err()
+}
+
+func getSection(ctx context.Context, tx *sql.Tx, name string) (*models.Section, error) {
+	var sec models.Section
+	err := tx.QueryRowContext(ctx, "SELECT section_name, dept_id FROM SECTION WHERE section_name=$1 FOR UPDATE", name).
+		Scan(&sec.SectionName, &sec.DeptID)
+	if err != nil {
+		return nil, err
+	}
+	return &sec, nil
+}
+
+func (s *AdminService) CreateSection(ctx context.Context, actorID string, sec models.Section) error {
+	if err := validateSection(sec); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO SECTION (section_name, dept_id) VALUES ($1, $2)", sec.SectionName, sec.DeptID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditCreate, "SECTION", sec.SectionName, nil, sec)
+	})
+}
+
+func (s *AdminService) UpdateSection(ctx context.Context, actorID string, sec models.Section) error {
+	if err := validateSection(sec); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getSection(ctx, tx, sec.SectionName)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE SECTION SET dept_id=$2 WHERE section_name=$1", sec.SectionName, sec.DeptID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditUpdate, "SECTION", sec.SectionName, before, sec)
+	})
+}
+
+func (s *AdminService) DeleteSection(ctx context.Context, actorID, name string) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getSection(ctx, tx, name)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM SECTION WHERE section_name=$1", name); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditDelete, "SECTION", name, before, nil)
+	})
+}
+
+// --- Teaching Assignments (TEACHES) ---
+
+func teachesKey(t models.Teaches) string {
+	return t.FacultyID + "/" + t.CourseID + "/" + t.SectionName
+}
+
+func validateTeaches(t models.Teaches) error {
+	if err := requireID("faculty_id", t.FacultyID, 20); err != nil {
+		return err
+	}
+	if err := requireID("course_id", t.CourseID, 10); err != nil {
+		return err
+	}
+	return requireID("section_name", t.SectionName, 10)
+}
+
+func (s *AdminService) ListTeaches(ctx context.Context, courseID string) ([]models.Teaches, error) {
+	query := "SELECT faculty_id, course_id, section_name FROM TEACHES"
+	var args []interface{}
+	if courseID != "" {
+		query += " WHERE course_id=$1"
+		args = append(args, courseID)
+	}
+	query += " ORDER BY course_id, section_name, faculty_id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := make([]models.Teaches, 0)
+	for rows.Next() {
+		var t models.Teaches
+		if err := rows.Scan(&t.FacultyID, &t.CourseID, &t.SectionName); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, t)
+	}
+	return assignments, rows.Err()
+}
+
+func (s *AdminService) CreateTeaches(ctx context.Context, actorID string, t models.Teaches) error {
+	if err := validateTeaches(t); err != nil {
+		return err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO TEACHES (faculty_id, course_id, section_name) VALUES ($1, $2, $3)", t.FacultyID, t.CourseID, t.SectionName); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditCreate, "TEACHES", teachesKey(t), nil, t)
+	})
+	if err == nil {
+		cache.InvalidateTeacher(ctx, s.rdb, t.FacultyID)
+	}
+	return err
+}
+
+func (s *AdminService) DeleteTeaches(ctx context.Context, actorID string, t models.Teaches) error {
+	if err := validateTeaches(t); err != nil {
+		return err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM TEACHES WHERE faculty_id=$1 AND course_id=$2 AND section_name=$3", t.FacultyID, t.CourseID, t.SectionName)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrNotFound
+		}
+		return writeAudit(ctx, tx, actorID, AuditDelete, "TEACHES", teachesKey(t), t, nil)
+	})
+	if err == nil {
+		cache.InvalidateTeacher(ctx, s.rdb, t.FacultyID)
+	}
+	return err
+}
+
+// --- Syllabus Units ---
+
+func validateSyllabusUnit(u models.SyllabusUnit) error {
+	if u.UnitNo <= 0 {
+		return &ValidationError{Field: "unit_no", Message: "must be greater than 0"}
+	}
+	if err := requireText("topic", u.Topic, 255); err != nil {
+		return err
+	}
+	return requireID("course_id", u.CourseID, 10)
+}
+
+func (s *AdminService) ListSyllabusUnits(ctx context.Context, courseID string) ([]models.SyllabusUnit, error) {
+	query := "SELECT unit_id, unit_no, topic, course_id FROM SYLLABUS_UNIT"
+	var args []interface{}
+	if courseID != "" {
+		query += " WHERE course_id=$1"
+		args = append(args, courseID)
+	}
+	query += " ORDER BY course_id, unit_no, unit_id"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := make([]models.SyllabusUnit, 0)
+	for rows.Next() {
+		var u models.SyllabusUnit
+		if err := rows.Scan(&u.UnitID, &u.UnitNo, &u.Topic, &u.CourseID); err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func getSyllabusUnit(ctx context.Context, tx *sql.Tx, unitID int) (*models.SyllabusUnit, error) {
+	var u models.SyllabusUnit
+	err := tx.QueryRowContext(ctx, "SELECT unit_id, unit_no, topic, course_id FROM SYLLABUS_UNIT WHERE unit_id=$1 FOR UPDATE", unitID).
+		Scan(&u.UnitID, &u.UnitNo, &u.Topic, &u.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *AdminService) CreateSyllabusUnit(ctx context.Context, actorID string, u models.SyllabusUnit) (*models.SyllabusUnit, error) {
+	if err := validateSyllabusUnit(u); err != nil {
+		return nil, err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, "INSERT INTO SYLLABUS_UNIT (unit_no, topic, course_id) VALUES ($1, $2, $3) RETURNING unit_id",
+			u.UnitNo, u.Topic, u.CourseID).Scan(&u.UnitID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditCreate, "SYLLABUS_UNIT", strconv.Itoa(u.UnitID), nil, u)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *AdminService) UpdateSyllabusUnit(ctx context.Context, actorID string, u models.SyllabusUnit) error {
+	if err := validateSyllabusUnit(u); err != nil {
+		return err
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getSyllabusUnit(ctx, tx, u.UnitID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE SYLLABUS_UNIT SET unit_no=$2, topic=$3, course_id=$4 WHERE unit_id=$1",
+			u.UnitID, u.UnitNo, u.Topic, u.CourseID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditUpdate, "SYLLABUS_UNIT", strconv.Itoa(u.UnitID), before, u)
+	})
+}
+
+func (s *AdminService) DeleteSyllabusUnit(ctx context.Context, actorID string, unitID int) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getSyllabusUnit(ctx, tx, unitID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM SYLLABUS_UNIT WHERE unit_id=$1", unitID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditDelete, "SYLLABUS_UNIT", strconv.Itoa(unitID), before, nil)
+	})
+}
+
+// --- Schedule ---
+
+func validateSchedule(sch models.Schedule) error {
+	if err := requireID("course_id", sch.CourseID, 10); err != nil {
+		return err
+	}
+	if err := requireID("section_name", sch.SectionName, 10); err != nil {
+		return err
+	}
+	if !IsValidDay(sch.DayOfWeek) {
+		return &ValidationError{Field: "day_of_week", Message: "must be one of " + strings.Join(ValidDays, ", ")}
+	}
+	start, err := ParseClock(sch.StartTime)
+	if err != nil {
+		return &ValidationError{Field: "start_time", Message: "must be HH:MM"}
+	}
+	end, err := ParseClock(sch.EndTime)
+	if err != nil {
+		return &ValidationError{Field: "end_time", Message: "must be HH:MM"}
+	}
+	if !end.After(start) {
+		return &ValidationError{Field: "end_time", Message: "must be after start_time"}
+	}
+	if len(sch.RoomNumber) > 20 {
+		return &ValidationError{Field: "room_number", Message: "must be at most 20 characters"}
+	}
+	return nil
+}
+
+func (s *AdminService) ListSchedules(ctx context.Context, courseID string) ([]models.Schedule, error) {
+	query := `
+		SELECT schedule_id, course_id, section_name, day_of_week,
+		       TO_CHAR(start_time, 'HH24:MI'), TO_CHAR(end_time, 'HH24:MI'), COALESCE(room_number, '')
+		FROM SCHEDULE`
+	var args []interface{}
+	if courseID != "" {
+		query += " WHERE course_id=$1"
+		args = append(args, courseID)
+	}
+	query += " ORDER BY course_id, section_name, day_of_week, start_time"
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := make([]models.Schedule, 0)
+	for rows.Next() {
+		var sch models.Schedule
+		if err := rows.Scan(&sch.ScheduleID, &sch.CourseID, &sch.SectionName, &sch.DayOfWeek, &sch.StartTime, &sch.EndTime, &sch.RoomNumber); err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, sch)
+	}
+	return schedules, rows.Err()
+}
+
+func getSchedule(ctx context.Context, tx *sql.Tx, scheduleID int) (*models.Schedule, error) {
+	var sch models.Schedule
+	err := tx.QueryRowContext(ctx, `
+		SELECT schedule_id, course_id, section_name, day_of_week,
+		       TO_CHAR(start_time, 'HH24:MI'), TO_CHAR(end_time, 'HH24:MI'), COALESCE(room_number, '')
+		FROM SCHEDULE WHERE schedule_id=$1 FOR UPDATE`, scheduleID).
+		Scan(&sch.ScheduleID, &sch.CourseID, &sch.SectionName, &sch.DayOfWeek, &sch.StartTime, &sch.EndTime, &sch.RoomNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &sch, nil
+}
+
+func (s *AdminService) CreateSchedule(ctx context.Context, actorID string, sch models.Schedule) (*models.Schedule, error) {
+	if err := validateSchedule(sch); err != nil {
+		return nil, err
+	}
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO SCHEDULE (course_id, section_name, day_of_week, start_time, end_time, room_number)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) RETURNING schedule_id
+		`, sch.CourseID, sch.SectionName, sch.DayOfWeek, sch.StartTime, sch.EndTime, sch.RoomNumber).Scan(&sch.ScheduleID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditCreate, "SCHEDULE", strconv.Itoa(sch.ScheduleID), nil, sch)
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalidateCourses(ctx, s.db, s.rdb, sch.CourseID)
+	return &sch, nil
+}
+
+func (s *AdminService) UpdateSchedule(ctx context.Context, actorID string, sch models.Schedule) error {
+	if err := validateSchedule(sch); err != nil {
+		return err
+	}
+	var previousCourse string
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getSchedule(ctx, tx, sch.ScheduleID)
+		if err != nil {
+			return err
+		}
+		previousCourse = before.CourseID
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE SCHEDULE SET course_id=$2, section_name=$3, day_of_week=$4, start_time=$5, end_time=$6, room_number=NULLIF($7, '')
+			WHERE schedule_id=$1
+		`, sch.ScheduleID, sch.CourseID, sch.SectionName, sch.DayOfWeek, sch.StartTime, sch.EndTime, sch.RoomNumber); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditUpdate, "SCHEDULE", strconv.Itoa(sch.ScheduleID), before, sch)
+	})
+	if err == nil {
+		invalidateCourses(ctx, s.db, s.rdb, previousCourse, sch.CourseID)
+	}
+	return err
+}
+
+func (s *AdminService) DeleteSchedule(ctx context.Context, actorID string, scheduleID int) error {
+	var courseID string
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		before, err := getSchedule(ctx, tx, scheduleID)
+		if err != nil {
+			return err
+		}
+		courseID = before.CourseID
+		if _, err := tx.ExecContext(ctx, "DELETE FROM SCHEDULE WHERE schedule_id=$1", scheduleID); err != nil {
+			return err
+		}
+		return writeAudit(ctx, tx, actorID, AuditDelete, "SCHEDULE", strconv.Itoa(scheduleID), before, nil)
+	})
+	if err == nil {
+		invalidateCourses(ctx, s.db, s.rdb, courseID)
+	}
+	return err
+}
+
+// --- Audit Log ---
+
+// ListAuditLog returns the most recent audit entries, optionally filtered by entity.
+func (s *AdminService) ListAuditLog(ctx context.Context, entity string, limit, offset int) ([]models.AuditEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT audit_id, actor_id, action, entity, entity_key, old_data, new_data, created_at
+		FROM ADMIN_AUDIT_LOG
+		WHERE ($1 = '' OR entity = $1)
+		ORDER BY created_at DESC, audit_id DESC
+		LIMIT $2 OFFSET $3
+	`, strings.ToUpper(entity), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var e models.AuditEntry
+		var oldData, newData []byte
+		if err := rows.Scan(&e.AuditID, &e.ActorID, &e.Action, &e.Entity, &e.EntityKey, &oldData, &newData, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.OldData = oldData
+		e.NewData = newData
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
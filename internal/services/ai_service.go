@@ -1,14 +1,13 @@
 package services
 
 import (
-	"academ_aide/internal/config"
-	"bytes"
+	"academ_aide/internal/ai"
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
 	"crypto/sha256"
-	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"sort"
 	"strings"
 )
@@ -16,7 +15,7 @@ import (
 // Data Structures
 
 type StudentRisk struct {
-	Type     string `json:"type"`     // "Attendance", "Grades"
+	Type     string `json:"type"`     // "Attendance", "Grades", "Assessments", "Quizzes", "Engagement"
 	Severity string `json:"severity"` // "High", "Medium", "Low"
 	Message  string `json:"message"`
 	Subject  string `json:"subject,omitempty"`
@@ -40,12 +39,16 @@ type WhatIfScenario struct {
 
 // Service Interface regarding AI capabilities
 type AIService struct {
-	db *sql.DB
+	enrollments repository.EnrollmentRepository
+	risk        *RiskService
+	llm         ai.ChatClient
 }
 
-func NewAIService() *AIService {
+func NewAIService(enrollments repository.EnrollmentRepository, risk *RiskService, llm ai.ChatClient) *AIService {
 	return &AIService{
-		db: config.PostgresDB,
+		enrollments: enrollments,
+		risk:        risk,
+		llm:         llm,
 	}
 }
 
@@ -56,36 +59,71 @@ const (
 	GradeDropThreshold          = 5.0
 )
 
+// riskFactorAdvice turns the dominant factor of a stored risk score into a risk type and
+// suggestion. Chat sentiment is never the reported factor: it is counsellor-only data.
+var riskFactorAdvice = map[string]struct {
+	Type       string
+	Suggestion string
+	Reason     string
+}{
+	FactorGrades:          {"Grades", "Schedule remedial session for %s", "Your published grade puts you at risk of failing or academic probation."},
+	FactorAssessmentTrend: {"Assessments", "Review recent test and assignment feedback for %s", "Your assessment scores are low or falling."},
+	FactorQuiz:            {"Quizzes", "Revise weak topics in %s and retake a practice quiz", "Recent practice quiz scores are low."},
+	FactorEngagement:      {"Engagement", "Set aside regular study time for %s", "You have had little recent activity on the platform."},
+}
+
+// TeacherInsights returns a student's insights to a faculty member who teaches them.
+func (s *AIService) TeacherInsights(ctx context.Context, facultyID, studentID string) (*AIInsightsResponse, error) {
+	if err := s.risk.teachesStudent(ctx, facultyID, studentID); err != nil {
+		return nil, err
+	}
+	return s.GetStudentInsights(ctx, studentID)
+}
+
 // GetStudentInsights returns risks and suggestions for a student
-func (s *AIService) GetStudentInsights(studentID string) (*AIInsightsResponse, error) {
+func (s *AIService) GetStudentInsights(ctx context.Context, studentID string) (*AIInsightsResponse, error) {
 	var risks []StudentRisk
 	var suggestions []Suggestion
 
+	// Scores stored by the risk scoring job, keyed by course
+	scores := make(map[string]models.RiskScore)
+	if stored, err := s.risk.StudentRisks(ctx, studentID); err == nil {
+		for _, r := range stored {
+			scores[r.CourseID] = r
+		}
+	}
+
 	// 1. Fetch Enrolled Courses & Grades from DB
-	rows, err := s.db.Query(`
-		SELECT c.title, e.grade, e.status, e.course_id
-		FROM ENROLLS_IN e
-		JOIN COURSE c ON e.course_id = c.course_id
-		WHERE e.student_id = $1
-	`, studentID)
+	enrollments, err := s.enrollments.History(ctx, studentID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var title, gradeStr, status, courseID string
-		var grade sql.NullString
-		if err := rows.Scan(&title, &grade, &status, &courseID); err != nil {
-			continue
-		}
-		if grade.Valid {
-			gradeStr = grade.String
-		}
 
-		// --- A. Grade Analysis (REAL) ---
-		// Map grade to risk
-		if status == "Enrolled" || status == "Completed" {
+	for _, e := range enrollments {
+		title, gradeStr, status, courseID := e.Title, e.Grade, e.Status, e.CourseID
+
+		// --- A. Academic Risk (REAL) ---
+		// Prefer the multi-factor score; fall back to the grade for enrollments the job has not scored.
+		if r, ok := scores[courseID]; ok {
+			if r.Level != RiskLow && len(r.Factors) > 0 {
+				severity := "Medium"
+				if r.Level == RiskHigh {
+					severity = "High"
+				}
+				top := reportedFactor(r.Factors)
+				advice := riskFactorAdvice[top.Factor]
+				risks = append(risks, StudentRisk{
+					Type:     advice.Type,
+					Severity: severity,
+					Message:  fmt.Sprintf("Risk score %.0f/100 in %s: %s", r.Score, title, top.Detail),
+					Subject:  title,
+				})
+				suggestions = append(suggestions, Suggestion{
+					Suggestion: fmt.Sprintf(advice.Suggestion, title),
+					Reason:     advice.Reason,
+				})
+			}
+		} else if status == "Enrolled" || status == "Completed" {
 			switch gradeStr {
 			case "F", "D", "E":
 				risks = append(risks, StudentRisk{
@@ -179,7 +217,7 @@ func (s *AIService) GetStudentInsights(studentID string) (*AIInsightsResponse, e
 }
 
 // CalculateWhatIf simulates attendance scenarios
-func (s *AIService) CalculateWhatIf(studentID string, missedClasses int) (*WhatIfScenario, error) {
+func (s *AIService) CalculateWhatIf(_ context.Context, studentID string, missedClasses int) (*WhatIfScenario, error) {
 	// Mock Base State (Same as frontend default)
 	currentTotal := 40.0
 	currentAttended := 34.0 // 85% initially
@@ -207,6 +245,7 @@ func (s *AIService) CalculateWhatIf(studentID string, missedClasses int) (*WhatI
 
 // Quiz Analysis Structures
 type QuizSubmission struct {
+	StudentID      string              `json:"student_id,omitempty"` // Optional; must be the logged-in student
 	CourseID       string              `json:"course_id"`
 	WrongQuestions []QuizWrongQuestion `json:"wrong_questions"`
 	TotalQuestions int                 `json:"total_questions"`
@@ -232,7 +271,7 @@ type StudyPriorityItem struct {
 }
 
 // AnalyzeQuizPerformance generates insights based on quiz results
-func (s *AIService) AnalyzeQuizPerformance(sub QuizSubmission) (*QuizAnalysisResponse, error) {
+func (s *AIService) AnalyzeQuizPerformance(ctx context.Context, sub QuizSubmission) (*QuizAnalysisResponse, error) {
 	if len(sub.WrongQuestions) == 0 {
 		return &QuizAnalysisResponse{
 			WeakAreas: []string{},
@@ -269,7 +308,7 @@ Return ONLY valid JSON in this format:
 `, sub.CourseID, sb.String())
 
 	// Call Ollama
-	jsonResp, err := s.callOllamaJSON(prompt)
+	jsonResp, err := generateJSON(ctx, s.llm, "quiz_analysis", prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -300,29 +339,15 @@ Return ONLY valid JSON in this format:
 	return &analysis, nil
 }
 
-// Helper: Call Ollama (Duplicated to avoid circular deps or refactoring overhead for now)
-func (s *AIService) callOllamaJSON(prompt string) (string, error) {
-	url := "http://localhost:11434/api/generate"
-	reqBody := map[string]interface{}{
-		"model":  "llama3.2",
-		"prompt": prompt,
-		"stream": false,
-		"format": "json",
-	}
-	jsonData, _ := json.Marshal(reqBody)
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+// generateJSON sends a single prompt for agent and returns the model's JSON object reply.
+func generateJSON(ctx context.Context, llm ai.ChatClient, agent, prompt string) (string, error) {
+	turn, err := llm.Chat(ctx, ai.ChatRequest{
+		Messages: []ai.Message{{Role: "user", Content: prompt}},
+		JSON:     true,
+		Agent:    agent,
+	})
 	if err != nil {
-		return "", fmt.Errorf("ollama connection failed: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	var oResp struct {
-		Response string `json:"response"`
-	}
-	// Simple unmarshal
-	json.Unmarshal(body, &oResp)
-
-	return oResp.Response, nil
+	return turn.Content, nil
 }
@@ -0,0 +1,386 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alert kinds (TEACHER_ALERT.kind)
+const (
+	AlertRiskThreshold      = "risk_threshold"
+	AlertLowQuizAverage     = "low_quiz_average"
+	AlertAttendanceDrop     = "attendance_drop"
+	AlertUnansweredQuestion = "unanswered_question"
+)
+
+// Alert severities share the risk level names
+var ValidAlertSeverities = []string{RiskHigh, RiskMedium, RiskLow}
+
+// Producer thresholds
+const (
+	LowQuizAverageThreshold = 0.5 // Course quiz average below this raises an alert
+	quizAverageWindow       = 7 * 24 * time.Hour
+	minQuizAttempts         = 3 // Attempts needed before a course average is meaningful
+	attendanceWindow        = 14 * 24 * time.Hour
+	minRecentClasses        = 3    // Classes needed in the window before judging attendance
+	AttendanceDropPoints    = 15.0 // Drop vs. earlier attendance that raises an alert
+	UnansweredAfter         = 24 * time.Hour
+	UnansweredEscalateAfter = 72 * time.Hour
+)
+
+type AlertService struct {
+	db *sql.DB
+}
+
+func NewAlertService(db *sql.DB) *AlertService {
+	return &AlertService{
+		db: db,
+	}
+}
+
+// AlertFilter narrows the alert feed. Status is "active" (default: not dismissed),
+// "unread", "read", "dismissed" or "all".
+type AlertFilter struct {
+	CourseID string
+	Severity string
+	Status   string
+}
+
+// isoWeek formats t as e.g. "2024-W07"; used to raise recurring alerts at most weekly.
+func isoWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// Raise stores an alert for every faculty member teaching the course. Re-raising the
+// same dedupeKey is a no-op, so producers can run repeatedly. Returns the number of
+// alerts created.
+func (s *AlertService) Raise(ctx context.Context, courseID, studentID, kind, severity, title, message, dedupeKey string) (int, error) {
+	var student interface{}
+	if studentID != "" {
+		student = studentID
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO TEACHER_ALERT (faculty_id, course_id, student_id, kind, severity, title, message, dedupe_key)
+		SELECT DISTINCT faculty_id, $1, $2, $3, $4, $5, $6, $7 FROM TEACHES WHERE course_id=$1
+		ON CONFLICT (faculty_id, dedupe_key) DO NOTHING
+	`, courseID, student, kind, severity, title, message, dedupeKey)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// --- Producers ---
+
+// RunChecks runs the periodic producers and returns the number of alerts created.
+// Risk threshold alerts are raised by the risk scoring job as scores change.
+func (s *AlertService) RunChecks(ctx context.Context) (int, error) {
+	now := time.Now()
+	total := 0
+	for _, check := range []func(context.Context, time.Time) (int, error){
+		s.checkQuizAverages,
+		s.checkAttendance,
+		s.checkUnansweredQuestions,
+	} {
+		n, err := check(ctx, now)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+func (s *AlertService) checkQuizAverages(ctx context.Context, now time.Time) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.course_id, c.title, AVG(q.score::float / q.total_questions), COUNT(*)
+		FROM QUIZ_ATTEMPT q
+		JOIN COURSE c ON c.course_id = q.course_id
+		WHERE q.taken_at >= $1
+		GROUP BY q.course_id, c.title
+		HAVING COUNT(*) >= $2 AND AVG(q.score::float / q.total_questions) < $3
+	`, now.Add(-quizAverageWindow), minQuizAttempts, LowQuizAverageThreshold)
+	if err != nil {
+		return 0, err
+	}
+	type lowCourse struct {
+		courseID, title string
+		avg             float64
+		attempts        int
+	}
+	var low []lowCourse
+	for rows.Next() {
+		var l lowCourse
+		if err := rows.Scan(&l.courseID, &l.title, &l.avg, &l.attempts); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		low = append(low, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, l := range low {
+		severity := RiskMedium
+		if l.avg < LowQuizAverageThreshold-0.15 {
+			severity = RiskHigh
+		}
+		n, err := s.Raise(ctx, l.courseID, "", AlertLowQuizAverage, severity,
+			fmt.Sprintf("Low quiz average in %s", l.title),
+			fmt.Sprintf("Students averaged %.0f%% across %d practice quiz attempt(s) this week.", l.avg*100, l.attempts),
+			fmt.Sprintf("quiz:%s:%s", l.courseID, isoWeek(now)))
+		if err != nil {
+			return created, err
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// checkAttendance flags students whose recent attendance is below the critical
+// threshold or has dropped sharply compared with earlier in the term.
+func (s *AlertService) checkAttendance(ctx context.Context, now time.Time) (int, error) {
+	since := now.Add(-attendanceWindow)
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT a.student_id, s.s_first_name || ' ' || s.s_last_name, a.course_id, c.title,
+			AVG(CASE WHEN a.present THEN 100.0 ELSE 0 END) FILTER (WHERE a.class_date >= $1),
+			AVG(CASE WHEN a.present THEN 100.0 ELSE 0 END) FILTER (WHERE a.class_date < $1)
+		FROM ATTENDANCE a
+		JOIN ENROLLS_IN e ON e.student_id = a.student_id AND e.course_id = a.course_id
+		JOIN STUDENT s ON s.student_id = a.student_id
+		JOIN COURSE c ON c.course_id = a.course_id
+		WHERE e.status = 'Enrolled'
+		GROUP BY a.student_id, s.s_first_name, s.s_last_name, a.course_id, c.title
+		HAVING COUNT(*) FILTER (WHERE a.class_date >= $1) >= $2
+	`, since, minRecentClasses)
+	if err != nil {
+		return 0, err
+	}
+	type drop struct {
+		studentID, name, courseID, title string
+		recent                           float64
+		earlier                          sql.NullFloat64
+	}
+	var drops []drop
+	for rows.Next() {
+		var d drop
+		if err := rows.Scan(&d.studentID, &d.name, &d.courseID, &d.title, &d.recent, &d.earlier); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		dropped := d.earlier.Valid && d.earlier.Float64-d.recent >= AttendanceDropPoints
+		if d.recent < AttendanceThresholdCritical || dropped {
+			drops = append(drops, d)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, d := range drops {
+		severity := RiskMedium
+		if d.recent < AttendanceThresholdCritical {
+			severity = RiskHigh
+		}
+		message := fmt.Sprintf("Attended %.0f%% of classes in the last 14 days", d.recent)
+		if d.earlier.Valid && d.earlier.Float64 > d.recent {
+			message += fmt.Sprintf(", down from %.0f%% earlier", d.earlier.Float64)
+		}
+		n, err := s.Raise(ctx, d.courseID, d.studentID, AlertAttendanceDrop, severity,
+			fmt.Sprintf("Attendance drop: %s in %s", d.name, d.title),
+			message+".",
+			fmt.Sprintf("attendance:%s:%s:%s", d.studentID, d.courseID, isoWeek(now)))
+		if err != nil {
+			return created, err
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// checkUnansweredQuestions alerts on questions left open for a day, and again at
+// high severity once they are three days old.
+func (s *AlertService) checkUnansweredQuestions(ctx context.Context, now time.Time) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT q.question_id, q.student_id, s.s_first_name || ' ' || s.s_last_name, q.course_id, c.title, q.question, q.created_at
+		FROM STUDENT_QUESTION q
+		JOIN STUDENT s ON s.student_id = q.student_id
+		JOIN COURSE c ON c.course_id = q.course_id
+		WHERE q.answered_at IS NULL AND q.created_at <= $1
+	`, now.Add(-UnansweredAfter))
+	if err != nil {
+		return 0, err
+	}
+	type open struct {
+		id                                    int
+		studentID, name, courseID, title, txt string
+		createdAt                             time.Time
+	}
+	var questions []open
+	for rows.Next() {
+		var q open
+		if err := rows.Scan(&q.id, &q.studentID, &q.name, &q.courseID, &q.title, &q.txt, &q.createdAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		questions = append(questions, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, q := range questions {
+		severity := RiskMedium
+		if now.Sub(q.createdAt) >= UnansweredEscalateAfter {
+			severity = RiskHigh
+		}
+		excerpt := q.txt
+		if len([]rune(excerpt)) > 120 {
+			excerpt = string([]rune(excerpt)[:120]) + "..."
+		}
+		n, err := s.Raise(ctx, q.courseID, q.studentID, AlertUnansweredQuestion, severity,
+			fmt.Sprintf("Unanswered question from %s in %s", q.name, q.title),
+			fmt.Sprintf("Asked %s ago: %q", now.Sub(q.createdAt).Round(time.Hour), excerpt),
+			fmt.Sprintf("question:%d:%s", q.id, severity))
+		if err != nil {
+			return created, err
+		}
+		created += n
+	}
+	return created, nil
+}
+
+// StartScheduler runs the producers immediately and then every interval until ctx is cancelled.
+// The returned channel is closed when it has stopped.
+func (s *AlertService) StartScheduler(ctx context.Context, interval time.Duration) <-chan struct{} {
+	return schedule(ctx, "Alert checks", interval, s.RunChecks)
+}
+
+// --- Feed ---
+
+func alertStatusClause(status string) (string, error) {
+	switch status {
+	case "", "active":
+		return "dismissed_at IS NULL", nil
+	case "unread":
+		return "read_at IS NULL AND dismissed_at IS NULL", nil
+	case "read":
+		return "read_at IS NOT NULL AND dismissed_at IS NULL", nil
+	case "dismissed":
+		return "dismissed_at IS NOT NULL", nil
+	case "all":
+		return "TRUE", nil
+	}
+	return "", &ValidationError{Field: "status", Message: "must be one of active, unread, read, dismissed, all"}
+}
+
+// List returns a page of the faculty member's alerts, newest first, with the total
+// matching the filter and the number still unread.
+func (s *AlertService) List(ctx context.Context, facultyID string, f AlertFilter, limit, offset int) ([]models.Alert, int, int, error) {
+	statusClause, err := alertStatusClause(f.Status)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	f.Severity = strings.ToLower(f.Severity)
+	if f.Severity != "" && !contains(ValidAlertSeverities, f.Severity) {
+		return nil, 0, 0, &ValidationError{Field: "severity", Message: "must be one of " + strings.Join(ValidAlertSeverities, ", ")}
+	}
+
+	where := `faculty_id=$1 AND ($2 = '' OR course_id=$2) AND ($3 = '' OR severity=$3) AND ` + statusClause
+	args := []interface{}{facultyID, f.CourseID, f.Severity}
+
+	var total, unread int
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE `+statusClause+`),
+			COUNT(*) FILTER (WHERE read_at IS NULL AND dismissed_at IS NULL)
+		FROM TEACHER_ALERT
+		WHERE faculty_id=$1 AND ($2 = '' OR course_id=$2) AND ($3 = '' OR severity=$3)
+	`, args...).Scan(&total, &unread); err != nil {
+		return nil, 0, 0, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT alert_id, course_id, student_id, kind, severity, title, message, created_at, read_at, dismissed_at
+		FROM TEACHER_ALERT
+		WHERE `+where+`
+		ORDER BY created_at DESC, alert_id DESC
+		LIMIT $4 OFFSET $5
+	`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	defer rows.Close()
+
+	alerts := make([]models.Alert, 0)
+	for rows.Next() {
+		var a models.Alert
+		var studentID sql.NullString
+		var readAt, dismissedAt sql.NullTime
+		if err := rows.Scan(&a.AlertID, &a.CourseID, &studentID, &a.Kind, &a.Severity, &a.Title, &a.Message, &a.CreatedAt, &readAt, &dismissedAt); err != nil {
+			return nil, 0, 0, err
+		}
+		if studentID.Valid {
+			a.StudentID = &studentID.String
+		}
+		if readAt.Valid {
+			a.ReadAt = &readAt.Time
+		}
+		if dismissedAt.Valid {
+			a.DismissedAt = &dismissedAt.Time
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, total, unread, rows.Err()
+}
+
+// MarkRead marks one of the faculty member's alerts as read.
+func (s *AlertService) MarkRead(ctx context.Context, facultyID string, alertID int) error {
+	return s.update(ctx, `UPDATE TEACHER_ALERT SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP)
+		WHERE alert_id=$1 AND faculty_id=$2`, alertID, facultyID)
+}
+
+// Dismiss hides one of the faculty member's alerts from the active feed.
+func (s *AlertService) Dismiss(ctx context.Context, facultyID string, alertID int) error {
+	return s.update(ctx, `UPDATE TEACHER_ALERT SET read_at = COALESCE(read_at, CURRENT_TIMESTAMP),
+		dismissed_at = COALESCE(dismissed_at, CURRENT_TIMESTAMP)
+		WHERE alert_id=$1 AND faculty_id=$2`, alertID, facultyID)
+}
+
+func (s *AlertService) update(ctx context.Context, query string, args ...interface{}) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks every unread alert as read, optionally limited to one course.
+func (s *AlertService) MarkAllRead(ctx context.Context, facultyID, courseID string) (int, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE TEACHER_ALERT SET read_at = CURRENT_TIMESTAMP
+		WHERE faculty_id=$1 AND ($2 = '' OR course_id=$2) AND read_at IS NULL
+	`, facultyID, courseID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
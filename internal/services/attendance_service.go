@@ -0,0 +1,63 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type AttendanceService struct {
+	db *sql.DB
+}
+
+func NewAttendanceService(db *sql.DB) *AttendanceService {
+	return &AttendanceService{
+		db: db,
+	}
+}
+
+// Record stores (or corrects) attendance for one class date of a course taught by
+// the faculty member. date is "YYYY-MM-DD". The batch is all-or-nothing.
+func (s *AttendanceService) Record(ctx context.Context, facultyID, courseID, date string, records []models.AttendanceRecord) error {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return err
+	}
+	classDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return &ValidationError{Field: "date", Message: "must be YYYY-MM-DD"}
+	}
+	if classDate.After(time.Now()) {
+		return &ValidationError{Field: "date", Message: "must not be in the future"}
+	}
+	if len(records) == 0 {
+		return &ValidationError{Field: "records", Message: "at least one record is required"}
+	}
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		enrolled, err := courseStudents(ctx, tx, courseID)
+		if err != nil {
+			return err
+		}
+		isEnrolled := make(map[string]bool, len(enrolled))
+		for _, id := range enrolled {
+			isEnrolled[id] = true
+		}
+
+		for i, r := range records {
+			if !isEnrolled[r.StudentID] {
+				return &ValidationError{Field: fmt.Sprintf("records[%d].student_id", i), Message: fmt.Sprintf("%q is not enrolled in %s", r.StudentID, courseID)}
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO ATTENDANCE (student_id, course_id, class_date, present, recorded_by)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (student_id, course_id, class_date) DO UPDATE SET
+					present = EXCLUDED.present, recorded_by = EXCLUDED.recorded_by, recorded_at = CURRENT_TIMESTAMP
+			`, r.StudentID, courseID, classDate, r.Present, facultyID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"academ_aide/internal/cache"
+	"academ_aide/internal/metrics"
+	"academ_aide/internal/models"
+	"academ_aide/internal/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/errgroup"
+)
+
+// userBrief is the small, always-present context about the caller, plus the records
+// schedule and grade lookups answer from. Everything else (materials, announcements,
+// ...) is fetched by the model through tools.
+type userBrief struct {
+	intro       string // Who the user is
+	displayName string
+	courseLabel string
+	courseList  []string // "Title (ID)"
+	courseIDs   []string
+	courses     map[string]string // Course ID -> title
+
+	timetable    []models.ScheduleItem
+	timetableErr error
+	grades       []models.StudentGrade // Students only
+	gradesErr    error
+}
+
+// addCourse lists a course once; a teacher's sections of one course share it.
+func (b *userBrief) addCourse(id, title string) {
+	if _, ok := b.courses[id]; ok {
+		return
+	}
+	b.courseIDs = append(b.courseIDs, id)
+	b.courses[id] = title
+	b.courseList = append(b.courseList, fmt.Sprintf("%s (%s)", title, id))
+}
+
+// week is the user's timetable as the chat assistant sees it.
+func (b *userBrief) week() ([]models.ScheduleItem, error) {
+	return b.timetable, b.timetableErr
+}
+
+// publishedGrades are the student's published grades.
+func (b *userBrief) publishedGrades() ([]models.StudentGrade, error) {
+	return b.grades, b.gradesErr
+}
+
+// complete reports whether every record loaded, so the brief may be cached.
+func (b *userBrief) complete() bool {
+	return b.timetableErr == nil && b.gradesErr == nil
+}
+
+// loadBrief returns the caller's brief from the cache, or builds and caches it.
+func (s *RAGService) loadBrief(ctx context.Context, userID, role string) (b *userBrief, err error) {
+	ctx, span := tracing.Start(ctx, "chat.brief")
+	defer func() { tracing.End(span, err) }()
+
+	b, ok := s.Briefs.get(ctx, role, userID)
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	if ok {
+		return b, nil
+	}
+	if b, err = s.buildBrief(ctx, userID, role); err != nil {
+		return nil, err
+	}
+	if b.complete() {
+		s.Briefs.set(ctx, role, userID, b)
+	}
+	return b, nil
+}
+
+// buildBrief queries the profile, courses, timetable and grades concurrently. Without
+// a profile or course list there is no brief; a timetable or grade failure is kept on
+// the brief, and lookups that need it leave the question to the model.
+func (s *RAGService) buildBrief(ctx context.Context, userID, role string) (*userBrief, error) {
+	b := &userBrief{courses: map[string]string{}, courseLabel: "Enrolled Courses"}
+	g, gctx := errgroup.WithContext(ctx)
+
+	if role == "teacher" {
+		var f *models.Faculty
+		var taught []models.TaughtCourse
+		g.Go(func() (err error) {
+			if f, err = s.Data.Faculty.Get(gctx, userID); err != nil {
+				return fmt.Errorf("fetching faculty profile: %w", err)
+			}
+			return nil
+		})
+		g.Go(func() (err error) {
+			if taught, err = s.Data.Faculty.Courses(gctx, userID); err != nil {
+				return fmt.Errorf("fetching courses: %w", err)
+			}
+			return nil
+		})
+		g.Go(func() error {
+			b.timetable, b.timetableErr = s.Data.Schedules.ForFaculty(gctx, userID)
+			return nil
+		})
+		if err := g.Wait(); err != nil {
+			return nil, err
+		}
+
+		b.displayName = f.FirstName
+		b.intro = fmt.Sprintf("You are talking to %s, a Faculty Member (Email: %s).", b.displayName, f.Email)
+		b.courseLabel = "Courses Taught"
+		for _, tc := range taught {
+			b.addCourse(tc.CourseID, tc.Title)
+		}
+		return b, nil
+	}
+
+	var st *models.Student
+	var enrolled []models.EnrolledCourse
+	g.Go(func() (err error) {
+		if st, err = s.Data.Students.Get(gctx, userID); err != nil {
+			return fmt.Errorf("fetching student profile: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() (err error) {
+		if enrolled, err = s.Data.Enrollments.Current(gctx, userID); err != nil {
+			return fmt.Errorf("fetching courses: %w", err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		b.timetable, b.timetableErr = s.Data.Schedules.StudentWeek(gctx, userID)
+		return nil
+	})
+	g.Go(func() error {
+		b.grades, b.gradesErr = s.Grading.StudentGrades(gctx, userID)
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	b.displayName = st.FirstName
+	studentYear := time.Now().Year() - st.YearOfJoining
+	if studentYear <= 0 {
+		studentYear = 1
+	}
+	b.intro = fmt.Sprintf("You are talking to %s, a %d Year %s student.", b.displayName, studentYear, st.DeptID)
+	for _, c := range enrolled {
+		b.addCourse(c.ID, c.Name)
+	}
+	return b, nil
+}
+
+// briefTTL bounds how stale a brief gets when a change bypasses the services that
+// invalidate it (e.g. an edit made directly in the database).
+const briefTTL = 10 * time.Minute
+
+// BriefCache keeps each user's brief in Redis, so a chat turn does not rebuild it.
+// The writes that change one (grades, enrollments, courses, schedules, teaching
+// assignments) drop it through the cache package. A nil client disables it.
+type BriefCache struct {
+	rdb *redis.Client
+}
+
+func NewBriefCache(rdb *redis.Client) *BriefCache {
+	return &BriefCache{rdb: rdb}
+}
+
+// briefRecord is a userBrief as stored in Redis.
+type briefRecord struct {
+	Intro       string                `json:"intro"`
+	DisplayName string                `json:"display_name"`
+	CourseLabel string                `json:"course_label"`
+	CourseIDs   []string              `json:"course_ids"`
+	Titles      []string              `json:"titles"`
+	Timetable   []models.ScheduleItem `json:"timetable"`
+	Grades      []models.StudentGrade `json:"grades,omitempty"`
+}
+
+func (c *BriefCache) get(ctx context.Context, role, userID string) (*userBrief, bool) {
+	if c == nil || c.rdb == nil {
+		return nil, false
+	}
+	data, err := c.rdb.Get(ctx, cache.ChatBriefKey(role, userID)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			slog.WarnContext(ctx, "Brief cache read failed", "error", err)
+		}
+		metrics.CacheLookup(metrics.CacheBrief, false)
+		return nil, false
+	}
+	var rec briefRecord
+	if err := json.Unmarshal(data, &rec); err != nil || len(rec.Titles) != len(rec.CourseIDs) {
+		metrics.CacheLookup(metrics.CacheBrief, false)
+		return nil, false
+	}
+	metrics.CacheLookup(metrics.CacheBrief, true)
+	b := &userBrief{
+		intro:       rec.Intro,
+		displayName: rec.DisplayName,
+		courseLabel: rec.CourseLabel,
+		courses:     map[string]string{},
+		timetable:   rec.Timetable,
+		grades:      rec.Grades,
+	}
+	for i, id := range rec.CourseIDs {
+		b.addCourse(id, rec.Titles[i])
+	}
+	return b, true
+}
+
+func (c *BriefCache) set(ctx context.Context, role, userID string, b *userBrief) {
+	if c == nil || c.rdb == nil {
+		return
+	}
+	rec := briefRecord{
+		Intro:       b.intro,
+		DisplayName: b.displayName,
+		CourseLabel: b.courseLabel,
+		CourseIDs:   b.courseIDs,
+		Timetable:   b.timetable,
+		Grades:      b.grades,
+	}
+	for _, id := range b.courseIDs {
+		rec.Titles = append(rec.Titles, b.courses[id])
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	if err := c.rdb.Set(ctx, cache.ChatBriefKey(role, userID), data, briefTTL).Err(); err != nil {
+		slog.WarnContext(ctx, "Brief cache write failed", "error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const queryDelay = 50 * time.Millisecond
+
+// slowFaculty and slowSchedules add a round trip to every query they pass on.
+type slowFaculty struct{ repository.FacultyRepository }
+
+func (r slowFaculty) Get(ctx context.Context, facultyID string) (*models.Faculty, error) {
+	time.Sleep(queryDelay)
+	return r.FacultyRepository.Get(ctx, facultyID)
+}
+
+func (r slowFaculty) Courses(ctx context.Context, facultyID string) ([]models.TaughtCourse, error) {
+	time.Sleep(queryDelay)
+	return r.FacultyRepository.Courses(ctx, facultyID)
+}
+
+type slowSchedules struct{ repository.ScheduleRepository }
+
+func (r slowSchedules) ForFaculty(ctx context.Context, facultyID string) ([]models.ScheduleItem, error) {
+	time.Sleep(queryDelay)
+	return r.ScheduleRepository.ForFaculty(ctx, facultyID)
+}
+
+func TestBuildBriefQueriesConcurrently(t *testing.T) {
+	mem := facultyFixture()
+	mem.Faculty[0].Email = "meera@example.edu"
+	mem.Schedules = []models.Schedule{
+		{ScheduleID: 1, CourseID: "CS101", SectionName: "CSE-A", DayOfWeek: "Monday", StartTime: "09:00", EndTime: "10:00"},
+	}
+	repos := mem.Repositories()
+	repos.Faculty = slowFaculty{repos.Faculty}
+	repos.Schedules = slowSchedules{repos.Schedules}
+	s := &RAGService{RAGDeps: RAGDeps{Data: repos}}
+
+	start := time.Now()
+	b, err := s.loadBrief(context.Background(), "F1", "teacher")
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed >= 2*queryDelay {
+		t.Errorf("brief took %s; its three queries should overlap", elapsed)
+	}
+	if !reflect.DeepEqual(b.courseIDs, []string{"CS101", "CS102"}) {
+		t.Errorf("courseIDs = %v, want each taught course once", b.courseIDs)
+	}
+	if items, err := b.week(); err != nil || len(items) != 1 {
+		t.Errorf("week() = %v, %v", items, err)
+	}
+	if b.intro != "You are talking to Meera, a Faculty Member (Email: meera@example.edu)." {
+		t.Errorf("intro = %q", b.intro)
+	}
+}
+
+func TestLatencyWindowPercentiles(t *testing.T) {
+	w := newLatencyWindow(100)
+	if w.Percentile(50) != 0 {
+		t.Error("empty window should report 0")
+	}
+	for i := 1; i <= 150; i++ { // The first 50 are overwritten
+		w.Observe(time.Duration(i) * time.Millisecond)
+	}
+	if p50 := w.Percentile(50); p50 != 100*time.Millisecond {
+		t.Errorf("p50 = %s, want 100ms", p50)
+	}
+	if p95 := w.Percentile(95); p95 != 145*time.Millisecond {
+		t.Errorf("p95 = %s, want 145ms", p95)
+	}
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Deterministic answers for schedule and grade lookups, so "what's my next class?"
+// does not need a model round trip.
+
+var (
+	nowPattern      = regexp.MustCompile(`(?i)\b(next class|right now|now|current class|happening)\b`)
+	tomorrowPattern = regexp.MustCompile(`(?i)\btomorrow\b`)
+	todayPattern    = regexp.MustCompile(`(?i)\btoday\b`)
+	dayPattern      = regexp.MustCompile(`(?i)\b(monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b`)
+	cgpaPattern     = regexp.MustCompile(`(?i)\b(cgpa|gpa|sgpa)\b`)
+)
+
+// answerLookup returns a data-only answer for schedule and grade lookups, or false when
+// the question needs the model or is not about the user's own records.
+func answerLookup(cl Classification, message, role string, brief *userBrief) (string, bool) {
+	if !personalLookup(message) {
+		return "", false
+	}
+	var answer string
+	var err error
+	switch cl.Intent {
+	case IntentSchedule:
+		answer, err = answerSchedule(cl, message, brief)
+	case IntentGrades:
+		if role == "teacher" {
+			return "", false // Teachers ask about other students; leave it to the tools
+		}
+		answer, err = answerGrades(cl, message, brief)
+	default:
+		return "", false
+	}
+	if err != nil {
+		slog.Warn("Lookup answer failed, using the model", "intent", cl.Intent, "error", err)
+		return "", false
+	}
+	return answer, answer != ""
+}
+
+func writeClasses(sb *strings.Builder, items []models.ScheduleItem) {
+	for _, it := range items {
+		sb.WriteString(fmt.Sprintf("- %s-%s: %s", it.StartTime, it.EndTime, it.Title))
+		if it.RoomNumber != "" {
+			sb.WriteString(fmt.Sprintf(" (%s)", it.RoomNumber))
+		}
+		sb.WriteString("\n")
+	}
+}
+
+func answerSchedule(cl Classification, message string, brief *userBrief) (string, error) {
+	items, err := brief.week()
+	if err != nil {
+		return "", err
+	}
+	if title, ok := brief.courses[cl.Topic]; ok {
+		filtered := make([]models.ScheduleItem, 0)
+		for _, it := range items {
+			if it.CourseID == cl.Topic {
+				filtered = append(filtered, it)
+			}
+		}
+		if len(filtered) == 0 {
+			return fmt.Sprintf("%s has no scheduled classes.", title), nil
+		}
+		items = filtered
+	}
+
+	now := time.Now()
+	today := now.Weekday().String()
+	onDay := func(day string) []models.ScheduleItem {
+		var out []models.ScheduleItem
+		for _, it := range items {
+			if it.DayOfWeek == day {
+				out = append(out, it)
+			}
+		}
+		return out
+	}
+
+	var sb strings.Builder
+	switch {
+	case nowPattern.MatchString(message) && !tomorrowPattern.MatchString(message):
+		clock := now.Format("15:04")
+		for _, it := range onDay(today) {
+			if it.StartTime <= clock && clock < it.EndTime {
+				return fmt.Sprintf("Right now you have **%s** in %s until %s.", it.Title, orTBA(it.RoomNumber), it.EndTime), nil
+			}
+		}
+		for _, it := range onDay(today) {
+			if it.StartTime > clock {
+				return fmt.Sprintf("Your next class today is **%s** at %s in %s.", it.Title, it.StartTime, orTBA(it.RoomNumber)), nil
+			}
+		}
+		return "No more classes scheduled for today.", nil
+
+	case tomorrowPattern.MatchString(message), todayPattern.MatchString(message), dayPattern.MatchString(message):
+		day := today
+		label := "today"
+		if tomorrowPattern.MatchString(message) {
+			day = now.AddDate(0, 0, 1).Weekday().String()
+			label = "tomorrow"
+		} else if m := dayPattern.FindString(message); m != "" && !todayPattern.MatchString(message) {
+			day = strings.ToUpper(m[:1]) + strings.ToLower(m[1:])
+			label = "on " + day
+		}
+		classes := onDay(day)
+		if len(classes) == 0 {
+			return fmt.Sprintf("You have no classes %s.", label), nil
+		}
+		sb.WriteString(fmt.Sprintf("Your classes %s (%s):\n", label, day))
+		writeClasses(&sb, classes)
+
+	default:
+		if len(items) == 0 {
+			return "You have no scheduled classes.", nil
+		}
+		sb.WriteString("Your weekly timetable:\n")
+		for _, day := range ValidDays {
+			if classes := onDay(day); len(classes) > 0 {
+				sb.WriteString(fmt.Sprintf("\n**%s**\n", day))
+				writeClasses(&sb, classes)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func orTBA(room string) string {
+	if room == "" {
+		return "a room to be announced"
+	}
+	return room
+}
+
+func answerGrades(cl Classification, message string, brief *userBrief) (string, error) {
+	grades, err := brief.publishedGrades()
+	if err != nil {
+		return "", err
+	}
+
+	if title, ok := brief.courses[cl.Topic]; ok {
+		for _, g := range grades {
+			if g.CourseID == cl.Topic {
+				answer := fmt.Sprintf("Your grade in %s is **%s**", title, g.Grade)
+				if g.Total != nil {
+					answer += fmt.Sprintf(" (%.1f/100)", *g.Total)
+				}
+				return answer + ".", nil
+			}
+		}
+		return fmt.Sprintf("No grade has been published for %s yet.", title), nil
+	}
+
+	if len(grades) == 0 {
+		return "No grades have been published yet.", nil
+	}
+	cgpa, credits := cgpaOf(grades)
+	if cgpaPattern.MatchString(message) {
+		return fmt.Sprintf("Your CGPA is **%.2f** over %d credits.", cgpa, credits), nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Your published grades:\n")
+	for _, g := range grades {
+		sb.WriteString(fmt.Sprintf("- %s (%s): %s\n", g.Title, g.CourseID, g.Grade))
+	}
+	sb.WriteString(fmt.Sprintf("\nCGPA: **%.2f** over %d credits.", cgpa, credits))
+	return sb.String(), nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"academ_aide/internal/prompt"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+func golden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s (run with -update to create it): %v", path, err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func studentPromptFixture() chatPromptData {
+	return chatPromptData{
+		Persona:     builtinPersonas["socratic"].SystemPrompt,
+		HasTools:    true,
+		Intro:       "You are talking to Asha, a 2 Year CSE student.",
+		Now:         "Monday, 10:15",
+		CourseLabel: "Enrolled Courses",
+		Courses:     []string{"Operating Systems (CS301)", "Database Systems (CS302)"},
+		Tone:        toneHint("negative"),
+	}
+}
+
+func TestChatPromptStudent(t *testing.T) {
+	res, err := chatPrompt.Render(studentPromptFixture(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "chat_prompt_student", res.Text)
+}
+
+func TestChatPromptNoToolsNoCourses(t *testing.T) {
+	data := chatPromptData{
+		Persona:     builtinPersonas["teacher"].SystemPrompt,
+		Intro:       "You are talking to Ravi, a Faculty Member (Email: ravi@example.edu).",
+		Now:         "Friday, 16:40",
+		CourseLabel: "Courses Taught",
+		Tone:        toneHint("neutral"),
+	}
+	res, err := chatPrompt.Render(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "chat_prompt_teacher", res.Text)
+}
+
+func TestChatPromptOverBudget(t *testing.T) {
+	data := studentPromptFixture()
+	for i := 1; i <= 40; i++ {
+		data.Courses = append(data.Courses, fmt.Sprintf("Elective Course Number %d (EL%03d)", i, i))
+	}
+	full, err := chatPrompt.Render(data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	budget := full.Tokens / 2
+	res, err := chatPrompt.Render(data, budget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res.Dropped, []string{"tone"}) || !reflect.DeepEqual(res.Truncated, []string{"context"}) {
+		t.Errorf("dropped %v, truncated %v; want tone dropped and context truncated", res.Dropped, res.Truncated)
+	}
+	if res.Tokens > budget {
+		t.Errorf("Tokens = %d, over budget %d", res.Tokens, budget)
+	}
+	golden(t, "chat_prompt_over_budget", res.Text)
+}
+
+func TestChatPromptRequiredTooLong(t *testing.T) {
+	_, err := chatPrompt.Render(studentPromptFixture(), 50)
+	if !errors.Is(err, prompt.ErrOverBudget) {
+		t.Fatalf("err = %v, want ErrOverBudget", err)
+	}
+}
+
+func TestSystemPromptBudget(t *testing.T) {
+	s := &RAGService{ContextTokens: 8192, ReplyTokens: 1024}
+	// 8192 - 1024 reply - 2048 tool results - 2 for the message
+	if got := s.systemPromptBudget("hello"); got != 5118 {
+		t.Errorf("systemPromptBudget = %d, want 5118", got)
+	}
+}
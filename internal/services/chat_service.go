@@ -0,0 +1,233 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const maxThreadTitle = 120
+
+// ChatService manages conversation threads and reads back the ChatLogs written by
+// RAGService.ProcessChat.
+type ChatService struct {
+	threads *mongo.Collection
+	logs    *mongo.Collection
+}
+
+func NewChatService(mdb *mongo.Database) *ChatService {
+	return &ChatService{
+		threads: mdb.Collection("Conversations"),
+		logs:    mdb.Collection("ChatLogs"),
+	}
+}
+
+// EnsureIndexes creates the indexes behind the thread list, the per-thread message
+// pages, the per-user activity scans (risk scoring, history clearing), the answer
+// feedback report and intent analytics.
+func (s *ChatService) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.threads.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "updated_at", Value: -1}},
+	}); err != nil {
+		return fmt.Errorf("indexing Conversations: %w", err)
+	}
+	if _, err := s.logs.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "student_id", Value: 1}, {Key: "conversation_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "student_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "feedback.created_at", Value: -1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "intent", Value: 1}, {Key: "timestamp", Value: -1}}},
+	}); err != nil {
+		return fmt.Errorf("indexing ChatLogs: %w", err)
+	}
+	return nil
+}
+
+// threadTitle trims a title to maxThreadTitle characters; untitled threads are named
+// after their first message.
+func threadTitle(title string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if utf8.RuneCountInString(title) > maxThreadTitle {
+		title = string([]rune(title)[:maxThreadTitle-3]) + "..."
+	}
+	return title
+}
+
+func threadFilter(userID, threadID string) (bson.M, error) {
+	oid, err := primitive.ObjectIDFromHex(threadID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	return bson.M{"_id": oid, "user_id": userID}, nil
+}
+
+// CreateThread starts a new conversation. An empty title becomes "New chat".
+func (s *ChatService) CreateThread(ctx context.Context, userID, title, agentID string) (*models.Conversation, error) {
+	title = threadTitle(title)
+	if title == "" {
+		title = "New chat"
+	}
+	now := time.Now()
+	t := models.Conversation{
+		UserID:    userID,
+		Title:     title,
+		AgentID:   agentID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	res, err := s.threads.InsertOne(ctx, t)
+	if err != nil {
+		return nil, fmt.Errorf("creating thread: %w", err)
+	}
+	t.ID = res.InsertedID.(primitive.ObjectID).Hex()
+	return &t, nil
+}
+
+// GetThread returns one of the user's threads.
+func (s *ChatService) GetThread(ctx context.Context, userID, threadID string) (*models.Conversation, error) {
+	filter, err := threadFilter(userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	var t models.Conversation
+	if err := s.threads.FindOne(ctx, filter).Decode(&t); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ListThreads returns the user's threads, most recently active first.
+func (s *ChatService) ListThreads(ctx context.Context, userID string, limit, offset int) ([]models.Conversation, int64, error) {
+	filter := bson.M{"user_id": userID}
+	total, err := s.threads.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := s.threads.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	threads := make([]models.Conversation, 0)
+	if err := cursor.All(ctx, &threads); err != nil {
+		return nil, 0, err
+	}
+	return threads, total, nil
+}
+
+// RenameThread changes a thread's title.
+func (s *ChatService) RenameThread(ctx context.Context, userID, threadID, title string) (*models.Conversation, error) {
+	title = threadTitle(title)
+	if title == "" {
+		return nil, &ValidationError{Field: "title", Message: "is required"}
+	}
+	filter, err := threadFilter(userID, threadID)
+	if err != nil {
+		return nil, err
+	}
+	var t models.Conversation
+	err = s.threads.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"title": title}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&t)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// DeleteThread removes a thread and its messages.
+func (s *ChatService) DeleteThread(ctx context.Context, userID, threadID string) error {
+	filter, err := threadFilter(userID, threadID)
+	if err != nil {
+		return err
+	}
+	res, err := s.threads.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	if _, err := s.logs.DeleteMany(ctx, bson.M{"student_id": userID, "conversation_id": threadID}); err != nil {
+		return fmt.Errorf("deleting thread messages: %w", err)
+	}
+	return nil
+}
+
+// Messages returns a page of the thread's messages, newest first.
+func (s *ChatService) Messages(ctx context.Context, userID, threadID string, limit, offset int) ([]models.ChatLog, int64, error) {
+	if _, err := s.GetThread(ctx, userID, threadID); err != nil {
+		return nil, 0, err
+	}
+	filter := bson.M{"student_id": userID, "conversation_id": threadID}
+	total, err := s.logs.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := s.logs.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	messages := make([]models.ChatLog, 0)
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
+
+// ClearThread deletes the thread's messages but keeps the thread itself.
+func (s *ChatService) ClearThread(ctx context.Context, userID, threadID string) (int64, error) {
+	filter, err := threadFilter(userID, threadID)
+	if err != nil {
+		return 0, err
+	}
+	res, err := s.threads.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"message_count": 0, "updated_at": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	if res.MatchedCount == 0 {
+		return 0, ErrNotFound
+	}
+	deleted, err := s.logs.DeleteMany(ctx, bson.M{"student_id": userID, "conversation_id": threadID})
+	if err != nil {
+		return 0, fmt.Errorf("clearing thread: %w", err)
+	}
+	return deleted.DeletedCount, nil
+}
+
+// touch records activity on a thread after an exchange is stored.
+func (s *ChatService) touch(ctx context.Context, threadID string, messages int) {
+	oid, err := primitive.ObjectIDFromHex(threadID)
+	if err != nil {
+		return
+	}
+	_, err = s.threads.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
+		"$set": bson.M{"updated_at": time.Now()},
+		"$inc": bson.M{"message_count": messages},
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Updating chat thread failed", "thread_id", threadID, "error", err)
+	}
+}
@@ -0,0 +1,436 @@
+package services
+
+import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/models"
+	"academ_aide/internal/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// toolEnv is what a tool may see: the caller, the courses they may access and the
+// citations gathered so far in this exchange.
+type toolEnv struct {
+	rag       *RAGService
+	userID    string
+	role      string
+	courseIDs []string // Enrolled (student) or taught (teacher) courses
+	topK      int      // Material chunks per search
+	brief     *userBrief
+
+	conversationID string
+	disclosed      map[string]bool // Emails, phones and student IDs the data tools returned
+	disclosedText  []string        // Data tool results; students named there are the user's to see
+	citations      []models.Citation
+	usedData       bool // A tool other than the material search ran
+}
+
+func (e *toolEnv) hasCourse(courseID string) bool {
+	return contains(e.courseIDs, courseID)
+}
+
+// chatTool is a typed Go function the model may call during ProcessChat.
+type chatTool struct {
+	ai.Tool
+	Roles []string // Roles the tool is offered to
+	Run   func(ctx context.Context, env *toolEnv, args json.RawMessage) (interface{}, error)
+}
+
+// Argument schema helpers
+func objectSchema(required []string, props map[string]interface{}) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func prop(typ, description string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "description": description}
+}
+
+var chatTools = []chatTool{
+	{
+		Tool: ai.Tool{
+			Name:        "get_timetable",
+			Description: "Weekly class timetable of the user (a student's classes or a teacher's teaching slots), optionally for one day.",
+			Parameters: objectSchema(nil, map[string]interface{}{
+				"day": prop("string", `Day of the week (e.g. "Monday") or "today"; omit for the whole week`),
+			}),
+		},
+		Roles: []string{"student", "teacher"},
+		Run:   toolTimetable,
+	},
+	{
+		Tool: ai.Tool{
+			Name:        "get_grades",
+			Description: "A student's published grades with component marks and CGPA. Teachers must give a course_id they teach and get its gradebook (every enrolled student), or one row with student_id.",
+			Parameters: objectSchema(nil, map[string]interface{}{
+				"course_id":  prop("string", "Limit to one course"),
+				"student_id": prop("string", "Teachers only: the student to look up"),
+			}),
+		},
+		Roles: []string{"student", "teacher"},
+		Run:   toolGrades,
+	},
+	{
+		Tool: ai.Tool{
+			Name:        "search_materials",
+			Description: "Semantic search over the course notes of the user's courses. Use it for questions about course content.",
+			Parameters: objectSchema([]string{"query"}, map[string]interface{}{
+				"query":     prop("string", "What to look for"),
+				"course_id": prop("string", "Limit to one course"),
+				"unit":      prop("integer", "Limit to one syllabus unit"),
+			}),
+		},
+		Roles: []string{"student", "teacher"},
+		Run:   toolSearchMaterials,
+	},
+	{
+		Tool: ai.Tool{
+			Name:        "get_announcements",
+			Description: "Latest announcements posted in the user's courses, newest first.",
+			Parameters: objectSchema(nil, map[string]interface{}{
+				"course_id": prop("string", "Limit to one course"),
+				"limit":     prop("integer", "Maximum number of announcements (default 5)"),
+			}),
+		},
+		Roles: []string{"student", "teacher"},
+		Run:   toolAnnouncements,
+	},
+	{
+		Tool: ai.Tool{
+			Name:        "get_teachers",
+			Description: "Teachers of the student's enrolled courses with their email addresses.",
+			Parameters:  objectSchema(nil, map[string]interface{}{}),
+		},
+		Roles: []string{"student"},
+		Run:   toolTeachers,
+	},
+	{
+		Tool: ai.Tool{
+			Name:        "compute_what_if",
+			Description: "Projects the student's attendance percentage and risk level if they miss more classes.",
+			Parameters: objectSchema([]string{"missed_classes"}, map[string]interface{}{
+				"missed_classes": prop("integer", "Number of additional classes missed"),
+			}),
+		},
+		Roles: []string{"student"},
+		Run:   toolWhatIf,
+	},
+}
+
+// toolsForRole returns the tools offered to a role.
+func toolsForRole(role string) []chatTool {
+	var tools []chatTool
+	for _, t := range chatTools {
+		if contains(t.Roles, role) {
+			tools = append(tools, t)
+		}
+	}
+	return tools
+}
+
+// findTool returns the registered tool with the name, or nil.
+func findTool(name string) *chatTool {
+	for i := range chatTools {
+		if chatTools[i].Name == name {
+			return &chatTools[i]
+		}
+	}
+	return nil
+}
+
+// toolsForPersona narrows the role's tools to those the persona lists (all of them if
+// it lists none). search_materials is dropped when the persona has retrieval off.
+func toolsForPersona(p *models.AgentPersona, role string) []chatTool {
+	var tools []chatTool
+	for _, t := range toolsForRole(role) {
+		if len(p.Tools) > 0 && !contains(p.Tools, t.Name) {
+			continue
+		}
+		if t.Name == "search_materials" && !p.Retrieval.Enabled {
+			continue
+		}
+		tools = append(tools, t)
+	}
+	return tools
+}
+
+// runTool executes a call and renders the result (or error) as JSON for the model.
+func runTool(ctx context.Context, env *toolEnv, tools []chatTool, call ai.ToolCall) string {
+	ctx, span := tracing.Start(ctx, "chat.tool", tracing.ToolName.String(call.Name))
+	var result interface{}
+	err := fmt.Errorf("unknown tool %q", call.Name)
+	for _, t := range tools {
+		if t.Name == call.Name {
+			result, err = t.Run(ctx, env, call.Arguments)
+			break
+		}
+	}
+	tracing.End(span, err)
+	if err != nil {
+		result = map[string]string{"error": err.Error()}
+	}
+	data, mErr := json.Marshal(result)
+	if mErr != nil {
+		return `{"error": "result could not be encoded"}`
+	}
+	return string(data)
+}
+
+func decodeArgs(args json.RawMessage, v interface{}) error {
+	if len(args) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// --- Tools ---
+
+func toolTimetable(ctx context.Context, env *toolEnv, args json.RawMessage) (interface{}, error) {
+	var a struct {
+		Day string `json:"day"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	day := strings.TrimSpace(a.Day)
+	if strings.EqualFold(day, "today") {
+		day = now.Weekday().String()
+	}
+	if day != "" {
+		day = strings.ToUpper(day[:1]) + strings.ToLower(day[1:])
+		if !IsValidDay(day) {
+			return nil, fmt.Errorf("day must be a weekday name or \"today\"")
+		}
+	}
+
+	items, err := env.brief.week()
+	if err != nil {
+		return nil, err
+	}
+	if day != "" {
+		filtered := make([]models.ScheduleItem, 0)
+		for _, it := range items {
+			if it.DayOfWeek == day {
+				filtered = append(filtered, it)
+			}
+		}
+		items = filtered
+	}
+	return map[string]interface{}{
+		"now":     now.Format("Monday 15:04"),
+		"classes": items,
+	}, nil
+}
+
+// gradePoints maps a letter grade onto the 10-point scale used for CGPA.
+func gradePoints(grade string) float64 {
+	switch grade {
+	case "O", "A+":
+		return 10.0
+	case "A":
+		return 9.0
+	case "B+":
+		return 8.0
+	case "B":
+		return 7.0
+	case "C+":
+		return 6.0
+	case "C":
+		return 5.0
+	case "D":
+		return 4.0
+	default:
+		return 0.0
+	}
+}
+
+func toolGrades(ctx context.Context, env *toolEnv, args json.RawMessage) (interface{}, error) {
+	var a struct {
+		CourseID  string `json:"course_id"`
+		StudentID string `json:"student_id"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	grading := env.rag.Grading
+
+	if env.role == "teacher" {
+		if a.CourseID == "" {
+			return nil, fmt.Errorf("course_id is required")
+		}
+		book, err := grading.Gradebook(ctx, env.userID, a.CourseID)
+		if err != nil {
+			return nil, err
+		}
+		if a.StudentID == "" {
+			return book, nil
+		}
+		for _, row := range book {
+			if row.StudentID == a.StudentID {
+				return row, nil
+			}
+		}
+		return nil, fmt.Errorf("%s is not enrolled in %s", a.StudentID, a.CourseID)
+	}
+
+	grades, err := env.brief.publishedGrades()
+	if err != nil {
+		return nil, err
+	}
+	shown := make([]models.StudentGrade, 0, len(grades))
+	for _, g := range grades {
+		if a.CourseID == "" || g.CourseID == a.CourseID {
+			shown = append(shown, g)
+		}
+	}
+	result := map[string]interface{}{"grades": shown}
+	if cgpa, credits := cgpaOf(grades); credits > 0 {
+		result["cgpa"] = cgpa
+	}
+	return result, nil
+}
+
+// cgpaOf returns the credit-weighted grade point average (truncated to two decimals)
+// and the credits it covers.
+func cgpaOf(grades []models.StudentGrade) (float64, int) {
+	totalCredits, totalPoints := 0, 0.0
+	for _, g := range grades {
+		totalCredits += g.Credits
+		totalPoints += gradePoints(g.Grade) * float64(g.Credits)
+	}
+	if totalCredits == 0 {
+		return 0, 0
+	}
+	return float64(int(totalPoints/float64(totalCredits)*100)) / 100, totalCredits
+}
+
+func toolSearchMaterials(ctx context.Context, env *toolEnv, args json.RawMessage) (interface{}, error) {
+	var a struct {
+		Query    string `json:"query"`
+		CourseID string `json:"course_id"`
+		Unit     int    `json:"unit"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(a.Query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	courses := env.courseIDs
+	if a.CourseID != "" {
+		if !env.hasCourse(a.CourseID) {
+			return nil, fmt.Errorf("%s is not one of your courses", a.CourseID)
+		}
+		courses = []string{a.CourseID}
+	}
+	if len(courses) == 0 {
+		return []interface{}{}, nil
+	}
+
+	embedding, err := env.rag.Embedder.GenerateEmbedding(ctx, a.Query)
+	if err != nil {
+		return nil, err
+	}
+	materials, err := env.rag.Repo.SearchMaterials(ctx, embedding, env.topK, courses, a.Unit)
+	if err != nil {
+		return nil, err
+	}
+	type chunk struct {
+		CourseID string `json:"course_id"`
+		Unit     int    `json:"unit"`
+		Source   string `json:"source"`
+		Content  string `json:"content"`
+	}
+	chunks := make([]chunk, 0, len(materials))
+	for _, m := range materials {
+		if hits := DetectMaterialInjection(m.Content); len(hits) > 0 {
+			env.rag.Safety.Record(ctx, models.SafetyIncident{
+				UserID: env.userID, Role: env.role, ConversationID: env.conversationID,
+				Kind: IncidentMaterialInjection, Source: SourceMaterial, Severity: RiskHigh,
+				Detail:  fmt.Sprintf("%s unit %d: %s", m.SourceFile, m.UnitNo, strings.Join(hits, ", ")),
+				Excerpt: m.Content,
+			})
+			continue
+		}
+		chunks = append(chunks, chunk{CourseID: m.CourseID, Unit: m.UnitNo, Source: m.SourceFile, Content: m.Content})
+		env.citations = append(env.citations, models.Citation{CourseID: m.CourseID, UnitNo: m.UnitNo, SourceFile: m.SourceFile, Score: m.Score})
+	}
+	trace.SpanFromContext(ctx).SetAttributes(tracing.ChunkCount.Int(len(chunks)))
+	return chunks, nil
+}
+
+func toolAnnouncements(ctx context.Context, env *toolEnv, args json.RawMessage) (interface{}, error) {
+	var a struct {
+		CourseID string `json:"course_id"`
+		Limit    int    `json:"limit"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if a.Limit <= 0 || a.Limit > 20 {
+		a.Limit = 5
+	}
+	courses := env.courseIDs
+	if a.CourseID != "" {
+		if !env.hasCourse(a.CourseID) {
+			return nil, fmt.Errorf("%s is not one of your courses", a.CourseID)
+		}
+		courses = []string{a.CourseID}
+	}
+
+	announcements, err := env.rag.Data.Announcements.ForCourses(ctx, courses, a.Limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]string, 0, len(announcements))
+	for _, an := range announcements {
+		out = append(out, map[string]string{
+			"course":  an.Course,
+			"content": an.Content,
+			"date":    an.Date,
+		})
+	}
+	return out, nil
+}
+
+func toolTeachers(ctx context.Context, env *toolEnv, _ json.RawMessage) (interface{}, error) {
+	teachers, err := env.rag.Data.Faculty.TeachersOf(ctx, env.userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]map[string]string, 0, len(teachers))
+	for _, t := range teachers {
+		out = append(out, map[string]string{
+			"name":      t.Name,
+			"email":     t.Email,
+			"course":    t.Course,
+			"course_id": t.CourseID,
+		})
+	}
+	return out, nil
+}
+
+func toolWhatIf(ctx context.Context, env *toolEnv, args json.RawMessage) (interface{}, error) {
+	var a struct {
+		MissedClasses int `json:"missed_classes"`
+	}
+	if err := decodeArgs(args, &a); err != nil {
+		return nil, err
+	}
+	if a.MissedClasses < 0 {
+		return nil, fmt.Errorf("missed_classes must not be negative")
+	}
+	return env.rag.Insights.CalculateWhatIf(ctx, env.userID, a.MissedClasses)
+}
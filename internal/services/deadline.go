@@ -0,0 +1,16 @@
+package services
+
+import (
+	"context"
+	"time"
+)
+
+// writeTimeout bounds a bookkeeping write that outlives the request that caused it.
+const writeTimeout = 5 * time.Second
+
+// detach returns a context for a write that must happen even if the client has gone
+// away, such as a safety incident or the log of an answer already generated. It keeps
+// ctx's values but not its cancellation, and has its own deadline.
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), writeTimeout)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"log/slog"
+)
+
+// FacultyService backs the teacher dashboard pages that are not grading, risk or alerts.
+type FacultyService struct {
+	repo repository.Repositories
+}
+
+func NewFacultyService(repo repository.Repositories) *FacultyService {
+	return &FacultyService{
+		repo: repo,
+	}
+}
+
+// Courses lists the course sections the faculty member teaches.
+func (s *FacultyService) Courses(ctx context.Context, facultyID string) ([]models.TaughtCourse, error) {
+	return s.repo.Faculty.Courses(ctx, facultyID)
+}
+
+// EnrolledStudents lists everyone enrolled in a course.
+func (s *FacultyService) EnrolledStudents(ctx context.Context, courseID string) ([]models.StudentContact, error) {
+	return s.repo.Enrollments.Students(ctx, courseID)
+}
+
+// ClassHealth buckets a course's grades. ErrNotFound if the course does not exist.
+func (s *FacultyService) ClassHealth(ctx context.Context, courseID string) (*models.ClassHealth, error) {
+	course, err := s.repo.Courses.Get(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+	health := &models.ClassHealth{
+		CourseID: courseID,
+		Title:    course.Title,
+		// Attendance still mocked as we lack attendance table
+		AttendanceDistribution: map[string]int{"90-100%": 12, "75-90%": 8, "60-75%": 3, "<60%": 1},
+		PerformanceHeatmap:     map[string]int{"Excellent (A/A+)": 0, "Good (B/B+)": 0, "Average (C/C+)": 0, "Poor (D/F)": 0},
+	}
+
+	grades, err := s.repo.Enrollments.Grades(ctx, courseID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Fetching course grades failed", "course_id", courseID, "error", err)
+		return health, nil
+	}
+	for _, g := range grades {
+		switch g {
+		case "O", "A+", "A":
+			health.PerformanceHeatmap["Excellent (A/A+)"]++
+		case "B+", "B":
+			health.PerformanceHeatmap["Good (B/B+)"]++
+		case "C+", "C":
+			health.PerformanceHeatmap["Average (C/C+)"]++
+		case "D", "F":
+			health.PerformanceHeatmap["Poor (D/F)"]++
+		}
+	}
+	return health, nil
+}
+
+// StudentStanding returns a student's contact details and grade in a course.
+// ErrNotFound if the student does not exist.
+func (s *FacultyService) StudentStanding(ctx context.Context, studentID, courseID string) (*models.StudentStanding, error) {
+	student, err := s.repo.Students.Get(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	st := &models.StudentStanding{
+		StudentContact: models.StudentContact{StudentID: studentID, Name: student.FirstName + " " + student.LastName, Email: student.Email},
+		Grade:          "N/A",
+	}
+	if e, err := s.repo.Enrollments.Get(ctx, studentID, courseID); err == nil && e.Grade != "" {
+		st.Grade = e.Grade
+	}
+	return st, nil
+}
+
+// PostAnnouncement adds an announcement to a course.
+func (s *FacultyService) PostAnnouncement(ctx context.Context, facultyID, courseID, content string) error {
+	return s.repo.Announcements.Create(ctx, facultyID, courseID, content)
+}
+
+// Profile returns the faculty member with the departments they teach in.
+// ErrNotFound if they do not exist.
+func (s *FacultyService) Profile(ctx context.Context, facultyID string) (*models.FacultyProfile, error) {
+	f, err := s.repo.Faculty.Get(ctx, facultyID)
+	if err != nil {
+		return nil, err
+	}
+	profile := &models.FacultyProfile{
+		FacultyID: f.FacultyID,
+		FirstName: f.FirstName,
+		LastName:  f.LastName,
+		Email:     f.Email,
+		PhoneNo:   f.PhoneNo,
+	}
+	if depts, err := s.repo.Faculty.Departments(ctx, facultyID); err == nil {
+		profile.Departments = depts
+	} else {
+		slog.ErrorContext(ctx, "Fetching faculty departments failed", "error", err)
+	}
+	return profile, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func facultyFixture() *repository.Memory {
+	return &repository.Memory{
+		Courses: []repository.Course{
+			{CourseID: "CS101", Title: "Data Structures", Credits: 4, DeptID: "CSE"},
+			{CourseID: "CS102", Title: "Operating Systems", Credits: 3, DeptID: "CSE"},
+		},
+		Departments: []models.Department{{DeptID: "CSE", DeptName: "Computer Science"}},
+		Students: []models.Student{
+			{StudentID: "S1", FirstName: "Asha", LastName: "Rao", Email: "asha@example.edu"},
+			{StudentID: "S2", FirstName: "Ravi", LastName: "Kumar", Email: "ravi@example.edu"},
+		},
+		Faculty: []models.Faculty{{FacultyID: "F1", FirstName: "Meera", LastName: "Iyer"}},
+		Teaches: []models.Teaches{
+			{FacultyID: "F1", CourseID: "CS102", SectionName: "CSE-A"},
+			{FacultyID: "F1", CourseID: "CS101", SectionName: "CSE-B"},
+			{FacultyID: "F1", CourseID: "CS101", SectionName: "CSE-A"},
+		},
+		Enrollments: []models.Enrollment{
+			{StudentID: "S1", CourseID: "CS101", Status: "Enrolled", Grade: "A"},
+			{StudentID: "S2", CourseID: "CS101", Status: "Enrolled"},
+			{StudentID: "S2", CourseID: "CS102", Status: "Completed", Grade: "F"},
+		},
+	}
+}
+
+func TestFacultyServiceOnMemory(t *testing.T) {
+	ctx := context.Background()
+	mem := facultyFixture()
+	s := NewFacultyService(mem.Repositories())
+
+	courses, err := s.Courses(ctx, "F1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.TaughtCourse{
+		{CourseID: "CS101", Title: "Data Structures", Section: "CSE-A"},
+		{CourseID: "CS101", Title: "Data Structures", Section: "CSE-B"},
+		{CourseID: "CS102", Title: "Operating Systems", Section: "CSE-A"},
+	}
+	if !reflect.DeepEqual(courses, want) {
+		t.Errorf("Courses = %+v, want %+v", courses, want)
+	}
+
+	standing, err := s.StudentStanding(ctx, "S2", "CS101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if standing.Name != "Ravi Kumar" || standing.Grade != "N/A" {
+		t.Errorf("ungraded standing = %+v", standing)
+	}
+	if _, err := s.StudentStanding(ctx, "S9", "CS101"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown student: err = %v, want ErrNotFound", err)
+	}
+
+	health, err := s.ClassHealth(ctx, "CS101")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if health.Title != "Data Structures" || health.PerformanceHeatmap["Excellent (A/A+)"] != 1 {
+		t.Errorf("ClassHealth = %+v", health)
+	}
+	if _, err := s.ClassHealth(ctx, "CS999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown course: err = %v, want ErrNotFound", err)
+	}
+
+	profile, err := s.Profile(ctx, "F1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(profile.Departments, []string{"Computer Science"}) {
+		t.Errorf("Departments = %v", profile.Departments)
+	}
+}
+
+func TestPersonaCourseIDsOnMemory(t *testing.T) {
+	repos := facultyFixture().Repositories()
+	s := NewPersonaService(nil, repos.Enrollments, repos.Faculty)
+
+	for _, tc := range []struct {
+		userID, role string
+		want         []string
+	}{
+		{"F1", "teacher", []string{"CS101", "CS102"}}, // One entry per course, not per section
+		{"S2", "student", []string{"CS101"}},          // Completed courses are not current
+	} {
+		got, err := s.userCourseIDs(context.Background(), tc.userID, tc.role)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s %s: got %v, want %v", tc.role, tc.userID, got, tc.want)
+		}
+	}
+}
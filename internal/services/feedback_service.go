@@ -0,0 +1,270 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Feedback ratings and reasons
+const (
+	RatingUp   = "up"
+	RatingDown = "down"
+)
+
+var ValidFeedbackReasons = []string{"incorrect", "irrelevant", "incomplete", "outdated", "unsafe", "other"}
+
+const (
+	maxFeedbackComment   = 1000
+	recentNegativeLimit  = 20
+	feedbackAnswerLength = 300
+	uncitedKey           = "(no material)" // Answers that retrieved no course material
+)
+
+type FeedbackService struct {
+	db   *sql.DB
+	logs *mongo.Collection
+}
+
+func NewFeedbackService(db *sql.DB, mdb *mongo.Database) *FeedbackService {
+	return &FeedbackService{
+		db:   db,
+		logs: mdb.Collection("ChatLogs"),
+	}
+}
+
+// dateRange builds a Mongo condition for inclusive YYYY-MM-DD bounds; either may be empty.
+func dateRange(from, to string) (bson.M, error) {
+	cond := bson.M{}
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return nil, &ValidationError{Field: "from", Message: "must be a date (YYYY-MM-DD)"}
+		}
+		cond["$gte"] = t
+	}
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return nil, &ValidationError{Field: "to", Message: "must be a date (YYYY-MM-DD)"}
+		}
+		cond["$lt"] = t.AddDate(0, 0, 1)
+	}
+	return cond, nil
+}
+
+// FeedbackFilter narrows the quality report. From and To are inclusive YYYY-MM-DD
+// dates; empty leaves the range open.
+type FeedbackFilter struct {
+	CourseID string
+	From     string
+	To       string
+}
+
+// Submit stores the user's rating on one of their bot answers, replacing any earlier one.
+func (s *FeedbackService) Submit(ctx context.Context, userID, messageID string, fb models.AnswerFeedback) (*models.AnswerFeedback, error) {
+	if fb.Rating != RatingUp && fb.Rating != RatingDown {
+		return nil, &ValidationError{Field: "rating", Message: `must be "up" or "down"`}
+	}
+	if fb.Reason != "" && !contains(ValidFeedbackReasons, fb.Reason) {
+		return nil, &ValidationError{Field: "reason", Message: "must be one of " + strings.Join(ValidFeedbackReasons, ", ")}
+	}
+	fb.Comment = strings.TrimSpace(fb.Comment)
+	if utf8.RuneCountInString(fb.Comment) > maxFeedbackComment {
+		return nil, &ValidationError{Field: "comment", Message: fmt.Sprintf("must be at most %d characters", maxFeedbackComment)}
+	}
+	oid, err := primitive.ObjectIDFromHex(messageID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	fb.CreatedAt = time.Now()
+
+	res, err := s.logs.UpdateOne(ctx,
+		bson.M{"_id": oid, "student_id": userID, "is_bot": true},
+		bson.M{"$set": bson.M{"feedback": fb}})
+	if err != nil {
+		return nil, fmt.Errorf("saving feedback: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, ErrNotFound
+	}
+	return &fb, nil
+}
+
+// feedbackDoc is the projection of a rated ChatLog the report needs.
+type feedbackDoc struct {
+	ID        string                `bson:"_id"`
+	AgentID   string                `bson:"agent_id"`
+	Message   string                `bson:"message"`
+	Citations []models.Citation     `bson:"citations"`
+	Feedback  models.AnswerFeedback `bson:"feedback"`
+}
+
+type bucketSet map[string]*models.FeedbackBucket
+
+func (b bucketSet) add(key, courseID string, fb models.AnswerFeedback) {
+	bucket, ok := b[key]
+	if !ok {
+		bucket = &models.FeedbackBucket{Key: key, CourseID: courseID, Reasons: map[string]int{}}
+		b[key] = bucket
+	}
+	if fb.Rating == RatingDown {
+		bucket.Down++
+		if fb.Reason != "" {
+			bucket.Reasons[fb.Reason]++
+		}
+	} else {
+		bucket.Up++
+	}
+}
+
+// sorted returns the buckets with the most negative ratings first.
+func (b bucketSet) sorted() []models.FeedbackBucket {
+	out := make([]models.FeedbackBucket, 0, len(b))
+	for _, bucket := range b {
+		bucket.DownRate = math.Round(float64(bucket.Down)/float64(bucket.Up+bucket.Down)*100) / 100
+		if len(bucket.Reasons) == 0 {
+			bucket.Reasons = nil
+		}
+		out = append(out, *bucket)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Down != out[j].Down {
+			return out[i].Down > out[j].Down
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
+
+// Report aggregates answer ratings by course, agent and cited source file. A teacher
+// (facultyID set) sees only answers grounded in the courses they teach; admins
+// (facultyID empty) see everything, including answers that cited no material.
+func (s *FeedbackService) Report(ctx context.Context, facultyID string, f FeedbackFilter) (*models.FeedbackReport, error) {
+	filter := bson.M{"is_bot": true, "feedback": bson.M{"$exists": true}}
+
+	var scope []string // Courses the report may show; nil means all
+	switch {
+	case f.CourseID != "":
+		if facultyID != "" {
+			if err := teachesCourse(ctx, s.db, facultyID, f.CourseID); err != nil {
+				return nil, err
+			}
+		}
+		scope = []string{f.CourseID}
+	case facultyID != "":
+		rows, err := s.db.QueryContext(ctx, "SELECT DISTINCT course_id FROM TEACHES WHERE faculty_id=$1", facultyID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		scope = make([]string, 0)
+		for rows.Next() {
+			var id string
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			scope = append(scope, id)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if scope != nil {
+		filter["citations.course_id"] = bson.M{"$in": scope}
+	}
+
+	created, err := dateRange(f.From, f.To)
+	if err != nil {
+		return nil, err
+	}
+	if len(created) > 0 {
+		filter["feedback.created_at"] = created
+	}
+
+	opts := options.Find().
+		SetProjection(bson.M{"agent_id": 1, "message": 1, "citations": 1, "feedback": 1}).
+		SetSort(bson.D{{Key: "feedback.created_at", Value: -1}})
+	cursor, err := s.logs.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var docs []feedbackDoc
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	report := &models.FeedbackReport{RecentNegative: make([]models.FeedbackComment, 0)}
+	byCourse, byAgent, bySource := bucketSet{}, bucketSet{}, bucketSet{}
+	for _, d := range docs {
+		report.Total++
+		if d.Feedback.Rating == RatingDown {
+			report.Down++
+		} else {
+			report.Up++
+		}
+
+		agent := d.AgentID
+		if agent == "" {
+			agent = "general"
+		}
+		byAgent.add(agent, "", d.Feedback)
+
+		// An answer counts once per course and once per file, however many chunks it cited
+		seenCourse, seenSource := map[string]bool{}, map[string]bool{}
+		var sources []string
+		for _, cit := range d.Citations {
+			if scope != nil && !contains(scope, cit.CourseID) {
+				continue
+			}
+			if !seenCourse[cit.CourseID] {
+				seenCourse[cit.CourseID] = true
+				byCourse.add(cit.CourseID, "", d.Feedback)
+			}
+			file := cit.SourceFile
+			if file == "" {
+				file = fmt.Sprintf("Unit %d", cit.UnitNo)
+			}
+			key := cit.CourseID + "/" + file
+			if !seenSource[key] {
+				seenSource[key] = true
+				bySource.add(key, cit.CourseID, d.Feedback)
+				sources = append(sources, key)
+			}
+		}
+		if len(d.Citations) == 0 {
+			byCourse.add(uncitedKey, "", d.Feedback)
+		}
+
+		if d.Feedback.Rating == RatingDown && d.Feedback.Comment != "" && len(report.RecentNegative) < recentNegativeLimit {
+			answer := d.Message
+			if utf8.RuneCountInString(answer) > feedbackAnswerLength {
+				answer = string([]rune(answer)[:feedbackAnswerLength]) + "..."
+			}
+			report.RecentNegative = append(report.RecentNegative, models.FeedbackComment{
+				MessageID: d.ID,
+				AgentID:   agent,
+				Answer:    answer,
+				Reason:    d.Feedback.Reason,
+				Comment:   d.Feedback.Comment,
+				Sources:   sources,
+				CreatedAt: d.Feedback.CreatedAt,
+			})
+		}
+	}
+	report.ByCourse = byCourse.sorted()
+	report.ByAgent = byAgent.sorted()
+	report.BySource = bySource.sorted()
+	return report, nil
+}
@@ -0,0 +1,817 @@
+package services
+
+import (
+	"academ_aide/internal/cache"
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrForbidden is returned when a teacher acts on a course they do not teach.
+var ErrForbidden = errors.New("not permitted for this course")
+
+// ValidComponentKinds mirrors the CHECK constraint on ASSESSMENT_COMPONENT.kind
+var ValidComponentKinds = []string{"internal", "assignment", "lab", "final"}
+
+// Grade states (COURSE_GRADE.state)
+const (
+	GradeDraft     = "draft"
+	GradePublished = "published"
+)
+
+// Grade audit actions
+const (
+	GradeAuditScheme    = "scheme"
+	GradeAuditMark      = "mark"
+	GradeAuditPublish   = "publish"
+	GradeAuditUnpublish = "unpublish"
+	GradeAuditImport    = "import"
+)
+
+// gradeBands maps the weighted total (percent) onto the 10-point letter grades
+// used for CGPA. The first band whose minimum is met wins.
+var gradeBands = []struct {
+	min   float64
+	grade string
+}{
+	{90, "O"},
+	{80, "A+"},
+	{70, "A"},
+	{60, "B+"},
+	{55, "B"},
+	{50, "C+"},
+	{45, "C"},
+	{40, "D"},
+	{0, "F"},
+}
+
+// LetterGrade converts a weighted total out of 100 into a letter grade.
+func LetterGrade(total float64) string {
+	for _, b := range gradeBands {
+		if total >= b.min {
+			return b.grade
+		}
+	}
+	return "F"
+}
+
+type GradingService struct {
+	db  *sql.DB
+	rdb *redis.Client
+}
+
+func NewGradingService(db *sql.DB, rdb *redis.Client) *GradingService {
+	return &GradingService{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+// PublishResult summarises a publish request.
+type PublishResult struct {
+	Published  []string `json:"published"`
+	Incomplete []string `json:"incomplete"` // Students still missing at least one component mark
+}
+
+// --- Helpers ---
+
+// authorize checks that the faculty member teaches the course.
+func (s *GradingService) authorize(ctx context.Context, facultyID, courseID string) error {
+	return teachesCourse(ctx, s.db, facultyID, courseID)
+}
+
+// teachesCourse returns ErrForbidden unless the faculty member teaches the course.
+func teachesCourse(ctx context.Context, db *sql.DB, facultyID, courseID string) error {
+	var ok bool
+	err := db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM TEACHES WHERE faculty_id=$1 AND course_id=$2)",
+		facultyID, courseID).Scan(&ok)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// writeGradeAudit records a grading change inside the caller's transaction.
+func writeGradeAudit(ctx context.Context, tx *sql.Tx, actorID, action, courseID, studentID string, before, after interface{}) error {
+	var oldData, newData []byte
+	var err error
+	if before != nil {
+		if oldData, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		if newData, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+	var student interface{}
+	if studentID != "" {
+		student = studentID
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO GRADE_AUDIT_LOG (actor_id, action, course_id, student_id, old_data, new_data)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, actorID, action, courseID, student, nullableJSON(oldData), nullableJSON(newData))
+	return err
+}
+
+func listComponents(ctx context.Context, q interface {
+	QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, courseID string) ([]models.AssessmentComponent, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT component_id, course_id, name, kind, max_marks, weight
+		FROM ASSESSMENT_COMPONENT WHERE course_id=$1 ORDER BY component_id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comps := make([]models.AssessmentComponent, 0)
+	for rows.Next() {
+		var comp models.AssessmentComponent
+		if err := rows.Scan(&comp.ComponentID, &comp.CourseID, &comp.Name, &comp.Kind, &comp.MaxMarks, &comp.Weight); err != nil {
+			return nil, err
+		}
+		comps = append(comps, comp)
+	}
+	return comps, rows.Err()
+}
+
+func validateScheme(comps []models.AssessmentComponent) error {
+	if len(comps) == 0 {
+		return &ValidationError{Field: "components", Message: "at least one component is required"}
+	}
+	seen := make(map[string]bool)
+	total := 0.0
+	for i, comp := range comps {
+		field := fmt.Sprintf("components[%d]", i)
+		if err := requireText(field+".name", comp.Name, 50); err != nil {
+			return err
+		}
+		key := strings.ToLower(strings.TrimSpace(comp.Name))
+		if seen[key] {
+			return &ValidationError{Field: field + ".name", Message: "is duplicated"}
+		}
+		seen[key] = true
+		if !contains(ValidComponentKinds, comp.Kind) {
+			return &ValidationError{Field: field + ".kind", Message: "must be one of " + strings.Join(ValidComponentKinds, ", ")}
+		}
+		if comp.MaxMarks <= 0 {
+			return &ValidationError{Field: field + ".max_marks", Message: "must be greater than 0"}
+		}
+		if comp.Weight <= 0 || comp.Weight > 100 {
+			return &ValidationError{Field: field + ".weight", Message: "must be between 0 and 100"}
+		}
+		total += comp.Weight
+	}
+	if math.Abs(total-100) > 0.01 {
+		return &ValidationError{Field: "components", Message: fmt.Sprintf("weights must total 100 (got %.2f)", total)}
+	}
+	return nil
+}
+
+// recompute refreshes the COURSE_GRADE row of each student from their component marks.
+// A published row stays published only if neither its total nor its grade changed;
+// otherwise it falls back to draft and ENROLLS_IN.grade keeps the last published grade.
+func recompute(ctx context.Context, tx *sql.Tx, courseID string, studentIDs []string) error {
+	comps, err := listComponents(ctx, tx, courseID)
+	if err != nil {
+		return err
+	}
+
+	for _, studentID := range studentIDs {
+		marks, err := studentMarks(ctx, tx, courseID, studentID)
+		if err != nil {
+			return err
+		}
+
+		var total, grade interface{}
+		if t, ok := weightedTotal(comps, marks); ok {
+			total = t
+			grade = LetterGrade(t)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO COURSE_GRADE (student_id, course_id, total, grade, state, computed_at)
+			VALUES ($1, $2, $3, $4, 'draft', CURRENT_TIMESTAMP)
+			ON CONFLICT (student_id, course_id) DO UPDATE SET
+				total = EXCLUDED.total,
+				grade = EXCLUDED.grade,
+				computed_at = EXCLUDED.computed_at,
+				state = CASE
+					WHEN COURSE_GRADE.state = 'published'
+						AND COURSE_GRADE.total IS NOT DISTINCT FROM EXCLUDED.total
+						AND COURSE_GRADE.grade IS NOT DISTINCT FROM EXCLUDED.grade
+					THEN 'published' ELSE 'draft' END
+		`, studentID, courseID, total, grade)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func studentMarks(ctx context.Context, tx *sql.Tx, courseID, studentID string) (map[int]float64, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT m.component_id, m.marks
+		FROM ASSESSMENT_MARK m
+		JOIN ASSESSMENT_COMPONENT ac ON ac.component_id = m.component_id
+		WHERE ac.course_id=$1 AND m.student_id=$2
+	`, courseID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	marks := make(map[int]float64)
+	for rows.Next() {
+		var id int
+		var m float64
+		if err := rows.Scan(&id, &m); err != nil {
+			return nil, err
+		}
+		marks[id] = m
+	}
+	return marks, rows.Err()
+}
+
+// weightedTotal returns the total out of 100, or false if any component is unmarked.
+func weightedTotal(comps []models.AssessmentComponent, marks map[int]float64) (float64, bool) {
+	if len(comps) == 0 {
+		return 0, false
+	}
+	total := 0.0
+	for _, comp := range comps {
+		m, ok := marks[comp.ComponentID]
+		if !ok {
+			return 0, false
+		}
+		total += m / comp.MaxMarks * comp.Weight
+	}
+	return math.Round(total*100) / 100, true
+}
+
+func courseStudents(ctx context.Context, tx *sql.Tx, courseID string) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT student_id FROM ENROLLS_IN WHERE course_id=$1 ORDER BY student_id", courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// invalidateGrades drops cached data that embeds published grades (profile CGPA, chat
+// briefs and chat answers).
+func invalidateGrades(ctx context.Context, rdb *redis.Client, studentIDs []string) {
+	if len(studentIDs) == 0 {
+		return
+	}
+	cache.InvalidateStudents(ctx, rdb, studentIDs...)
+	cache.InvalidateResponses(ctx, rdb)
+}
+
+// --- Assessment Scheme ---
+
+func (s *GradingService) GetScheme(ctx context.Context, facultyID, courseID string) ([]models.AssessmentComponent, error) {
+	if err := s.authorize(ctx, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	return listComponents(ctx, s.db, courseID)
+}
+
+// SetScheme replaces the course's assessment scheme. Components are matched by name so
+// existing marks survive re-weighting; removing a component that already has marks, or
+// lowering its max_marks below a mark already entered, is refused.
+func (s *GradingService) SetScheme(ctx context.Context, facultyID, courseID string, comps []models.AssessmentComponent) ([]models.AssessmentComponent, error) {
+	if err := s.authorize(ctx, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	if err := validateScheme(comps); err != nil {
+		return nil, err
+	}
+
+	var result []models.AssessmentComponent
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		before, err := listComponents(ctx, tx, courseID)
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]models.AssessmentComponent)
+		for _, comp := range before {
+			existing[strings.ToLower(comp.Name)] = comp
+		}
+
+		for i, comp := range comps {
+			name := strings.TrimSpace(comp.Name)
+			key := strings.ToLower(name)
+			if old, ok := existing[key]; ok {
+				delete(existing, key)
+				if comp.MaxMarks < old.MaxMarks {
+					var highest float64
+					if err := tx.QueryRowContext(ctx,
+						"SELECT COALESCE(MAX(marks), 0) FROM ASSESSMENT_MARK WHERE component_id=$1",
+						old.ComponentID).Scan(&highest); err != nil {
+						return err
+					}
+					if highest > comp.MaxMarks {
+						return &ValidationError{Field: fmt.Sprintf("components[%d].max_marks", i), Message: fmt.Sprintf("must be at least %g, the highest mark already entered", highest)}
+					}
+				}
+				if _, err := tx.ExecContext(ctx, `
+					UPDATE ASSESSMENT_COMPONENT SET name=$1, kind=$2, max_marks=$3, weight=$4
+					WHERE component_id=$5
+				`, name, comp.Kind, comp.MaxMarks, comp.Weight, old.ComponentID); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO ASSESSMENT_COMPONENT (course_id, name, kind, max_marks, weight)
+				VALUES ($1, $2, $3, $4, $5)
+			`, courseID, name, comp.Kind, comp.MaxMarks, comp.Weight); err != nil {
+				return err
+			}
+		}
+
+		for _, removed := range existing {
+			var hasMarks bool
+			if err := tx.QueryRowContext(ctx,
+				"SELECT EXISTS (SELECT 1 FROM ASSESSMENT_MARK WHERE component_id=$1)",
+				removed.ComponentID).Scan(&hasMarks); err != nil {
+				return err
+			}
+			if hasMarks {
+				return &ValidationError{Field: "components", Message: fmt.Sprintf("%q already has marks and cannot be removed", removed.Name)}
+			}
+			if _, err := tx.ExecContext(ctx, "DELETE FROM ASSESSMENT_COMPONENT WHERE component_id=$1", removed.ComponentID); err != nil {
+				return err
+			}
+		}
+
+		// Marks and max_marks may now imply different totals
+		if result, err = listComponents(ctx, tx, courseID); err != nil {
+			return err
+		}
+		students, err := courseStudents(ctx, tx, courseID)
+		if err != nil {
+			return err
+		}
+		if err := recompute(ctx, tx, courseID, students); err != nil {
+			return err
+		}
+		return writeGradeAudit(ctx, tx, facultyID, GradeAuditScheme, courseID, "", before, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// --- Marks ---
+
+// EnterMarks upserts (or clears, when Marks is nil) one component's marks for a batch of
+// students and recomputes their draft grades. The batch is all-or-nothing.
+func (s *GradingService) EnterMarks(ctx context.Context, facultyID, courseID string, componentID int, entries []models.MarkEntry) error {
+	if err := s.authorize(ctx, facultyID, courseID); err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return &ValidationError{Field: "entries", Message: "at least one entry is required"}
+	}
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var maxMarks float64
+		err := tx.QueryRowContext(ctx,
+			"SELECT max_marks FROM ASSESSMENT_COMPONENT WHERE component_id=$1 AND course_id=$2",
+			componentID, courseID).Scan(&maxMarks)
+		if err != nil {
+			return err
+		}
+
+		enrolled, err := courseStudents(ctx, tx, courseID)
+		if err != nil {
+			return err
+		}
+		isEnrolled := make(map[string]bool, len(enrolled))
+		for _, id := range enrolled {
+			isEnrolled[id] = true
+		}
+
+		seen := make(map[string]bool)
+		var changed []string
+		for i, e := range entries {
+			field := fmt.Sprintf("entries[%d]", i)
+			if !isEnrolled[e.StudentID] {
+				return &ValidationError{Field: field + ".student_id", Message: fmt.Sprintf("%q is not enrolled in %s", e.StudentID, courseID)}
+			}
+			if seen[e.StudentID] {
+				return &ValidationError{Field: field + ".student_id", Message: "is duplicated"}
+			}
+			seen[e.StudentID] = true
+			if e.Marks != nil && (*e.Marks < 0 || *e.Marks > maxMarks) {
+				return &ValidationError{Field: field + ".marks", Message: fmt.Sprintf("must be between 0 and %g", maxMarks)}
+			}
+
+			var old sql.NullFloat64
+			err := tx.QueryRowContext(ctx,
+				"SELECT marks FROM ASSESSMENT_MARK WHERE component_id=$1 AND student_id=$2",
+				componentID, e.StudentID).Scan(&old)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+			if (old.Valid && e.Marks != nil && old.Float64 == *e.Marks) || (!old.Valid && e.Marks == nil) {
+				continue
+			}
+
+			if e.Marks == nil {
+				_, err = tx.ExecContext(ctx, "DELETE FROM ASSESSMENT_MARK WHERE component_id=$1 AND student_id=$2", componentID, e.StudentID)
+			} else {
+				_, err = tx.ExecContext(ctx, `
+					INSERT INTO ASSESSMENT_MARK (component_id, student_id, marks, entered_by, updated_at)
+					VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+					ON CONFLICT (component_id, student_id) DO UPDATE SET
+						marks = EXCLUDED.marks, entered_by = EXCLUDED.entered_by, updated_at = EXCLUDED.updated_at
+				`, componentID, e.StudentID, *e.Marks, facultyID)
+			}
+			if err != nil {
+				return err
+			}
+
+			var before, after interface{}
+			if old.Valid {
+				before = map[string]interface{}{"component_id": componentID, "marks": old.Float64}
+			}
+			if e.Marks != nil {
+				after = map[string]interface{}{"component_id": componentID, "marks": *e.Marks}
+			}
+			if err := writeGradeAudit(ctx, tx, facultyID, GradeAuditMark, courseID, e.StudentID, before, after); err != nil {
+				return err
+			}
+			changed = append(changed, e.StudentID)
+		}
+
+		return recompute(ctx, tx, courseID, changed)
+	})
+}
+
+// --- Gradebook ---
+
+// Gradebook returns every enrolled student with their component marks, computed
+// draft grade and the grade currently published to them.
+func (s *GradingService) Gradebook(ctx context.Context, facultyID, courseID string) ([]models.GradebookRow, error) {
+	if err := s.authorize(ctx, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	comps, err := listComponents(ctx, s.db, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.student_id, s.s_first_name || ' ' || s.s_last_name, e.grade,
+			cg.total, cg.grade, COALESCE(cg.state, '')
+		FROM ENROLLS_IN e
+		JOIN STUDENT s ON s.student_id = e.student_id
+		LEFT JOIN COURSE_GRADE cg ON cg.student_id = e.student_id AND cg.course_id = e.course_id
+		WHERE e.course_id=$1
+		ORDER BY e.student_id
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	book := make([]models.GradebookRow, 0)
+	index := make(map[string]int)
+	for rows.Next() {
+		var r models.GradebookRow
+		var published, grade sql.NullString
+		var total sql.NullFloat64
+		if err := rows.Scan(&r.StudentID, &r.Name, &published, &total, &grade, &r.State); err != nil {
+			return nil, err
+		}
+		if published.Valid {
+			r.PublishedGrade = &published.String
+		}
+		if total.Valid {
+			r.Total = &total.Float64
+		}
+		if grade.Valid {
+			r.Grade = &grade.String
+		}
+		r.Marks = make([]models.ComponentMark, len(comps))
+		for i, comp := range comps {
+			r.Marks[i] = models.ComponentMark{ComponentID: comp.ComponentID, Name: comp.Name, Kind: comp.Kind, MaxMarks: comp.MaxMarks, Weight: comp.Weight}
+		}
+		index[r.StudentID] = len(book)
+		book = append(book, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	markRows, err := s.db.QueryContext(ctx, `
+		SELECT m.student_id, m.component_id, m.marks
+		FROM ASSESSMENT_MARK m
+		JOIN ASSESSMENT_COMPONENT ac ON ac.component_id = m.component_id
+		WHERE ac.course_id=$1
+	`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer markRows.Close()
+
+	for markRows.Next() {
+		var studentID string
+		var componentID int
+		var marks float64
+		if err := markRows.Scan(&studentID, &componentID, &marks); err != nil {
+			return nil, err
+		}
+		i, ok := index[studentID]
+		if !ok {
+			continue
+		}
+		for j := range book[i].Marks {
+			if book[i].Marks[j].ComponentID == componentID {
+				m := marks
+				book[i].Marks[j].Marks = &m
+			}
+		}
+	}
+	return book, markRows.Err()
+}
+
+// --- Publication ---
+
+// Publish copies draft grades into ENROLLS_IN.grade, making them visible to students.
+// studentIDs limits the publication; empty means every enrolled student.
+func (s *GradingService) Publish(ctx context.Context, facultyID, courseID string, studentIDs []string) (*PublishResult, error) {
+	if err := s.authorize(ctx, facultyID, courseID); err != nil {
+		return nil, err
+	}
+
+	result := &PublishResult{Published: []string{}, Incomplete: []string{}}
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT e.student_id, e.grade, cg.total, cg.grade, COALESCE(cg.state, '')
+			FROM ENROLLS_IN e
+			LEFT JOIN COURSE_GRADE cg ON cg.student_id = e.student_id AND cg.course_id = e.course_id
+			WHERE e.course_id=$1 AND (COALESCE(cardinality($2::text[]), 0) = 0 OR e.student_id = ANY($2::text[]))
+			ORDER BY e.student_id
+			FOR UPDATE OF e
+		`, courseID, studentIDs)
+		if err != nil {
+			return err
+		}
+
+		type pending struct {
+			studentID string
+			previous  sql.NullString
+			total     sql.NullFloat64 // NULL for imported grades
+			grade     string
+		}
+		var toPublish []pending
+		for rows.Next() {
+			var p pending
+			var grade sql.NullString
+			var state string
+			if err := rows.Scan(&p.studentID, &p.previous, &p.total, &grade, &state); err != nil {
+				rows.Close()
+				return err
+			}
+			switch {
+			case !grade.Valid:
+				result.Incomplete = append(result.Incomplete, p.studentID)
+			case state == GradeDraft:
+				p.grade = grade.String
+				toPublish = append(toPublish, p)
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, p := range toPublish {
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE ENROLLS_IN SET grade=$1 WHERE student_id=$2 AND course_id=$3",
+				p.grade, p.studentID, courseID); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE COURSE_GRADE SET state='published', published_at=CURRENT_TIMESTAMP, published_by=$1
+				WHERE student_id=$2 AND course_id=$3
+			`, facultyID, p.studentID, courseID); err != nil {
+				return err
+			}
+			var before interface{}
+			if p.previous.Valid {
+				before = map[string]interface{}{"grade": p.previous.String}
+			}
+			after := map[string]interface{}{"grade": p.grade}
+			if p.total.Valid {
+				after["total"] = p.total.Float64
+			}
+			if err := writeGradeAudit(ctx, tx, facultyID, GradeAuditPublish, courseID, p.studentID, before, after); err != nil {
+				return err
+			}
+			result.Published = append(result.Published, p.studentID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateGrades(ctx, s.rdb, result.Published)
+	return result, nil
+}
+
+// Unpublish withdraws published grades (e.g. to correct an error) and returns them to draft.
+func (s *GradingService) Unpublish(ctx context.Context, facultyID, courseID string, studentIDs []string) ([]string, error) {
+	if err := s.authorize(ctx, facultyID, courseID); err != nil {
+		return nil, err
+	}
+
+	withdrawn := []string{}
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			UPDATE COURSE_GRADE cg SET state='draft', published_at=NULL, published_by=NULL
+			FROM ENROLLS_IN e
+			WHERE e.student_id = cg.student_id AND e.course_id = cg.course_id
+				AND cg.course_id=$1 AND cg.state='published'
+				AND (COALESCE(cardinality($2::text[]), 0) = 0 OR cg.student_id = ANY($2::text[]))
+			RETURNING cg.student_id, e.grade
+		`, courseID, studentIDs)
+		if err != nil {
+			return err
+		}
+		previous := make(map[string]sql.NullString)
+		for rows.Next() {
+			var id string
+			var grade sql.NullString
+			if err := rows.Scan(&id, &grade); err != nil {
+				rows.Close()
+				return err
+			}
+			previous[id] = grade
+			withdrawn = append(withdrawn, id)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		for _, id := range withdrawn {
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE ENROLLS_IN SET grade=NULL WHERE student_id=$1 AND course_id=$2",
+				id, courseID); err != nil {
+				return err
+			}
+			var before interface{}
+			if previous[id].Valid {
+				before = map[string]interface{}{"grade": previous[id].String}
+			}
+			if err := writeGradeAudit(ctx, tx, facultyID, GradeAuditUnpublish, courseID, id, before, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	invalidateGrades(ctx, s.rdb, withdrawn)
+	return withdrawn, nil
+}
+
+// --- Audit ---
+
+func (s *GradingService) ListAudit(ctx context.Context, facultyID, courseID string, limit, offset int) ([]models.GradeAuditEntry, error) {
+	if err := s.authorize(ctx, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT audit_id, actor_id, action, course_id, student_id, old_data, new_data, created_at
+		FROM GRADE_AUDIT_LOG
+		WHERE course_id=$1
+		ORDER BY created_at DESC, audit_id DESC
+		LIMIT $2 OFFSET $3
+	`, courseID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.GradeAuditEntry, 0)
+	for rows.Next() {
+		var e models.GradeAuditEntry
+		var studentID sql.NullString
+		var oldData, newData []byte
+		if err := rows.Scan(&e.AuditID, &e.ActorID, &e.Action, &e.CourseID, &studentID, &oldData, &newData, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if studentID.Valid {
+			e.StudentID = &studentID.String
+		}
+		e.OldData = oldData
+		e.NewData = newData
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// --- Student View ---
+
+// StudentGrades returns the student's published grades. Component marks are included
+// only while the computed grade is still the published one, so draft corrections stay hidden.
+func (s *GradingService) StudentGrades(ctx context.Context, studentID string) ([]models.StudentGrade, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.course_id, c.title, c.credits, e.grade,
+			CASE WHEN cg.state = 'published' THEN cg.total END,
+			CASE WHEN cg.state = 'published' THEN cg.published_at END
+		FROM ENROLLS_IN e
+		JOIN COURSE c ON c.course_id = e.course_id
+		LEFT JOIN COURSE_GRADE cg ON cg.student_id = e.student_id AND cg.course_id = e.course_id
+		WHERE e.student_id=$1 AND e.grade IS NOT NULL
+		ORDER BY e.course_id
+	`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grades := make([]models.StudentGrade, 0)
+	for rows.Next() {
+		var g models.StudentGrade
+		var total sql.NullFloat64
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&g.CourseID, &g.Title, &g.Credits, &g.Grade, &total, &publishedAt); err != nil {
+			return nil, err
+		}
+		if total.Valid {
+			g.Total = &total.Float64
+		}
+		if publishedAt.Valid {
+			g.PublishedAt = &publishedAt.Time
+		}
+		grades = append(grades, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range grades {
+		if grades[i].Total == nil {
+			continue
+		}
+		markRows, err := s.db.QueryContext(ctx, `
+			SELECT ac.component_id, ac.name, ac.kind, ac.max_marks, ac.weight, m.marks
+			FROM ASSESSMENT_COMPONENT ac
+			LEFT JOIN ASSESSMENT_MARK m ON m.component_id = ac.component_id AND m.student_id=$2
+			WHERE ac.course_id=$1
+			ORDER BY ac.component_id
+		`, grades[i].CourseID, studentID)
+		if err != nil {
+			return nil, err
+		}
+		for markRows.Next() {
+			var cm models.ComponentMark
+			var marks sql.NullFloat64
+			if err := markRows.Scan(&cm.ComponentID, &cm.Name, &cm.Kind, &cm.MaxMarks, &cm.Weight, &marks); err != nil {
+				markRows.Close()
+				return nil, err
+			}
+			if marks.Valid {
+				cm.Marks = &marks.Float64
+			}
+			grades[i].Components = append(grades[i].Components, cm)
+		}
+		markRows.Close()
+	}
+	return grades, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"errors"
+	"testing"
+)
+
+func TestLetterGradeBands(t *testing.T) {
+	tests := []struct {
+		total float64
+		grade string
+	}{
+		{100, "O"}, {90, "O"}, {89.99, "A+"},
+		{80, "A+"}, {79.99, "A"},
+		{70, "A"}, {69.99, "B+"},
+		{60, "B+"}, {59.99, "B"},
+		{55, "B"}, {54.99, "C+"},
+		{50, "C+"}, {49.99, "C"},
+		{45, "C"}, {44.99, "D"},
+		{40, "D"}, {39.99, "F"},
+		{0, "F"}, {-1, "F"},
+	}
+	for _, tt := range tests {
+		if got := LetterGrade(tt.total); got != tt.grade {
+			t.Errorf("LetterGrade(%v) = %s, want %s", tt.total, got, tt.grade)
+		}
+	}
+}
+
+func TestWeightedTotal(t *testing.T) {
+	scheme := []models.AssessmentComponent{
+		{ComponentID: 1, Name: "CIE 1", MaxMarks: 50, Weight: 20},
+		{ComponentID: 2, Name: "Lab", MaxMarks: 25, Weight: 30},
+		{ComponentID: 3, Name: "SEE", MaxMarks: 100, Weight: 50},
+	}
+	tests := []struct {
+		name   string
+		comps  []models.AssessmentComponent
+		marks  map[int]float64
+		total  float64
+		ok     bool
+		letter string
+	}{
+		{"full marks", scheme, map[int]float64{1: 50, 2: 25, 3: 100}, 100, true, "O"},
+		{"weighted", scheme, map[int]float64{1: 40, 2: 20, 3: 70}, 75, true, "A"},
+		{"rounded to two places", scheme, map[int]float64{1: 33, 2: 17, 3: 61}, 64.1, true, "B+"},
+		{"just under a band", scheme, map[int]float64{1: 50, 2: 25, 3: 79.98}, 89.99, true, "A+"},
+		{"zero is a mark", scheme, map[int]float64{1: 0, 2: 0, 3: 0}, 0, true, "F"},
+		{"component unmarked", scheme, map[int]float64{1: 50, 3: 100}, 0, false, ""},
+		{"no marks", scheme, map[int]float64{}, 0, false, ""},
+		{"no scheme", nil, map[int]float64{1: 50}, 0, false, ""},
+		{"marks outside the scheme ignored", scheme[:1], map[int]float64{1: 25, 9: 10}, 10, true, "F"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, ok := weightedTotal(tt.comps, tt.marks)
+			if ok != tt.ok || total != tt.total {
+				t.Fatalf("weightedTotal = %v, %v; want %v, %v", total, ok, tt.total, tt.ok)
+			}
+			if ok && LetterGrade(total) != tt.letter {
+				t.Errorf("LetterGrade(%v) = %s, want %s", total, LetterGrade(total), tt.letter)
+			}
+		})
+	}
+}
+
+func TestValidateScheme(t *testing.T) {
+	comp := func(name string, max, weight float64) models.AssessmentComponent {
+		return models.AssessmentComponent{Name: name, Kind: "internal", MaxMarks: max, Weight: weight}
+	}
+	tests := []struct {
+		name  string
+		comps []models.AssessmentComponent
+		field string // "" when valid
+	}{
+		{"valid", []models.AssessmentComponent{comp("CIE", 50, 40), comp("SEE", 100, 60)}, ""},
+		{"empty", nil, "components"},
+		{"weights under 100", []models.AssessmentComponent{comp("CIE", 50, 40), comp("SEE", 100, 50)}, "components"},
+		{"duplicate name", []models.AssessmentComponent{comp("CIE", 50, 50), comp(" cie ", 50, 50)}, "components[1].name"},
+		{"zero max marks", []models.AssessmentComponent{comp("CIE", 0, 100)}, "components[0].max_marks"},
+		{"bad kind", []models.AssessmentComponent{{Name: "Viva", Kind: "oral", MaxMarks: 10, Weight: 100}}, "components[0].kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateScheme(tt.comps)
+			var vErr *ValidationError
+			switch {
+			case tt.field == "" && err != nil:
+				t.Errorf("err = %v, want valid", err)
+			case tt.field != "" && (!errors.As(err, &vErr) || vErr.Field != tt.field):
+				t.Errorf("err = %v, want a ValidationError on %s", err, tt.field)
+			}
+		})
+	}
+}
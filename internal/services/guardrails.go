@@ -0,0 +1,332 @@
+package services
+
+import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/config"
+	"academ_aide/internal/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// --- Prompt injection ---
+
+// injectionPatterns catch text that tries to override the assistant's instructions or
+// pull data the caller may not see. They run on user messages and on retrieved
+// course materials.
+var injectionPatterns = []struct {
+	name         string
+	pattern      *regexp.Regexp
+	messagesOnly bool // Course notes legitimately talk about "all students' marks"
+}{
+	{"override_instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override|bypass)\b.{0,30}\b(previous|prior|above|earlier|all|your|the|system)\b.{0,20}\b(instructions?|prompts?|rules|guidelines|directions)\b`), false},
+	{"new_instructions", regexp.MustCompile(`(?i)\b(new|updated|real)\s+(system\s+)?(instructions?|rules)\s*:`), false},
+	{"role_override", regexp.MustCompile(`(?i)\b(you are now|from now on,? you (are|will)|act as (an? )?(admin|administrator|teacher|developer|system)|pretend (to be|you are)|developer mode|jailbreak|DAN mode)\b`), false},
+	{"prompt_extraction", regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|output|leak)\b.{0,20}\b(system prompt|your (instructions|prompt|rules)|hidden (instructions|prompt))\b`), false},
+	{"role_markup", regexp.MustCompile(`(?im)(<\|im_start\|>|<\|im_end\|>|\[/?INST\]|<<SYS>>|^\s*#{2,}\s*(system|assistant)\s*:?\s*$|</?untrusted_content)`), false},
+	{"bulk_personal_data", regexp.MustCompile(`(?i)\b(other|all|every|each)\s+(students?'?|users?'?|classmates'?)\s*(s\s+)?(grades?|marks|scores|cgpa|emails?|phone|numbers|addresses|personal|records|data)\b`), true},
+}
+
+// DetectInjection returns the names of the injection patterns a user message matches.
+func DetectInjection(message string) []string {
+	return detectInjection(message, false)
+}
+
+// DetectMaterialInjection returns the names of the injection patterns a retrieved
+// course material chunk matches.
+func DetectMaterialInjection(chunk string) []string {
+	return detectInjection(chunk, true)
+}
+
+func detectInjection(text string, material bool) []string {
+	var hits []string
+	for _, p := range injectionPatterns {
+		if material && p.messagesOnly {
+			continue
+		}
+		if p.pattern.MatchString(text) {
+			hits = append(hits, p.name)
+		}
+	}
+	return hits
+}
+
+// Tools whose output is written by other people (material authors, announcers) and is
+// delimited as untrusted in the conversation.
+var untrustedTools = []string{"search_materials", "get_announcements"}
+
+// delimitUntrusted wraps a tool result the model must treat as data. Results are JSON,
+// which escapes '<' and '>', so the content cannot close the tag itself.
+func delimitUntrusted(tool, content string) string {
+	return fmt.Sprintf("<untrusted_content source=%q>\n%s\n</untrusted_content>", tool, content)
+}
+
+// --- Personal data in answers ---
+
+var piiPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{"email", regexp.MustCompile(`(?i)\b[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}\b`)},
+	{"phone", regexp.MustCompile(`(?:\+91[\s-]?)?\b[6-9]\d{9}\b|\b\d{3}-\d{4}\b`)},
+	{"student_id", regexp.MustCompile(`\b(?:1[A-Z]{2}\d{2}[A-Z]{2,3}\d{3}|S\d{4})\b`)},
+}
+
+// collectIdentifiers adds every email, phone number and student ID in text to seen.
+// Identifiers the caller's own tools returned are theirs to see.
+func collectIdentifiers(text string, seen map[string]bool) {
+	for _, p := range piiPatterns {
+		for _, m := range p.pattern.FindAllString(text, -1) {
+			seen[strings.ToLower(m)] = true
+		}
+	}
+}
+
+// containsIdentifiers reports whether text holds any email, phone number or student ID.
+func containsIdentifiers(text string) bool {
+	for _, p := range piiPatterns {
+		if p.pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordWords mark an answer that talks about someone's grades or attendance.
+var recordWords = regexp.MustCompile(`(?i)\b(grades?|marks?|scores?|scored|cgpa|gpa|sgpa|results?|attendance|absent|present|failed|passed|backlogs?)\b`)
+
+// rosterName matches a student the caller may not see in an answer.
+type rosterName struct {
+	full  *regexp.Regexp
+	first *regexp.Regexp // nil when the first name alone could be someone the caller may see
+}
+
+// hiddenNames returns the students of the caller's courses that an answer must not
+// name: everyone but the caller and the students the caller's own tools returned
+// (disclosed holds those results).
+func hiddenNames(roster []models.StudentContact, userID, displayName string, disclosed []string) []rosterName {
+	visible := func(name string) bool {
+		lower := strings.ToLower(name)
+		for _, text := range disclosed {
+			if strings.Contains(strings.ToLower(text), lower) {
+				return true
+			}
+		}
+		return false
+	}
+	shownFirst := map[string]bool{strings.ToLower(displayName): true}
+	var hidden []models.StudentContact
+	for _, st := range roster {
+		first := strings.Fields(st.Name)
+		if st.StudentID == userID || visible(st.Name) {
+			if len(first) > 0 {
+				shownFirst[strings.ToLower(first[0])] = true
+			}
+			continue
+		}
+		hidden = append(hidden, st)
+	}
+
+	names := make([]rosterName, 0, len(hidden))
+	for _, st := range hidden {
+		parts := strings.Fields(st.Name)
+		if len(parts) == 0 {
+			continue
+		}
+		n := rosterName{full: regexp.MustCompile(`(?i)\b` + strings.Join(quoteAll(parts), `\s+`) + `\b`)}
+		if len(parts[0]) >= 3 && !shownFirst[strings.ToLower(parts[0])] {
+			n.first = regexp.MustCompile(`\b` + regexp.QuoteMeta(parts[0]) + `\b`)
+		}
+		names = append(names, n)
+	}
+	return names
+}
+
+func quoteAll(parts []string) []string {
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		quoted[i] = regexp.QuoteMeta(p)
+	}
+	return quoted
+}
+
+// redactPII replaces the emails, phone numbers and student IDs in an answer that the
+// caller was not given by a tool, and the names of hidden students: full names always,
+// a first name alone when the answer talks about grades or attendance. Returns the
+// filtered text and the kinds redacted.
+func redactPII(text string, allowed map[string]bool, hidden []rosterName) (string, []string) {
+	var kinds []string
+	records := recordWords.MatchString(text)
+	named := false
+	for _, n := range hidden {
+		if n.full.MatchString(text) {
+			text, named = n.full.ReplaceAllString(text, "[redacted]"), true
+		}
+		if records && n.first != nil && n.first.MatchString(text) {
+			text, named = n.first.ReplaceAllString(text, "[redacted]"), true
+		}
+	}
+	if named {
+		kinds = append(kinds, "name")
+	}
+	for _, p := range piiPatterns {
+		redacted := false
+		text = p.pattern.ReplaceAllStringFunc(text, func(m string) string {
+			if allowed[strings.ToLower(m)] {
+				return m
+			}
+			redacted = true
+			return "[redacted]"
+		})
+		if redacted {
+			kinds = append(kinds, p.kind)
+		}
+	}
+	return text, kinds
+}
+
+// --- Moderation ---
+
+// Moderation categories (SafetyIncidents.kind)
+const (
+	ModerationSelfHarm = "self_harm"
+	ModerationAbuse    = "abuse"
+)
+
+// ModerationVerdict is a moderator's decision on one text.
+type ModerationVerdict struct {
+	Flagged  bool   `json:"flagged"`
+	Category string `json:"category"` // ModerationSelfHarm, ModerationAbuse or a hook's own label
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Moderator checks a text for self-harm or abuse.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) (ModerationVerdict, error)
+}
+
+var moderationRules = []struct {
+	category string
+	reason   string
+	pattern  *regexp.Regexp
+}{
+	{ModerationSelfHarm, "self-harm", regexp.MustCompile(`(?i)\b(kill(ing)? myself|end(ing)? my (own )?life|take my (own )?life|suicid(e|al)|want(ed)? to die|wish i (was|were) dead|better off dead|self[- ]?harm(ing)?|cut(ting)? myself|hurt(ing)? myself|no reason to (live|go on)|don'?t want to (live|be alive))\b`)},
+	{ModerationAbuse, "threat of violence", regexp.MustCompile(`(?i)\b(i('?ll| will| am going to|'?m going to|'?m gonna| wanna| want to) (kill|hurt|beat( up)?|stab|shoot|attack) (him|her|them|you|my|the|that|this|some)|(shoot|bomb|blow) up (the|my|our) (school|college|campus|class|university|hostel))\b`)},
+	{ModerationAbuse, "harassment", regexp.MustCompile(`(?i)\b(leak (her|his|their) (nudes|photos|address)|dox(x)?(ing)? (him|her|them)|how (do|can) i (stalk|blackmail|harass) )`)},
+}
+
+// KeywordModerator flags self-harm and abuse with fixed patterns.
+type KeywordModerator struct{}
+
+func (KeywordModerator) Moderate(ctx context.Context, text string) (ModerationVerdict, error) {
+	for _, r := range moderationRules {
+		if r.pattern.MatchString(text) {
+			return ModerationVerdict{Flagged: true, Category: r.category, Reason: r.reason}, nil
+		}
+	}
+	return ModerationVerdict{}, nil
+}
+
+// HookModerator asks an external moderation service: POST {"input": text} answered
+// with {"flagged": bool, "category": "...", "reason": "..."}.
+type HookModerator struct {
+	URL     string
+	APIKey  string
+	Client  *http.Client
+	Timeout time.Duration // Per call
+}
+
+func (m *HookModerator) Moderate(ctx context.Context, text string) (ModerationVerdict, error) {
+	body, err := json.Marshal(map[string]string{"input": text})
+	if err != nil {
+		return ModerationVerdict{}, err
+	}
+	if m.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, bytes.NewReader(body))
+	if err != nil {
+		return ModerationVerdict{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.APIKey)
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return ModerationVerdict{}, fmt.Errorf("calling moderation hook: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ModerationVerdict{}, fmt.Errorf("moderation hook returned status %d", resp.StatusCode)
+	}
+	var v ModerationVerdict
+	if err := json.NewDecoder(resp.Body).Decode(&v); err != nil {
+		return ModerationVerdict{}, fmt.Errorf("decoding moderation verdict: %w", err)
+	}
+	return v, nil
+}
+
+// moderators runs each moderator in turn and returns the first flag. A failing
+// moderator is skipped so a hook outage does not block chat.
+type moderators []Moderator
+
+func (ms moderators) Moderate(ctx context.Context, text string) (ModerationVerdict, error) {
+	var firstErr error
+	for _, m := range ms {
+		v, err := m.Moderate(ctx, text)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if v.Flagged {
+			return v, nil
+		}
+	}
+	return ModerationVerdict{}, firstErr
+}
+
+// NewModerator returns the keyword moderator, followed by the cfg.ModerationURL hook
+// (with cfg.ModerationAPIKey) when one is configured.
+func NewModerator(cfg config.Chat) Moderator {
+	ms := moderators{KeywordModerator{}}
+	if cfg.ModerationURL != "" {
+		ms = append(ms, &HookModerator{
+			URL:     cfg.ModerationURL,
+			APIKey:  cfg.ModerationAPIKey,
+			Client:  ai.HTTPClient,
+			Timeout: 5 * time.Second,
+		})
+	}
+	return ms
+}
+
+// Replies used instead of the model when moderation flags a message or an answer.
+const (
+	abuseReply        = "I can't help with that. If you or someone else is in danger, please contact campus security or emergency services right away."
+	unsafeAnswerReply = "I'm sorry, I can't share that answer. Could you rephrase your question?"
+)
+
+// selfHarmReply points the user to people who can help, naming the configured helpline.
+func selfHarmReply(helpline string) string {
+	return fmt.Sprintf("I'm really sorry you're feeling this way. You don't have to go through it alone. "+
+		"Please reach out to someone you trust or to the campus counsellor, or call %s. "+
+		"If you are in immediate danger, call your local emergency number now.", helpline)
+}
+
+// moderationReply is the reply for a flagged user message.
+func moderationReply(v ModerationVerdict, helpline string) string {
+	if v.Category == ModerationSelfHarm {
+		return selfHarmReply(helpline)
+	}
+	return abuseReply
+}
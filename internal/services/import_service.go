@@ -0,0 +1,696 @@
+package services
+
+import (
+	"academ_aide/internal/cache"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/xuri/excelize/v2"
+)
+
+// Datasets supported by bulk import/export
+const (
+	DatasetStudents    = "students"
+	DatasetEnrollments = "enrollments"
+	DatasetSchedules   = "schedules"
+	DatasetGrades      = "grades"
+)
+
+// ValidGrades lists the letter grades accepted in ENROLLS_IN.grade
+var ValidGrades = []string{"O", "A+", "A", "B+", "B", "C+", "C", "D", "E", "F"}
+
+// ValidEnrollmentStatuses lists the accepted ENROLLS_IN.status values
+var ValidEnrollmentStatuses = []string{"Enrolled", "Completed", "Dropped"}
+
+// MaxImportRows bounds a single import so one request cannot hold a transaction open indefinitely.
+const MaxImportRows = 20000
+
+var ErrUnknownDataset = errors.New("unknown dataset")
+
+type ImportRowError struct {
+	Row     int    `json:"row"` // Spreadsheet row number (header is row 1)
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	Dataset   string           `json:"dataset"`
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	ValidRows int              `json:"valid_rows"`
+	Committed bool             `json:"committed"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// importRow is a parsed CSV record keyed by lower-case column name.
+type importRow struct {
+	line   int
+	fields map[string]string
+}
+
+func (r importRow) get(col string) string {
+	return strings.TrimSpace(r.fields[col])
+}
+
+type datasetSpec struct {
+	columns  []string // Export order and accepted import headers
+	required []string
+	key      func(r importRow) string
+	validate func(refs *importRefs, r importRow) []ImportRowError
+	apply    func(ctx context.Context, tx *sql.Tx, actorID string, r importRow) error
+	export   string // Query returning columns in the same order as columns
+}
+
+var datasets = map[string]datasetSpec{
+	DatasetStudents: {
+		columns:  []string{"student_id", "first_name", "last_name", "email", "phone_no", "semester", "year_of_joining", "dept_id"},
+		required: []string{"student_id", "first_name", "last_name", "email", "semester", "year_of_joining", "dept_id"},
+		key:      func(r importRow) string { return r.get("student_id") },
+		validate: validateStudentRow,
+		apply:    applyStudentRow,
+		export: `SELECT student_id, s_first_name, s_last_name, s_email, COALESCE(s_phone_no, ''),
+			semester::text, year_of_joining::text, dept_id
+			FROM STUDENT ORDER BY student_id`,
+	},
+	DatasetEnrollments: {
+		columns:  []string{"student_id", "course_id", "status", "backlog"},
+		required: []string{"student_id", "course_id"},
+		key:      func(r importRow) string { return r.get("student_id") + "/" + r.get("course_id") },
+		validate: validateEnrollmentRow,
+		apply:    applyEnrollmentRow,
+		export: `SELECT student_id, course_id, COALESCE(status, ''), COALESCE(backlog, FALSE)::text
+			FROM ENROLLS_IN ORDER BY student_id, course_id`,
+	},
+	DatasetSchedules: {
+		columns:  []string{"course_id", "section_name", "day_of_week", "start_time", "end_time", "room_number"},
+		required: []string{"course_id", "section_name", "day_of_week", "start_time", "end_time"},
+		key: func(r importRow) string {
+			return r.get("course_id") + "/" + r.get("section_name") + "/" + normalizeDay(r.get("day_of_week")) + "/" + r.get("start_time")
+		},
+		validate: validateScheduleRow,
+		apply:    applyScheduleRow,
+		export: `SELECT course_id, section_name, day_of_week, TO_CHAR(start_time, 'HH24:MI'), TO_CHAR(end_time, 'HH24:MI'), COALESCE(room_number, '')
+			FROM SCHEDULE
+			ORDER BY course_id, section_name,
+				CASE day_of_week WHEN 'Monday' THEN 1 WHEN 'Tuesday' THEN 2 WHEN 'Wednesday' THEN 3
+					WHEN 'Thursday' THEN 4 WHEN 'Friday' THEN 5 ELSE 6 END,
+				start_time`,
+	},
+	DatasetGrades: {
+		columns:  []string{"student_id", "course_id", "grade"},
+		required: []string{"student_id", "course_id", "grade"},
+		key:      func(r importRow) string { return r.get("student_id") + "/" + r.get("course_id") },
+		validate: validateGradeRow,
+		apply:    applyGradeRow,
+		export: `SELECT student_id, course_id, grade FROM ENROLLS_IN
+			WHERE grade IS NOT NULL ORDER BY course_id, student_id`,
+	},
+}
+
+// DatasetNames returns the supported dataset names.
+func DatasetNames() []string {
+	return []string{DatasetStudents, DatasetEnrollments, DatasetSchedules, DatasetGrades}
+}
+
+// importRefs holds the reference data rows are validated against.
+type importRefs struct {
+	departments map[string]bool
+	students    map[string]bool
+	courses     map[string]bool
+	sections    map[string]bool
+	enrollments map[string]bool   // "student/course"
+	schemes     map[string]bool   // Courses with an assessment scheme
+	emails      map[string]string // email -> student_id
+}
+
+type ImportService struct {
+	db  *sql.DB
+	rdb *redis.Client
+}
+
+func NewImportService(db *sql.DB, rdb *redis.Client) *ImportService {
+	return &ImportService{
+		db:  db,
+		rdb: rdb,
+	}
+}
+
+func (s *ImportService) loadSet(ctx context.Context, query string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	set := make(map[string]bool)
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		set[v] = true
+	}
+	return set, rows.Err()
+}
+
+func (s *ImportService) loadRefs(ctx context.Context) (*importRefs, error) {
+	refs := &importRefs{emails: make(map[string]string)}
+	var err error
+	if refs.departments, err = s.loadSet(ctx, "SELECT dept_id FROM DEPARTMENT"); err != nil {
+		return nil, err
+	}
+	if refs.students, err = s.loadSet(ctx, "SELECT student_id FROM STUDENT"); err != nil {
+		return nil, err
+	}
+	if refs.courses, err = s.loadSet(ctx, "SELECT course_id FROM COURSE"); err != nil {
+		return nil, err
+	}
+	if refs.sections, err = s.loadSet(ctx, "SELECT section_name FROM SECTION"); err != nil {
+		return nil, err
+	}
+	if refs.enrollments, err = s.loadSet(ctx, "SELECT student_id || '/' || course_id FROM ENROLLS_IN"); err != nil {
+		return nil, err
+	}
+	if refs.schemes, err = s.loadSet(ctx, "SELECT DISTINCT course_id FROM ASSESSMENT_COMPONENT"); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, "SELECT LOWER(s_email), student_id FROM STUDENT")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var email, id string
+		if err := rows.Scan(&email, &id); err != nil {
+			return nil, err
+		}
+		refs.emails[email] = id
+	}
+	return refs, rows.Err()
+}
+
+// parseCSV reads a CSV with a header row. Columns are matched by name so their order does not matter.
+func parseCSV(spec datasetSpec, r io.Reader) ([]importRow, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, []ImportRowError{{Row: 1, Message: "file is empty"}}, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	known := make(map[string]bool)
+	for _, col := range spec.columns {
+		known[col] = true
+	}
+	index := make(map[string]int)
+	var headerErrs []ImportRowError
+	for i, col := range header {
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")))
+		if !known[name] {
+			headerErrs = append(headerErrs, ImportRowError{Row: 1, Column: name, Message: "unknown column"})
+			continue
+		}
+		if _, dup := index[name]; dup {
+			headerErrs = append(headerErrs, ImportRowError{Row: 1, Column: name, Message: "duplicate column"})
+			continue
+		}
+		index[name] = i
+	}
+	for _, col := range spec.required {
+		if _, ok := index[col]; !ok {
+			headerErrs = append(headerErrs, ImportRowError{Row: 1, Column: col, Message: "required column missing"})
+		}
+	}
+	if len(headerErrs) > 0 {
+		return nil, headerErrs, nil
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			row := 0
+			if errors.As(err, &parseErr) {
+				row = parseErr.StartLine
+			}
+			return nil, []ImportRowError{{Row: row, Message: "malformed CSV: " + err.Error()}}, nil
+		}
+		line, _ := reader.FieldPos(0)
+		if len(rows) >= MaxImportRows {
+			return nil, []ImportRowError{{Row: line, Message: fmt.Sprintf("too many rows (max %d)", MaxImportRows)}}, nil
+		}
+
+		fields := make(map[string]string, len(index))
+		empty := true
+		for col, i := range index {
+			if i < len(record) {
+				fields[col] = record[i]
+				if strings.TrimSpace(record[i]) != "" {
+					empty = false
+				}
+			}
+		}
+		if empty {
+			continue // Skip blank lines spreadsheets like to leave at the end
+		}
+		rows = append(rows, importRow{line: line, fields: fields})
+	}
+	return rows, nil, nil
+}
+
+// Import validates every row of a CSV and, if all rows are valid, applies them in a
+// single transaction. With dryRun the transaction is rolled back after applying, so
+// database-level problems are reported too without changing anything.
+func (s *ImportService) Import(ctx context.Context, actorID, dataset string, r io.Reader, dryRun bool) (*ImportReport, error) {
+	spec, ok := datasets[dataset]
+	if !ok {
+		return nil, ErrUnknownDataset
+	}
+	report := &ImportReport{Dataset: dataset, DryRun: dryRun, Errors: []ImportRowError{}}
+
+	rows, parseErrs, err := parseCSV(spec, r)
+	if err != nil {
+		return nil, err
+	}
+	if len(parseErrs) > 0 {
+		report.Errors = parseErrs
+		return report, nil
+	}
+	report.TotalRows = len(rows)
+
+	refs, err := s.loadRefs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading reference data: %w", err)
+	}
+
+	// Row-level validation, including duplicate keys within the file
+	seen := make(map[string]int)
+	for _, row := range rows {
+		rowErrs := checkRequired(spec, row)
+		if len(rowErrs) == 0 {
+			rowErrs = spec.validate(refs, row)
+		}
+		if key := spec.key(row); len(rowErrs) == 0 {
+			if first, dup := seen[key]; dup {
+				rowErrs = append(rowErrs, ImportRowError{Row: row.line, Message: fmt.Sprintf("duplicate key %s (first seen on row %d)", key, first)})
+			} else {
+				seen[key] = row.line
+			}
+		}
+		if len(rowErrs) == 0 {
+			report.ValidRows++
+		}
+		report.Errors = append(report.Errors, rowErrs...)
+	}
+	if len(report.Errors) > 0 {
+		return report, nil
+	}
+
+	// Apply everything in one transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, row := range rows {
+		if err := spec.apply(ctx, tx, actorID, row); err != nil {
+			report.ValidRows--
+			report.Errors = append(report.Errors, ImportRowError{Row: row.line, Message: translatePgError(err).Error()})
+			return report, nil
+		}
+	}
+	if dryRun {
+		return report, nil
+	}
+
+	summary := map[string]interface{}{"rows": len(rows)}
+	if err := writeAudit(ctx, tx, actorID, AuditImport, strings.ToUpper(dataset), "bulk", nil, summary); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, translatePgError(err)
+	}
+	report.Committed = true
+
+	s.invalidate(ctx, dataset, rows)
+	return report, nil
+}
+
+func (s *ImportService) invalidate(ctx context.Context, dataset string, rows []importRow) {
+	if dataset == DatasetGrades {
+		return // Imported grades are drafts; publishing clears the caches
+	}
+	if dataset == DatasetSchedules {
+		courses := make(map[string]bool)
+		var courseIDs []string
+		for _, row := range rows {
+			if id := row.get("course_id"); !courses[id] {
+				courses[id] = true
+				courseIDs = append(courseIDs, id)
+			}
+		}
+		invalidateCourses(ctx, s.db, s.rdb, courseIDs...)
+		return
+	}
+
+	var studentIDs []string
+	for _, row := range rows {
+		studentIDs = append(studentIDs, row.get("student_id"))
+	}
+	cache.InvalidateStudents(ctx, s.rdb, studentIDs...)
+	cache.InvalidateResponses(ctx, s.rdb)
+}
+
+func checkRequired(spec datasetSpec, row importRow) []ImportRowError {
+	var errs []ImportRowError
+	for _, col := range spec.required {
+		if row.get(col) == "" {
+			errs = append(errs, ImportRowError{Row: row.line, Column: col, Message: "is required"})
+		}
+	}
+	return errs
+}
+
+// fieldError converts a ValidationError from the shared validators into a row error.
+func fieldError(line int, err error) ImportRowError {
+	var vErr *ValidationError
+	if errors.As(err, &vErr) {
+		return ImportRowError{Row: line, Column: vErr.Field, Message: vErr.Message}
+	}
+	return ImportRowError{Row: line, Message: err.Error()}
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeDay accepts any capitalisation of a weekday name.
+func normalizeDay(day string) string {
+	for _, d := range ValidDays {
+		if strings.EqualFold(d, day) {
+			return d
+		}
+	}
+	return day
+}
+
+func parseBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "", "false", "no", "n", "0":
+		return false, nil
+	case "true", "yes", "y", "1":
+		return true, nil
+	}
+	return false, fmt.Errorf("invalid boolean %q", v)
+}
+
+// --- Students ---
+
+func validateStudentRow(refs *importRefs, r importRow) []ImportRowError {
+	var errs []ImportRowError
+	studentID := r.get("student_id")
+	for _, err := range []error{
+		requireID("student_id", studentID, 20),
+		requireText("first_name", r.get("first_name"), 50),
+		requireText("last_name", r.get("last_name"), 50),
+		validateEmail("email", r.get("email")),
+	} {
+		if err != nil {
+			errs = append(errs, fieldError(r.line, err))
+		}
+	}
+	if len(r.get("phone_no")) > 15 {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "phone_no", Message: "must be at most 15 characters"})
+	}
+	if sem, err := strconv.Atoi(r.get("semester")); err != nil || sem < 1 || sem > 8 {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "semester", Message: "must be a number between 1 and 8"})
+	}
+	maxYear := time.Now().Year() + 1
+	if year, err := strconv.Atoi(r.get("year_of_joining")); err != nil || year < 1990 || year > maxYear {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "year_of_joining", Message: fmt.Sprintf("must be a year between 1990 and %d", maxYear)})
+	}
+	if deptID := r.get("dept_id"); !refs.departments[deptID] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "dept_id", Message: fmt.Sprintf("unknown dept_id %q", deptID)})
+	}
+	if owner, ok := refs.emails[strings.ToLower(r.get("email"))]; ok && owner != studentID {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "email", Message: "already used by student " + owner})
+	} else if !ok {
+		// Reserve the email so a later row in the same file cannot reuse it
+		refs.emails[strings.ToLower(r.get("email"))] = studentID
+	}
+	return errs
+}
+
+func applyStudentRow(ctx context.Context, tx *sql.Tx, _ string, r importRow) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO STUDENT (student_id, s_first_name, s_last_name, s_email, s_phone_no, semester, year_of_joining, dept_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		ON CONFLICT (student_id) DO UPDATE SET
+			s_first_name = EXCLUDED.s_first_name,
+			s_last_name = EXCLUDED.s_last_name,
+			s_email = EXCLUDED.s_email,
+			s_phone_no = EXCLUDED.s_phone_no,
+			semester = EXCLUDED.semester,
+			year_of_joining = EXCLUDED.year_of_joining,
+			dept_id = EXCLUDED.dept_id
+	`, r.get("student_id"), r.get("first_name"), r.get("last_name"), r.get("email"), r.get("phone_no"),
+		r.get("semester"), r.get("year_of_joining"), r.get("dept_id"))
+	return err
+}
+
+// --- Enrollments ---
+
+func validateEnrollmentRow(refs *importRefs, r importRow) []ImportRowError {
+	var errs []ImportRowError
+	if id := r.get("student_id"); !refs.students[id] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "student_id", Message: fmt.Sprintf("unknown student_id %q", id)})
+	}
+	if id := r.get("course_id"); !refs.courses[id] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "course_id", Message: fmt.Sprintf("unknown course_id %q", id)})
+	}
+	if status := r.get("status"); status != "" && !contains(ValidEnrollmentStatuses, status) {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "status", Message: "must be one of " + strings.Join(ValidEnrollmentStatuses, ", ")})
+	}
+	if _, err := parseBool(r.get("backlog")); err != nil {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "backlog", Message: "must be true or false"})
+	}
+	return errs
+}
+
+func applyEnrollmentRow(ctx context.Context, tx *sql.Tx, _ string, r importRow) error {
+	status := r.get("status")
+	if status == "" {
+		status = "Enrolled"
+	}
+	backlog, _ := parseBool(r.get("backlog"))
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO ENROLLS_IN (student_id, course_id, status, backlog)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (student_id, course_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			backlog = EXCLUDED.backlog
+	`, r.get("student_id"), r.get("course_id"), status, backlog)
+	return err
+}
+
+// --- Schedules ---
+
+func validateScheduleRow(refs *importRefs, r importRow) []ImportRowError {
+	var errs []ImportRowError
+	if id := r.get("course_id"); !refs.courses[id] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "course_id", Message: fmt.Sprintf("unknown course_id %q", id)})
+	}
+	if name := r.get("section_name"); !refs.sections[name] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "section_name", Message: fmt.Sprintf("unknown section_name %q", name)})
+	}
+	if day := r.get("day_of_week"); !IsValidDay(normalizeDay(day)) {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "day_of_week", Message: fmt.Sprintf("bad day_of_week %q, must be one of %s", day, strings.Join(ValidDays, ", "))})
+	}
+	start, startErr := ParseClock(r.get("start_time"))
+	if startErr != nil {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "start_time", Message: "must be HH:MM"})
+	}
+	end, endErr := ParseClock(r.get("end_time"))
+	if endErr != nil {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "end_time", Message: "must be HH:MM"})
+	}
+	if startErr == nil && endErr == nil && !end.After(start) {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "end_time", Message: "must be after start_time"})
+	}
+	if len(r.get("room_number")) > 20 {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "room_number", Message: "must be at most 20 characters"})
+	}
+	return errs
+}
+
+// applyScheduleRow updates the slot with the same course, section, day and start
+// time if one exists, otherwise inserts a new slot.
+func applyScheduleRow(ctx context.Context, tx *sql.Tx, _ string, r importRow) error {
+	day := normalizeDay(r.get("day_of_week"))
+	res, err := tx.ExecContext(ctx, `
+		UPDATE SCHEDULE SET end_time=$5, room_number=NULLIF($6, '')
+		WHERE course_id=$1 AND section_name=$2 AND day_of_week=$3 AND start_time=$4
+	`, r.get("course_id"), r.get("section_name"), day, r.get("start_time"), r.get("end_time"), r.get("room_number"))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO SCHEDULE (course_id, section_name, day_of_week, start_time, end_time, room_number)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+	`, r.get("course_id"), r.get("section_name"), day, r.get("start_time"), r.get("end_time"), r.get("room_number"))
+	return err
+}
+
+// --- Grades ---
+
+func validateGradeRow(refs *importRefs, r importRow) []ImportRowError {
+	var errs []ImportRowError
+	studentID, courseID := r.get("student_id"), r.get("course_id")
+	if !refs.students[studentID] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "student_id", Message: fmt.Sprintf("unknown student_id %q", studentID)})
+	}
+	if !refs.courses[courseID] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "course_id", Message: fmt.Sprintf("unknown course_id %q", courseID)})
+	}
+	if len(errs) == 0 && !refs.enrollments[studentID+"/"+courseID] {
+		errs = append(errs, ImportRowError{Row: r.line, Message: fmt.Sprintf("%s is not enrolled in %s", studentID, courseID)})
+	}
+	if refs.schemes[courseID] {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "course_id", Message: fmt.Sprintf("%s has an assessment scheme; its grades come from marks", courseID)})
+	}
+	if grade := strings.ToUpper(r.get("grade")); !contains(ValidGrades, grade) {
+		errs = append(errs, ImportRowError{Row: r.line, Column: "grade", Message: "must be one of " + strings.Join(ValidGrades, ", ")})
+	}
+	return errs
+}
+
+// applyGradeRow stores an imported grade as a draft, like a computed one: students see
+// it once a teacher publishes it.
+func applyGradeRow(ctx context.Context, tx *sql.Tx, actorID string, r importRow) error {
+	studentID, courseID, grade := r.get("student_id"), r.get("course_id"), strings.ToUpper(r.get("grade"))
+
+	var oldGrade, oldState sql.NullString
+	err := tx.QueryRowContext(ctx,
+		"SELECT grade, state FROM COURSE_GRADE WHERE student_id=$1 AND course_id=$2",
+		studentID, courseID).Scan(&oldGrade, &oldState)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if oldGrade.Valid && oldGrade.String == grade {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO COURSE_GRADE (student_id, course_id, total, grade, state, computed_at)
+		VALUES ($1, $2, NULL, $3, 'draft', CURRENT_TIMESTAMP)
+		ON CONFLICT (student_id, course_id) DO UPDATE SET
+			total = NULL, grade = EXCLUDED.grade, state = 'draft', computed_at = EXCLUDED.computed_at
+	`, studentID, courseID, grade); err != nil {
+		return err
+	}
+
+	var before interface{}
+	if oldGrade.Valid {
+		before = map[string]interface{}{"grade": oldGrade.String, "state": oldState.String}
+	}
+	return writeGradeAudit(ctx, tx, actorID, GradeAuditImport, courseID, studentID, before, map[string]interface{}{"grade": grade})
+}
+
+// --- Export ---
+
+// Export returns the header and rows of a dataset, in the same column layout Import accepts.
+func (s *ImportService) Export(ctx context.Context, dataset string) ([]string, [][]string, error) {
+	spec, ok := datasets[dataset]
+	if !ok {
+		return nil, nil, ErrUnknownDataset
+	}
+
+	rows, err := s.db.QueryContext(ctx, spec.export)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var records [][]string
+	for rows.Next() {
+		record := make([]string, len(spec.columns))
+		dest := make([]interface{}, len(record))
+		for i := range record {
+			dest[i] = &record[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, err
+		}
+		records = append(records, record)
+	}
+	return spec.columns, records, rows.Err()
+}
+
+// WriteCSV writes a header and rows as CSV.
+func WriteCSV(w io.Writer, header []string, records [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(records); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// WriteXLSX writes a header and rows as a single-sheet Excel workbook.
+func WriteXLSX(w io.Writer, sheet string, header []string, records [][]string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	writeRow := func(rowNo int, values []string) error {
+		cell, err := excelize.CoordinatesToCellName(1, rowNo)
+		if err != nil {
+			return err
+		}
+		row := make([]interface{}, len(values))
+		for i, v := range values {
+			row[i] = v
+		}
+		return f.SetSheetRow(sheet, cell, &row)
+	}
+
+	if err := writeRow(1, header); err != nil {
+		return err
+	}
+	for i, record := range records {
+		if err := writeRow(i+2, record); err != nil {
+			return err
+		}
+	}
+	return f.Write(w)
+}
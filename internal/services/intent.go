@@ -0,0 +1,213 @@
+package services
+
+import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/config"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chat intents (ChatLogs.intent on user messages)
+const (
+	IntentSchedule      = "schedule"
+	IntentGrades        = "grades"
+	IntentCourseContent = "course_content"
+	IntentQuizRequest   = "quiz_request"
+	IntentWellbeing     = "wellbeing"
+	IntentAdmin         = "admin"
+	IntentGreeting      = "greeting"
+	IntentGeneral       = "general"
+)
+
+var ValidIntents = []string{IntentSchedule, IntentGrades, IntentCourseContent, IntentQuizRequest, IntentWellbeing, IntentAdmin, IntentGreeting, IntentGeneral}
+
+// Classification sources
+const (
+	IntentByRules = "rules"
+	IntentByModel = "model"
+)
+
+// Classification labels a chat message. Topic is the course it concerns (ID) when one
+// is mentioned, otherwise a short phrase or the intent itself.
+type Classification struct {
+	Intent     string  `json:"intent"`
+	Topic      string  `json:"topic"`
+	Confidence float64 `json:"confidence"`
+	Source     string  `json:"source"`
+}
+
+// Schedule and grade words also name course concepts ("a conflict serializable
+// schedule", "a z-score", "the results of Dijkstra's algorithm"). A message is a lookup
+// of the user's own records only when it names something only they have (timetable,
+// next class, CGPA) or asks in the first person.
+var (
+	scheduleReference = regexp.MustCompile(`(?i)\b(time ?table|next class|(classes|class|lectures?) (today|tomorrow|on \w+day|now)|which room|what room|free period|when is (my|the) \w+ (class|lecture|lab))\b`)
+	gradeReference    = regexp.MustCompile(`(?i)\b(cgpa|sgpa|gpa|did i pass|backlogs?)\b`)
+	firstPerson       = regexp.MustCompile(`(?i)\b(my|mine|(am|did|do|have|will) i|i (got|get|scored|have))\b`)
+)
+
+// personalLookup reports whether the message asks about the user's own timetable or
+// grades rather than a course concept.
+func personalLookup(message string) bool {
+	return firstPerson.MatchString(message) || scheduleReference.MatchString(message) || gradeReference.MatchString(message)
+}
+
+// intentRules are tried in order; the first match wins. Wellbeing comes first so that
+// "I'm scared I'll fail" is not read as a grades question. Personal rules only match
+// first-person questions, so other uses of their words fall through to course content.
+var intentRules = []struct {
+	intent   string
+	pattern  *regexp.Regexp
+	personal bool
+}{
+	{IntentWellbeing, regexp.MustCompile(`(?i)\b(stress(ed|ful)?|anxious|anxiety|depress(ed|ion|ing)?|overwhelm(ed|ing)?|lonely|burn(ed|t)? ?out|can'?t cope|give up|hopeless|panic(king)?|scared|afraid|worried|sad|exhausted|mental health)\b`), false},
+	{IntentQuizRequest, regexp.MustCompile(`(?i)\b(quiz( me)?|test me|practice questions?|mcqs?|mock (test|exam)|flash ?cards?)\b`), false},
+	{IntentSchedule, scheduleReference, false},
+	{IntentSchedule, regexp.MustCompile(`(?i)\bschedules?\b`), true},
+	{IntentGrades, gradeReference, false},
+	{IntentGrades, regexp.MustCompile(`(?i)\b(grades?|marks?|scores?|results?)\b`), true},
+	{IntentAdmin, regexp.MustCompile(`(?i)\b(fees?|registration|register for|enrol+(ment)?|add/drop|drop (a|the|this) course|leave application|certificate|transcript|hostel|scholarship|exam form|hall ticket|admit card|deadline|contact (my|the) (teacher|professor|faculty))\b`), false},
+	{IntentGreeting, regexp.MustCompile(`(?i)^\s*(hi|hii+|hello|hey|good (morning|afternoon|evening)|thanks|thank you|ok(ay)?|bye)\b[\s!.]*$`), false},
+	{IntentCourseContent, regexp.MustCompile(`(?i)\b(explain|what is|what are|define|definition|difference between|how does|how do|example of|derive|prove|unit \d|syllabus|concept|algorithm|theorem|formula)\b`), false},
+}
+
+// topicPhrase captures the subject of a content question ("explain deadlock in OS").
+var topicPhrase = regexp.MustCompile(`(?i)\b(?:explain|what (?:is|are)|define|definition of|difference between|how does|how do|example of)\s+(?:an?\s+|the\s+)?([^?.!,]{2,60})`)
+
+// adviceWords mark questions that need reasoning, not just a lookup.
+var adviceWords = regexp.MustCompile(`(?i)\b(why|how (can|should|do|could) i|should i|help me|plan|improve|tips?|advice|suggest|compare|better|worse|raise|bring up)\b`)
+
+type IntentClassifier struct {
+	llm      ai.ChatClient // Small model for messages no rule matches; nil disables the fallback
+	minScore float64
+}
+
+// NewIntentClassifier uses cfg.IntentModel (default: cfg.Model) for the fallback
+// model; "off" disables it.
+func NewIntentClassifier(cfg config.LLM) *IntentClassifier {
+	c := &IntentClassifier{minScore: 0.5}
+	if model := cfg.IntentModel; model != "off" {
+		if model == "" {
+			model = cfg.Model
+		}
+		c.llm = ai.NewChatClientWithModel(cfg, model)
+	}
+	return c
+}
+
+// Classify labels the message. courses maps the user's course IDs to titles and is
+// used to find the topic.
+func (c *IntentClassifier) Classify(ctx context.Context, message string, courses map[string]string) Classification {
+	topic := mentionedCourse(message, courses)
+
+	for _, rule := range intentRules {
+		if rule.personal && !firstPerson.MatchString(message) {
+			continue
+		}
+		if rule.pattern.MatchString(message) {
+			if topic == "" {
+				topic = topicFor(rule.intent, message)
+			}
+			return Classification{Intent: rule.intent, Topic: topic, Confidence: 0.9, Source: IntentByRules}
+		}
+	}
+
+	if c.llm != nil {
+		if cl, ok := c.classifyWithModel(ctx, message); ok {
+			if topic != "" {
+				cl.Topic = topic
+			}
+			return cl
+		}
+	}
+
+	if topic == "" {
+		topic = IntentGeneral
+	}
+	return Classification{Intent: IntentGeneral, Topic: topic, Confidence: 0.3, Source: IntentByRules}
+}
+
+const intentPrompt = `Classify the student's message for an academic assistant.
+Reply with JSON only: {"intent": "<intent>", "topic": "<2-5 word topic>", "confidence": <0 to 1>}
+Intents: schedule (timetable, classes, rooms), grades (marks, CGPA, results), course_content (explaining course material),
+quiz_request (wants practice questions), wellbeing (stress, emotions), admin (fees, registration, certificates, deadlines),
+greeting (small talk), general (anything else).`
+
+func (c *IntentClassifier) classifyWithModel(ctx context.Context, message string) (Classification, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	turn, err := c.llm.Chat(ctx, ai.ChatRequest{Agent: "intent", Messages: []ai.Message{
+		{Role: "system", Content: intentPrompt},
+		{Role: "user", Content: message},
+	}})
+	if err != nil {
+		slog.WarnContext(ctx, "Intent model failed", "error", err)
+		return Classification{}, false
+	}
+
+	content := turn.Content
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return Classification{}, false
+	}
+	var out struct {
+		Intent     string      `json:"intent"`
+		Topic      string      `json:"topic"`
+		Confidence json.Number `json:"confidence"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &out); err != nil || !contains(ValidIntents, out.Intent) {
+		return Classification{}, false
+	}
+	confidence, _ := strconv.ParseFloat(out.Confidence.String(), 64)
+	if confidence < c.minScore {
+		return Classification{}, false
+	}
+	topic := strings.ToLower(strings.TrimSpace(out.Topic))
+	if topic == "" {
+		topic = out.Intent
+	}
+	return Classification{Intent: out.Intent, Topic: truncateTopic(topic), Confidence: confidence, Source: IntentByModel}, true
+}
+
+// mentionedCourse returns the ID of the first of the user's courses the message names
+// by ID or title.
+func mentionedCourse(message string, courses map[string]string) string {
+	lower := strings.ToLower(message)
+	for id, title := range courses {
+		if strings.Contains(lower, strings.ToLower(id)) || (len(title) > 3 && strings.Contains(lower, strings.ToLower(title))) {
+			return id
+		}
+	}
+	return ""
+}
+
+func topicFor(intent, message string) string {
+	if intent == IntentCourseContent || intent == IntentQuizRequest {
+		if m := topicPhrase.FindStringSubmatch(message); m != nil {
+			return truncateTopic(strings.ToLower(strings.TrimSpace(m[1])))
+		}
+	}
+	return intent
+}
+
+func truncateTopic(topic string) string {
+	if r := []rune(topic); len(r) > 60 {
+		topic = string(r[:60])
+	}
+	return topic
+}
+
+// IsLookup reports whether a classified message is a plain lookup of the user's own
+// records that can be answered without the model.
+func IsLookup(cl Classification, message string) bool {
+	if cl.Source != IntentByRules || adviceWords.MatchString(message) || !personalLookup(message) {
+		return false
+	}
+	return cl.Intent == IntentSchedule || cl.Intent == IntentGrades
+}
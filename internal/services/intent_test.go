@@ -0,0 +1,93 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"strings"
+	"testing"
+)
+
+var lookupCourses = map[string]string{"CS101": "Data Structures", "CS305": "Database Systems"}
+
+func TestClassifyRules(t *testing.T) {
+	c := &IntentClassifier{} // Rules only
+	tests := []struct {
+		message string
+		intent  string
+		lookup  bool
+	}{
+		{"What's my next class?", IntentSchedule, true},
+		{"Show me my timetable", IntentSchedule, true},
+		{"classes tomorrow", IntentSchedule, true},
+		{"What is my schedule on Friday?", IntentSchedule, true},
+		{"What is my CGPA?", IntentGrades, true},
+		{"What grade did I get in CS101?", IntentGrades, true},
+		{"Show my marks", IntentGrades, true},
+		{"How can I improve my grades?", IntentGrades, false},
+
+		// Course concepts that share schedule and grade words
+		{"What is a conflict serializable schedule?", IntentCourseContent, false},
+		{"What is a z-score?", IntentCourseContent, false},
+		{"Explain the results of Dijkstra's algorithm", IntentCourseContent, false},
+		{"Define the marks of a Petri net", IntentCourseContent, false},
+
+		{"I'm worried about my grades", IntentWellbeing, false},
+		{"Quiz me on normalization", IntentQuizRequest, false},
+		{"hello", IntentGreeting, false},
+		{"Tell me a joke", IntentGeneral, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			cl := c.Classify(context.Background(), tt.message, lookupCourses)
+			if cl.Intent != tt.intent {
+				t.Errorf("intent = %s, want %s", cl.Intent, tt.intent)
+			}
+			if got := IsLookup(cl, tt.message); got != tt.lookup {
+				t.Errorf("IsLookup = %v, want %v", got, tt.lookup)
+			}
+		})
+	}
+}
+
+func TestAnswerLookup(t *testing.T) {
+	total := 82.5
+	brief := &userBrief{
+		courses:   lookupCourses,
+		timetable: []models.ScheduleItem{{CourseID: "CS305", Title: "Database Systems", DayOfWeek: "Monday", StartTime: "09:00", EndTime: "10:00", RoomNumber: "LH-2"}},
+		grades: []models.StudentGrade{
+			{CourseID: "CS101", Title: "Data Structures", Credits: 4, Grade: "A", Total: &total},
+		},
+	}
+	tests := []struct {
+		name    string
+		cl      Classification
+		message string
+		role    string
+		want    string // Substring of the answer; "" when the model must answer
+	}{
+		{"own grade", Classification{Intent: IntentGrades, Topic: "CS101"}, "What is my grade in CS101?", "student", "Your grade in Data Structures is **A** (82.5/100)"},
+		{"cgpa", Classification{Intent: IntentGrades, Topic: IntentGrades}, "cgpa?", "student", "Your CGPA is **9.00** over 4 credits"},
+		{"timetable", Classification{Intent: IntentSchedule, Topic: IntentSchedule}, "show my timetable", "student", "**Monday**\n- 09:00-10:00: Database Systems (LH-2)"},
+		{"teacher grades", Classification{Intent: IntentGrades, Topic: "CS101"}, "What are my students' grades in CS101?", "teacher", ""},
+
+		// Misclassified course concepts still go to the model
+		{"serializable schedule", Classification{Intent: IntentSchedule, Topic: "CS305"}, "What is a conflict serializable schedule in CS305?", "student", ""},
+		{"z-score", Classification{Intent: IntentGrades, Topic: IntentGrades}, "What is a z-score?", "student", ""},
+		{"dijkstra results", Classification{Intent: IntentGrades, Topic: IntentGrades}, "Explain the results of Dijkstra's algorithm", "student", ""},
+		{"other intent", Classification{Intent: IntentCourseContent}, "What is my favourite algorithm?", "student", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			answer, ok := answerLookup(tt.cl, tt.message, tt.role, brief)
+			if tt.want == "" {
+				if ok {
+					t.Errorf("answered %q, want the model", answer)
+				}
+				return
+			}
+			if !ok || !strings.Contains(answer, tt.want) {
+				t.Errorf("answer = %q (%v), want it to contain %q", answer, ok, tt.want)
+			}
+		})
+	}
+}
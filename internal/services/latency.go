@@ -0,0 +1,53 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyWindow keeps the most recent durations of one stage, for percentiles.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	total   int // Observed since start, including samples since overwritten
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, 0, size)}
+}
+
+// Observe records d and returns how many durations have been observed.
+func (w *latencyWindow) Observe(d time.Duration) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, d)
+	} else {
+		w.samples[w.next] = d
+		w.next = (w.next + 1) % len(w.samples)
+	}
+	w.total++
+	return w.total
+}
+
+// Percentile returns the p-th percentile (0 < p <= 100) of the window, or 0 when
+// nothing has been observed.
+func (w *latencyWindow) Percentile(p float64) time.Duration {
+	w.mu.Lock()
+	sorted := append([]time.Duration(nil), w.samples...)
+	w.mu.Unlock()
+	if len(sorted) == 0 {
+		return 0
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
@@ -0,0 +1,470 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Persona sources (AgentPersona.source)
+const (
+	PersonaBuiltin = "builtin"
+	PersonaCustom  = "custom"
+)
+
+const (
+	DefaultAgentID       = "general"
+	defaultRetrievalTopK = 3
+	maxRetrievalTopK     = 10 // Mirrors the CHECK constraint on AGENT_PERSONA.retrieval_top_k
+	maxSystemPrompt      = 8000
+)
+
+// PersonaRoles are the roles that chat with agents
+var PersonaRoles = []string{"student", "teacher"}
+
+var agentIDPattern = regexp.MustCompile(`^[a-z0-9_-]{2,50}$`)
+
+//go:embed personas/*.yaml
+var personaFiles embed.FS
+
+// builtinPersonas are the personas shipped in personas/*.yaml, by ID; personaAliases
+// maps alternative IDs (e.g. "coach") to them.
+var builtinPersonas, personaAliases = mustLoadPersonas()
+
+func mustLoadPersonas() (map[string]models.AgentPersona, map[string]string) {
+	personas := map[string]models.AgentPersona{}
+	aliases := map[string]string{}
+	files, err := fs.Glob(personaFiles, "personas/*.yaml")
+	if err != nil {
+		panic(err)
+	}
+	for _, file := range files {
+		data, err := personaFiles.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		var p models.AgentPersona
+		if err := yaml.Unmarshal(data, &p); err != nil {
+			panic(fmt.Sprintf("parsing %s: %v", file, err))
+		}
+		if p.Version == 0 {
+			p.Version = 1
+		}
+		p.Active, p.Source = true, PersonaBuiltin
+		p.SystemPrompt = strings.TrimSpace(p.SystemPrompt)
+		if err := validatePersona(&p); err != nil {
+			panic(fmt.Sprintf("%s: %v", file, err))
+		}
+		personas[p.ID] = p
+		for _, alias := range p.Aliases {
+			aliases[alias] = p.ID
+		}
+	}
+	return personas, aliases
+}
+
+type PersonaService struct {
+	db          *sql.DB
+	enrollments repository.EnrollmentRepository
+	faculty     repository.FacultyRepository
+}
+
+func NewPersonaService(db *sql.DB, enrollments repository.EnrollmentRepository, faculty repository.FacultyRepository) *PersonaService {
+	return &PersonaService{
+		db:          db,
+		enrollments: enrollments,
+		faculty:     faculty,
+	}
+}
+
+// validatePersona checks a definition and fills in retrieval and tool defaults.
+func validatePersona(p *models.AgentPersona) error {
+	if !agentIDPattern.MatchString(p.ID) {
+		return &ValidationError{Field: "id", Message: "must be 2-50 lowercase letters, digits, '_' or '-'"}
+	}
+	if err := requireText("name", p.Name, 100); err != nil {
+		return err
+	}
+	if err := requireText("system_prompt", p.SystemPrompt, maxSystemPrompt); err != nil {
+		return err
+	}
+	if len(p.Roles) == 0 {
+		return &ValidationError{Field: "roles", Message: "is required"}
+	}
+	for _, role := range p.Roles {
+		if !contains(PersonaRoles, role) {
+			return &ValidationError{Field: "roles", Message: "must be one of " + strings.Join(PersonaRoles, ", ")}
+		}
+	}
+	for _, tool := range p.Tools {
+		if findTool(tool) == nil {
+			return &ValidationError{Field: "tools", Message: fmt.Sprintf("unknown tool %q", tool)}
+		}
+	}
+	if p.Tools == nil {
+		p.Tools = []string{}
+	}
+	if p.Retrieval.TopK == 0 {
+		p.Retrieval.TopK = defaultRetrievalTopK
+	}
+	if p.Retrieval.TopK < 1 || p.Retrieval.TopK > maxRetrievalTopK {
+		return &ValidationError{Field: "retrieval.top_k", Message: fmt.Sprintf("must be between 1 and %d", maxRetrievalTopK)}
+	}
+	if t := p.Temperature; t != nil && (*t < 0 || *t > 2) {
+		return &ValidationError{Field: "temperature", Message: "must be between 0 and 2"}
+	}
+	if len(p.Model) > 100 {
+		return &ValidationError{Field: "model", Message: "must be at most 100 characters"}
+	}
+	return nil
+}
+
+// personaAllows reports whether a caller with the role and courses may chat with the persona.
+func personaAllows(p models.AgentPersona, role string, courseIDs []string) bool {
+	if !p.Active || !contains(p.Roles, role) {
+		return false
+	}
+	return p.CourseID == "" || contains(courseIDs, p.CourseID)
+}
+
+// storedPersonas returns the latest version of every AGENT_PERSONA agent matching the
+// filter, which is applied after the latest version is picked.
+func (s *PersonaService) storedPersonas(ctx context.Context, filter string, args ...interface{}) ([]models.AgentPersona, error) {
+	query := `
+		SELECT agent_id, version, name, description, system_prompt, roles, course_id, retrieval_enabled,
+			retrieval_top_k, temperature, model, tools, active, created_by, created_at
+		FROM (
+			SELECT DISTINCT ON (agent_id) agent_id, version, name, COALESCE(description, '') AS description,
+				system_prompt, array_to_string(allowed_roles, ',') AS roles, COALESCE(course_id, '') AS course_id,
+				retrieval_enabled, retrieval_top_k, temperature, COALESCE(model, '') AS model,
+				array_to_string(tools, ',') AS tools, active, created_by, created_at
+			FROM AGENT_PERSONA
+			ORDER BY agent_id, version DESC
+		) p`
+	if filter != "" {
+		query += " WHERE " + filter
+	}
+	return s.scanPersonas(ctx, query, args...)
+}
+
+func (s *PersonaService) scanPersonas(ctx context.Context, query string, args ...interface{}) ([]models.AgentPersona, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	personas := make([]models.AgentPersona, 0)
+	for rows.Next() {
+		var p models.AgentPersona
+		var roles, tools string
+		var temperature sql.NullFloat64
+		var createdAt time.Time
+		if err := rows.Scan(&p.ID, &p.Version, &p.Name, &p.Description, &p.SystemPrompt, &roles, &p.CourseID,
+			&p.Retrieval.Enabled, &p.Retrieval.TopK, &temperature, &p.Model, &tools, &p.Active, &p.CreatedBy, &createdAt); err != nil {
+			return nil, err
+		}
+		p.Roles = splitList(roles)
+		p.Tools = splitList(tools)
+		if temperature.Valid {
+			p.Temperature = &temperature.Float64
+		}
+		p.CreatedAt = &createdAt
+		p.Source = PersonaCustom
+		personas = append(personas, p)
+	}
+	return personas, rows.Err()
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// all merges the built-in personas with the stored ones; a stored version of a
+// built-in agent replaces it.
+func (s *PersonaService) all(ctx context.Context) ([]models.AgentPersona, error) {
+	stored, err := s.storedPersonas(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]models.AgentPersona{}
+	for id, p := range builtinPersonas {
+		byID[id] = p
+	}
+	for _, p := range stored {
+		byID[p.ID] = p
+	}
+	personas := make([]models.AgentPersona, 0, len(byID))
+	for _, p := range byID {
+		personas = append(personas, p)
+	}
+	sortPersonas(personas)
+	return personas, nil
+}
+
+// sortPersonas puts the default agent first, then shared agents before course-specific
+// ones, by name.
+func sortPersonas(personas []models.AgentPersona) {
+	sort.Slice(personas, func(i, j int) bool {
+		a, b := personas[i], personas[j]
+		if (a.ID == DefaultAgentID) != (b.ID == DefaultAgentID) {
+			return a.ID == DefaultAgentID
+		}
+		if a.CourseID != b.CourseID {
+			return a.CourseID < b.CourseID
+		}
+		return a.Name < b.Name
+	})
+}
+
+// get returns the current version of an agent, resolving aliases.
+func (s *PersonaService) get(ctx context.Context, agentID string) (*models.AgentPersona, error) {
+	if canonical, ok := personaAliases[agentID]; ok {
+		agentID = canonical
+	}
+	stored, err := s.storedPersonas(ctx, "agent_id=$1", agentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stored) > 0 {
+		return &stored[0], nil
+	}
+	if p, ok := builtinPersonas[agentID]; ok {
+		return &p, nil
+	}
+	return nil, ErrNotFound
+}
+
+// userCourseIDs returns a student's enrolled or a teacher's taught courses.
+func (s *PersonaService) userCourseIDs(ctx context.Context, userID, role string) ([]string, error) {
+	var courseIDs []string
+	if role == "teacher" {
+		taught, err := s.faculty.Courses(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, tc := range taught {
+			if len(courseIDs) == 0 || courseIDs[len(courseIDs)-1] != tc.CourseID {
+				courseIDs = append(courseIDs, tc.CourseID) // Sections of a course are adjacent
+			}
+		}
+		return courseIDs, nil
+	}
+	enrolled, err := s.enrollments.Current(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range enrolled {
+		courseIDs = append(courseIDs, c.ID)
+	}
+	return courseIDs, nil
+}
+
+// List returns the agents the user may chat with: shared agents for their role and
+// the personas of their enrolled (student) or taught (teacher) courses.
+func (s *PersonaService) List(ctx context.Context, userID, role string) ([]models.AgentPersona, error) {
+	courseIDs, err := s.userCourseIDs(ctx, userID, role)
+	if err != nil {
+		return nil, err
+	}
+	personas, err := s.all(ctx)
+	if err != nil {
+		return nil, err
+	}
+	available := make([]models.AgentPersona, 0, len(personas))
+	for _, p := range personas {
+		if personaAllows(p, role, courseIDs) {
+			available = append(available, p)
+		}
+	}
+	return available, nil
+}
+
+// ListAll returns the current version of every agent, including inactive ones.
+func (s *PersonaService) ListAll(ctx context.Context) ([]models.AgentPersona, error) {
+	return s.all(ctx)
+}
+
+// Resolve returns the persona to answer a chat with. An empty agent ID selects the
+// default agent. If AGENT_PERSONA cannot be read the built-in personas are used.
+func (s *PersonaService) Resolve(ctx context.Context, agentID, role string, courseIDs []string) (*models.AgentPersona, error) {
+	agentID = strings.ToLower(strings.TrimSpace(agentID))
+	if agentID == "" {
+		agentID = DefaultAgentID
+	}
+	p, err := s.get(ctx, agentID)
+	if err != nil && err != ErrNotFound {
+		slog.WarnContext(ctx, "Loading stored personas failed, using built-ins", "error", err)
+		if builtin, ok := builtinPersonas[agentID]; ok {
+			p, err = &builtin, nil
+		} else if canonical, ok := personaAliases[agentID]; ok {
+			builtin := builtinPersonas[canonical]
+			p, err = &builtin, nil
+		}
+	}
+	if err != nil || !personaAllows(*p, role, courseIDs) {
+		return nil, &ValidationError{Field: "agent_id", Message: fmt.Sprintf("agent %q is not available", agentID)}
+	}
+	return p, nil
+}
+
+// Versions returns every version of an agent, newest first; the built-in definition
+// (if any) comes last.
+func (s *PersonaService) Versions(ctx context.Context, agentID string) ([]models.AgentPersona, error) {
+	if canonical, ok := personaAliases[agentID]; ok {
+		agentID = canonical
+	}
+	versions, err := s.scanPersonas(ctx, `
+		SELECT agent_id, version, name, COALESCE(description, ''), system_prompt, array_to_string(allowed_roles, ','),
+			COALESCE(course_id, ''), retrieval_enabled, retrieval_top_k, temperature, COALESCE(model, ''),
+			array_to_string(tools, ','), active, created_by, created_at
+		FROM AGENT_PERSONA WHERE agent_id=$1 ORDER BY version DESC
+	`, agentID)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := builtinPersonas[agentID]; ok {
+		versions = append(versions, p)
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return versions, nil
+}
+
+// Create adds a new agent at version 1.
+func (s *PersonaService) Create(ctx context.Context, actorID string, p models.AgentPersona) (*models.AgentPersona, error) {
+	p.Aliases = nil
+	if err := validatePersona(&p); err != nil {
+		return nil, err
+	}
+	if _, err := s.get(ctx, p.ID); err == nil {
+		return nil, fmt.Errorf("%w: agent %s", ErrDuplicate, p.ID)
+	} else if err != ErrNotFound {
+		return nil, err
+	}
+	p.Version = 1
+	if err := s.insert(ctx, actorID, AuditCreate, nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Update stores the definition as the agent's next version. The course an agent
+// belongs to cannot change.
+func (s *PersonaService) Update(ctx context.Context, actorID string, p models.AgentPersona) (*models.AgentPersona, error) {
+	current, err := s.get(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.update(ctx, actorID, current, p)
+}
+
+func (s *PersonaService) update(ctx context.Context, actorID string, current *models.AgentPersona, p models.AgentPersona) (*models.AgentPersona, error) {
+	p.ID, p.CourseID = current.ID, current.CourseID
+	p.Aliases = nil
+	if err := validatePersona(&p); err != nil {
+		return nil, err
+	}
+	p.Version = current.Version + 1
+	if err := s.insert(ctx, actorID, AuditUpdate, current, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// insert writes a persona version and its audit entry. A concurrent edit of the same
+// agent fails with ErrDuplicate.
+func (s *PersonaService) insert(ctx context.Context, actorID, action string, before, p *models.AgentPersona) error {
+	p.Source, p.CreatedBy = PersonaCustom, actorID
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var createdAt time.Time
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO AGENT_PERSONA (agent_id, version, name, description, system_prompt, allowed_roles, course_id,
+				retrieval_enabled, retrieval_top_k, temperature, model, tools, active, created_by)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6::text[], NULLIF($7, ''), $8, $9, $10, NULLIF($11, ''), $12::text[], $13, $14)
+			RETURNING created_at
+		`, p.ID, p.Version, p.Name, p.Description, p.SystemPrompt, p.Roles, p.CourseID,
+			p.Retrieval.Enabled, p.Retrieval.TopK, p.Temperature, p.Model, p.Tools, p.Active, actorID).Scan(&createdAt); err != nil {
+			return err
+		}
+		p.CreatedAt = &createdAt
+		return writeAudit(ctx, tx, actorID, action, "AGENT_PERSONA", fmt.Sprintf("%s@%d", p.ID, p.Version), before, p)
+	})
+}
+
+// --- Course personas (teachers) ---
+
+// validateCoursePersona applies the limits on teacher-defined personas: they are for
+// the course's students and/or teachers and run on the default model.
+func validateCoursePersona(p models.AgentPersona) error {
+	if p.Model != "" {
+		return &ValidationError{Field: "model", Message: "can only be set by an administrator"}
+	}
+	return nil
+}
+
+// CoursePersonas returns the current version of each persona defined for the course,
+// including inactive ones.
+func (s *PersonaService) CoursePersonas(ctx context.Context, facultyID, courseID string) ([]models.AgentPersona, error) {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	personas, err := s.storedPersonas(ctx, "course_id=$1", courseID)
+	if err != nil {
+		return nil, err
+	}
+	sortPersonas(personas)
+	return personas, nil
+}
+
+// CreateCoursePersona adds a persona that only the course's students (and teachers,
+// if allowed) can chat with. Roles default to students.
+func (s *PersonaService) CreateCoursePersona(ctx context.Context, facultyID, courseID string, p models.AgentPersona) (*models.AgentPersona, error) {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	if err := validateCoursePersona(p); err != nil {
+		return nil, err
+	}
+	if len(p.Roles) == 0 {
+		p.Roles = []string{"student"}
+	}
+	p.CourseID = courseID
+	return s.Create(ctx, facultyID, p)
+}
+
+// UpdateCoursePersona stores the next version of one of the course's personas.
+func (s *PersonaService) UpdateCoursePersona(ctx context.Context, facultyID, courseID string, p models.AgentPersona) (*models.AgentPersona, error) {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	if err := validateCoursePersona(p); err != nil {
+		return nil, err
+	}
+	current, err := s.get(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current.CourseID != courseID {
+		return nil, ErrNotFound
+	}
+	if len(p.Roles) == 0 {
+		p.Roles = current.Roles
+	}
+	return s.update(ctx, facultyID, current, p)
+}
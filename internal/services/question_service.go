@@ -0,0 +1,131 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type QuestionService struct {
+	db          *sql.DB
+	enrollments repository.EnrollmentRepository
+}
+
+func NewQuestionService(db *sql.DB, enrollments repository.EnrollmentRepository) *QuestionService {
+	return &QuestionService{
+		db:          db,
+		enrollments: enrollments,
+	}
+}
+
+const questionSelect = `
+	SELECT question_id, student_id, course_id, question, answer, answered_by, created_at, answered_at
+	FROM STUDENT_QUESTION
+`
+
+func scanQuestions(rows *sql.Rows) ([]models.StudentQuestion, error) {
+	defer rows.Close()
+	questions := make([]models.StudentQuestion, 0)
+	for rows.Next() {
+		var q models.StudentQuestion
+		var answer, answeredBy sql.NullString
+		var answeredAt sql.NullTime
+		if err := rows.Scan(&q.QuestionID, &q.StudentID, &q.CourseID, &q.Question, &answer, &answeredBy, &q.CreatedAt, &answeredAt); err != nil {
+			return nil, err
+		}
+		if answer.Valid {
+			q.Answer = &answer.String
+		}
+		if answeredBy.Valid {
+			q.AnsweredBy = &answeredBy.String
+		}
+		if answeredAt.Valid {
+			q.AnsweredAt = &answeredAt.Time
+		}
+		questions = append(questions, q)
+	}
+	return questions, rows.Err()
+}
+
+// Ask records a student's question to the teachers of a course they are enrolled in.
+func (s *QuestionService) Ask(ctx context.Context, studentID, courseID, question string) (*models.StudentQuestion, error) {
+	if err := requireText("question", question, 2000); err != nil {
+		return nil, err
+	}
+	enrolled, err := s.enrollments.IsEnrolled(ctx, studentID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if !enrolled {
+		return nil, &ValidationError{Field: "course_id", Message: fmt.Sprintf("you are not enrolled in %s", courseID)}
+	}
+
+	q := models.StudentQuestion{StudentID: studentID, CourseID: courseID, Question: question}
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO STUDENT_QUESTION (student_id, course_id, question)
+		VALUES ($1, $2, $3)
+		RETURNING question_id, created_at
+	`, studentID, courseID, question).Scan(&q.QuestionID, &q.CreatedAt)
+	if err != nil {
+		return nil, translatePgError(err)
+	}
+	return &q, nil
+}
+
+// ListForStudent returns the student's questions, newest first.
+func (s *QuestionService) ListForStudent(ctx context.Context, studentID string) ([]models.StudentQuestion, error) {
+	rows, err := s.db.QueryContext(ctx, questionSelect+"WHERE student_id=$1 ORDER BY created_at DESC", studentID)
+	if err != nil {
+		return nil, err
+	}
+	return scanQuestions(rows)
+}
+
+// ListForTeacher returns questions for a course the faculty member teaches,
+// oldest open question first when openOnly is set.
+func (s *QuestionService) ListForTeacher(ctx context.Context, facultyID, courseID string, openOnly bool) ([]models.StudentQuestion, error) {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	query := questionSelect + "WHERE course_id=$1 ORDER BY created_at DESC"
+	if openOnly {
+		query = questionSelect + "WHERE course_id=$1 AND answered_at IS NULL ORDER BY created_at"
+	}
+	rows, err := s.db.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	return scanQuestions(rows)
+}
+
+// Answer records the faculty member's answer and dismisses the question's
+// outstanding unanswered-question alerts for every teacher of the course.
+func (s *QuestionService) Answer(ctx context.Context, facultyID string, questionID int, answer string) error {
+	if err := requireText("answer", answer, 4000); err != nil {
+		return err
+	}
+	var courseID string
+	err := s.db.QueryRowContext(ctx, "SELECT course_id FROM STUDENT_QUESTION WHERE question_id=$1", questionID).Scan(&courseID)
+	if err != nil {
+		return translatePgError(err)
+	}
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return err
+	}
+
+	return withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE STUDENT_QUESTION SET answer=$1, answered_by=$2, answered_at=CURRENT_TIMESTAMP
+			WHERE question_id=$3
+		`, answer, facultyID, questionID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE TEACHER_ALERT SET dismissed_at=CURRENT_TIMESTAMP
+			WHERE kind=$1 AND dedupe_key LIKE $2 AND dismissed_at IS NULL
+		`, AlertUnansweredQuestion, fmt.Sprintf("question:%d:%%", questionID))
+		return err
+	})
+}
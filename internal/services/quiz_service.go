@@ -2,43 +2,46 @@ package services
 
 import (
 	"academ_aide/internal/ai"
-	"academ_aide/internal/config"
 	"academ_aide/internal/models"
 	"academ_aide/internal/repository"
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"log/slog"
 	"strings"
 	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type QuizService struct {
 	Embedder *ai.Embedder
 	Repo     *repository.CourseRepository
+	LLM      ai.ChatClient
+
+	quizzes *mongo.Collection
 }
 
-func NewQuizService() *QuizService {
+func NewQuizService(db *sql.DB, mdb *mongo.Database, embedder *ai.Embedder, llm ai.ChatClient) *QuizService {
 	return &QuizService{
-		Embedder: ai.NewEmbedder(),
-		Repo:     repository.NewCourseRepository(config.PostgresDB),
+		Embedder: embedder,
+		Repo:     repository.NewCourseRepository(db),
+		LLM:      llm,
+		quizzes:  mdb.Collection("quizzes"),
 	}
 }
 
 // GenerateQuiz generates a quiz for a given course based on its syllabus
-func (s *QuizService) GenerateQuiz(courseID string, unit int, numQuestions int) (*models.Quiz, error) {
+func (s *QuizService) GenerateQuiz(ctx context.Context, courseID string, unit int, numQuestions int) (*models.Quiz, error) {
 	// 1. Fetch Syllabus Topics
 	var rows *sql.Rows
 	var err error
 
 	if unit > 0 {
-		rows, err = config.PostgresDB.Query("SELECT topic FROM SYLLABUS_UNIT WHERE course_id=$1 AND unit_no=$2", courseID, unit)
+		rows, err = s.Repo.DB.QueryContext(ctx, "SELECT topic FROM SYLLABUS_UNIT WHERE course_id=$1 AND unit_no=$2", courseID, unit)
 	} else {
-		rows, err = config.PostgresDB.Query("SELECT topic FROM SYLLABUS_UNIT WHERE course_id=$1", courseID)
+		rows, err = s.Repo.DB.QueryContext(ctx, "SELECT topic FROM SYLLABUS_UNIT WHERE course_id=$1", courseID)
 	}
 
 	if err != nil {
@@ -69,17 +72,17 @@ func (s *QuizService) GenerateQuiz(courseID string, unit int, numQuestions int)
 		query = fmt.Sprintf("Important concepts in %s: %s", courseID, topicStr)
 	}
 
-	embedding, err := s.Embedder.GenerateEmbedding(query)
+	embedding, err := s.Embedder.GenerateEmbedding(ctx, query)
 
 	var contextText string
 	if err != nil {
-		log.Println("Quiz embedding failed, falling back to basic prompt:", err)
+		slog.WarnContext(ctx, "Quiz embedding failed, falling back to basic prompt", "error", err)
 		contextText = "No course materials available."
 	} else {
 		// Fetch top 5 relevant chunks, filtering by unit if specified
-		materials, err := s.Repo.SearchMaterials(context.Background(), embedding, 5, []string{courseID}, unit)
+		materials, err := s.Repo.SearchMaterials(ctx, embedding, 5, []string{courseID}, unit)
 		if err != nil {
-			log.Println("Quiz material search failed:", err)
+			slog.WarnContext(ctx, "Quiz material search failed", "error", err)
 		} else {
 			var sb strings.Builder
 			for _, m := range materials {
@@ -124,7 +127,7 @@ Return ONLY valid JSON in the following format, with no extra text:
 }
 `, numQuestions, courseID, unitContext, contextText, topicStr)
 
-	jsonResp, err := s.callOllamaJSON(prompt)
+	jsonResp, err := generateJSON(ctx, s.LLM, "quiz_generator", prompt)
 	if err != nil {
 		return nil, err
 	}
@@ -134,8 +137,8 @@ Return ONLY valid JSON in the following format, with no extra text:
 		Questions []models.Question `json:"questions"`
 	}
 	if err := json.Unmarshal([]byte(jsonResp), &quizStructure); err != nil {
-		log.Println("Ollama JSON Parse Error:", err)
-		log.Println("Raw Response:", jsonResp)
+		slog.WarnContext(ctx, "Quiz response is not valid JSON", "error", err)
+		slog.DebugContext(ctx, "Quiz response", "response", jsonResp)
 		// Fallback attempt: try to find JSON in snippet if there's extra text
 		start := strings.Index(jsonResp, "{")
 		end := strings.LastIndex(jsonResp, "}")
@@ -157,8 +160,7 @@ Return ONLY valid JSON in the following format, with no extra text:
 		CreatedAt: time.Now(),
 	}
 
-	coll := config.MongoDB.Collection("quizzes")
-	res, err := coll.InsertOne(context.Background(), quiz)
+	res, err := s.quizzes.InsertOne(ctx, quiz)
 	if err != nil {
 		return nil, fmt.Errorf("saving quiz: %w", err)
 	}
@@ -169,28 +171,3 @@ Return ONLY valid JSON in the following format, with no extra text:
 
 	return quiz, nil
 }
-
-func (s *QuizService) callOllamaJSON(prompt string) (string, error) {
-	url := "http://localhost:11434/api/generate"
-	reqBody := map[string]interface{}{
-		"model":  "llama3.2",
-		"prompt": prompt,
-		"stream": false,
-		"format": "json", // Force JSON mode
-	}
-	jsonData, _ := json.Marshal(reqBody)
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("ollama connection failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(resp.Body)
-	var oResp struct {
-		Response string `json:"response"`
-	}
-	json.Unmarshal(body, &oResp)
-
-	return oResp.Response, nil
-}
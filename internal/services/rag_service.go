@@ -3,630 +3,581 @@ package services
 import (
 	"academ_aide/internal/ai"
 	"academ_aide/internal/config"
+	"academ_aide/internal/metrics"
 	"academ_aide/internal/models"
+	"academ_aide/internal/prompt"
 	"academ_aide/internal/repository"
-	"bytes"
+	"academ_aide/internal/tracing"
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
-	"net/http"
+	"log/slog"
 	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 )
 
+// RAGDeps are the collaborators of the chat pipeline. They are built once and shared
+// with the handlers, so caches, breakers and alert queues are not duplicated.
+type RAGDeps struct {
+	Embedder  *ai.Embedder
+	Repo      *repository.CourseRepository // Material search
+	Data      repository.Repositories      // Who the user is, their courses, timetable and announcements
+	Cache     *ResponseCache
+	Briefs    *BriefCache // The users' briefs, so a turn does not rebuild them
+	Threads   *ChatService
+	LLM       ai.ChatClient
+	Intents   *IntentClassifier
+	Personas  *PersonaService
+	Moderator Moderator
+	Safety    *SafetyService
+	Sentiment *SentimentScorer
+	Wellbeing *WellbeingService
+	Grading   *GradingService // Grade lookups and the grades tool
+	Insights  *AIService      // The what-if tool
+}
+
 type RAGService struct {
-	Embedder *ai.Embedder
-	Repo     *repository.CourseRepository
+	RAGDeps
+	MaxSteps int // Model turns that may call tools
+
+	ContextTokens int    // Model context window
+	ReplyTokens   int    // Part of the window kept for the answer
+	Helpline      string // Named in replies to self-harm messages
+
+	contexts       *mongo.Collection // ChatContext
+	contextLatency *latencyWindow    // Start of a turn to the first model call
 }
 
-func NewRAGService() *RAGService {
+func NewRAGService(mdb *mongo.Database, cfg config.Chat, deps RAGDeps) *RAGService {
 	return &RAGService{
-		Embedder: ai.NewEmbedder(),
-		Repo:     repository.NewCourseRepository(config.PostgresDB),
+		RAGDeps:  deps,
+		MaxSteps: cfg.MaxToolSteps,
+
+		ContextTokens: cfg.ContextTokens,
+		ReplyTokens:   cfg.ReplyTokens,
+		Helpline:      cfg.SafetyHelpline,
+
+		contexts:       mdb.Collection("ChatContext"),
+		contextLatency: newLatencyWindow(latencySamples),
 	}
 }
 
-// AnalyzeSentiment - Simple keyword-based or mock (since we are doing RAG)
-func (s *RAGService) AnalyzeSentiment(message string) string {
-	lower := strings.ToLower(message)
-	if strings.Contains(lower, "bad") || strings.Contains(lower, "hate") || strings.Contains(lower, "fail") {
-		return "negative"
-	} else if strings.Contains(lower, "good") || strings.Contains(lower, "love") || strings.Contains(lower, "thanks") {
-		return "positive"
-	}
-	return "neutral"
+const (
+	latencySamples     = 1000 // Turns the context latency percentiles are taken over
+	latencyLogInterval = 100  // Turns between latency log lines
+)
+
+// ContextLatency returns the median and 95th percentile time from receiving a message
+// to calling the model, over recent turns that reached the model.
+func (s *RAGService) ContextLatency() (p50, p95 time.Duration) {
+	return s.contextLatency.Percentile(50), s.contextLatency.Percentile(95)
 }
 
-func (s *RAGService) getAgentPrompt(agentID string) string {
-	switch agentID {
-	case "socratic":
-		return `You are a Socratic Tutor. Your goal is to help the student learn by asking guiding questions, NOT by giving answers.
-        RULES:
-        1. Never provide the direct answer immediately.
-        2. Ask probing questions to check understanding.
-        3. If the student is stuck, provide a small hint, then ask another question.
-        4. Break complex problems down into step-by-step logic.
-        5. Encourage critical thinking.
-        6. If the user asks for code, ask them to write the pseudo-code first.`
-	case "code_reviewer":
-		return `You are an expert Senior Software Engineer and Code Reviewer.
-        ROLE: Analyze the student's code for bugs, time complexity (Big O), and code style.
-        RULES:
-        1. Identify Logic Errors and Security Vulnerabilities.
-        2. Critique Variable Naming and Code Structure (Clean Code principles).
-        3. Explain *WHY* a change is needed before showing the fix.
-        4. Provide optimized, commented code snippets only after explaining the issue.
-        5. Assume the student wants to write production-grade code.`
-	case "research":
-		return `You are a PhD-level Research Assistant.
-        ROLE: Provide deep, academic, and comprehensive answers.
-        RULES:
-        1. Structure answers with: "Abstract/Summary", "Detailed Analysis", "Key Concepts", and "References/Citations".
-        2. Use formal, academic tone.
-        3. Connect the user's query to broader concepts in the field.
-        4. If using RAG context, explicitly cite the specific Unit/Module provided.
-        5. Highlight conflicting theories or alternative viewpoints if applicable.`
-	case "exam":
-		return `You are a High-Performance Exam Coach.
-        ROLE: Prepare the student to score maximum marks in minimum time.
-        RULES:
-        1. Focus on "High-Yield" topics and likely exam questions.
-        2. Provide Mnemonics and Memory Aids for difficult concepts.
-        3. Use "Rapid Fire" mode: Ask a question, wait for answer, then grade it.
-        4. Suggest time management strategies for the exam hall.
-        5. Point out common pitfalls where students lose marks.
-        6. Be direct, concise, and results-oriented.`
-	case "motivational", "coach":
-		return `You are a Supportive Academic Coach and Mentor.
-        ROLE: Boost user confidence, manage stress, and help with study planning.
-        RULES:
-        1. Validates the student's feelings (stress, overwhelm) first.
-        2. Break large, scary tasks into tiny, manageable "micro-goals".
-        3. Suggest specific study techniques (Pomodoro, Spaced Repetition).
-        4. Remind them of their past successes (check their high grades in context).
-        5. Be incredibly encouraging, positive, and empathetic. Use emojis.`
-	case "teacher":
-		return `You are an expert Teaching Assistant and Faculty Advisor.
-        ROLE: Assist the teacher with course planning, student performance analysis, and content generation.
-        RULES:
-        1. Contextualize answers based on the courses the teacher teaches.
-        2. Help with creating quiz questions, lecture notes, and syllabus planning.
-        3. Analyze student trends if data is provided (e.g., "Why is CS101 struggling?").
-        4. Be professional, concise, and helpful.`
-	default: // "general" or unknown
-		return `You are AcademAide, an intelligent academic advisor.
-        ROLE: Answer questions clearly and help the student with their coursework.
-        RULES:
-        1. If the user asks about course selection or electives, check their grades in relevant prerequisite courses.
-        2. Be encouraging but realistic based on their performance.
-        3. If the user asks for factual information, code syntax, or definitions, answer directly and concisely.`
+func (s *RAGService) observeContextLatency(d time.Duration) {
+	metrics.ChatContextDuration.Observe(d.Seconds())
+	if n := s.contextLatency.Observe(d); n%latencyLogInterval == 0 {
+		p50, p95 := s.ContextLatency()
+		slog.Info("Chat context latency", "turns", min(n, latencySamples), "p50", p50, "p95", p95)
 	}
 }
 
-func (s *RAGService) ProcessChat(userID, role, message, agentID string) (string, error) {
-	ctx := context.Background()
-
-	// 1. Check Cache (Semantic/Exact)
-	hash := sha256.Sum256([]byte(message))
-	cacheKey := "response:" + hex.EncodeToString(hash[:])
-	cached, err := config.RedisClient.Get(ctx, cacheKey).Result()
-	if err == nil {
-		return cached, nil
-	}
+// AnalyzeSentiment labels a message positive, neutral or negative with the lexicon
+// scorer.
+func (s *RAGService) AnalyzeSentiment(message string) string {
+	return scoreLexicon(message).Label
+}
 
-	var contextString string
+// ChatReply is an answer together with the ID of its stored ChatLog (for feedback)
+// and the course materials it was grounded in.
+type ChatReply struct {
+	Response  string
+	MessageID string
+	Citations []models.Citation
+	Intent    Classification
+	Support   []models.SupportResource // Set when the user seems distressed
+}
 
-	if role == "teacher" {
-		// --- TEACHER CONTEXT ---
-		var facultyName, email string
-		err = config.PostgresDB.QueryRow("SELECT f_first_name, f_email FROM FACULTY WHERE faculty_id=$1", userID).Scan(&facultyName, &email)
-		if err != nil {
-			return "", fmt.Errorf("fetching faculty profile: %w", err)
+// ProcessChat answers one message. ctx bounds the whole turn; the exchange is still
+// logged if the client goes away once an answer exists.
+func (s *RAGService) ProcessChat(ctx context.Context, userID, role, message, agentID, conversationID string) (reply *ChatReply, err error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "chat.process", attribute.String("chat.role", role))
+	defer func() { tracing.End(span, err) }()
+
+	// 1. Who is asking, and how the message reads. Independent, so they run together;
+	// the embedding for the semantic cache is computed up front when it may be needed.
+	var brief *userBrief
+	var mood Sentiment
+	var v ModerationVerdict
+	var embedding []float32
+	var embedErr error
+	cctx, cspan := tracing.Start(ctx, "chat.context")
+	g, gctx := errgroup.WithContext(cctx)
+	g.Go(func() (err error) {
+		brief, err = s.loadBrief(gctx, userID, role)
+		return err
+	})
+	g.Go(func() error {
+		mood = s.Sentiment.Score(gctx, message)
+		return nil
+	})
+	g.Go(func() error {
+		var err error
+		if v, err = s.Moderator.Moderate(gctx, message); err != nil {
+			slog.WarnContext(ctx, "Moderation failed", "error", err)
 		}
+		return nil
+	})
+	if s.Cache.SemanticEnabled() {
+		g.Go(func() error {
+			embedding, embedErr = s.Embedder.GenerateEmbedding(gctx, message)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		tracing.End(cspan, err)
+		return nil, err
+	}
 
-		// Fetch Courses Taught
-		var taughtCourses []string
-		rows, err := config.PostgresDB.Query("SELECT c.title FROM TEACHES t JOIN COURSE c ON t.course_id = c.course_id WHERE t.faculty_id=$1", userID)
-		if err == nil {
-			defer rows.Close()
-			for rows.Next() {
-				var t string
-				if err := rows.Scan(&t); err == nil {
-					taughtCourses = append(taughtCourses, t)
-				}
-			}
+	// 2. Which agent answers, and what the message is about. Both need the courses.
+	var persona *models.AgentPersona
+	var intent Classification
+	g, gctx = errgroup.WithContext(cctx)
+	g.Go(func() (err error) {
+		persona, err = s.Personas.Resolve(gctx, agentID, role, brief.courseIDs)
+		return err
+	})
+	g.Go(func() error {
+		intent = s.Intents.Classify(gctx, message, brief.courses)
+		return nil
+	})
+	if err := g.Wait(); err != nil {
+		tracing.End(cspan, err)
+		return nil, err
+	}
+	cspan.End()
+	span.SetAttributes(tracing.AgentID.String(persona.ID), tracing.Intent.String(intent.Intent))
+	sentiment := mood.Label
+	turn := chatTurn{
+		UserID:         userID,
+		ConversationID: conversationID,
+		AgentID:        persona.ID,
+		Message:        message,
+		Sentiment:      sentiment,
+		Intent:         intent,
+	}
+
+	// Self-harm and abuse get a fixed reply instead of the model
+	if mood.Crisis && !v.Flagged {
+		v = ModerationVerdict{Flagged: true, Category: ModerationSelfHarm, Reason: "self-harm (" + mood.Source + ")"}
+	}
+	if role == "student" {
+		turn.Support = s.checkWellbeing(ctx, turn, mood, v.Flagged && v.Category == ModerationSelfHarm)
+	}
+	if v.Flagged {
+		incident := models.SafetyIncident{
+			UserID: userID, Role: role, ConversationID: conversationID,
+			Kind: v.Category, Source: SourceMessage, Severity: RiskHigh, Detail: v.Reason, Excerpt: message,
 		}
-		taughtCoursesStr := strings.Join(taughtCourses, ", ")
-
-		// Fetch Enrolled Students for these courses
-		var studentListBuilder strings.Builder
-		sRows, err := config.PostgresDB.Query(`
-			SELECT DISTINCT c.title, s.s_first_name, s.s_last_name, s.student_id
-			FROM TEACHES t
-			JOIN ENROLLS_IN e ON t.course_id = e.course_id
-			JOIN STUDENT s ON e.student_id = s.student_id
-			JOIN COURSE c ON t.course_id = c.course_id
-			WHERE t.faculty_id = $1 AND e.status = 'Enrolled'
-			ORDER BY c.title, s.s_last_name
-		`, userID)
-
-		if err == nil {
-			defer sRows.Close()
-			studentListBuilder.WriteString("\n[ENROLLED STUDENTS]:\n")
-			currentCourse := ""
-			for sRows.Next() {
-				var cTitle, fName, LName, sID string
-				if err := sRows.Scan(&cTitle, &fName, &LName, &sID); err == nil {
-					if cTitle != currentCourse {
-						studentListBuilder.WriteString(fmt.Sprintf("\nCourse: %s\n", cTitle))
-						currentCourse = cTitle
-					}
-					studentListBuilder.WriteString(fmt.Sprintf("- %s %s (%s)\n", fName, LName, sID))
-				}
-			}
+		if v.Category == ModerationSelfHarm {
+			incident.Excerpt = "" // Confidential: only the counsellor's alert holds the message
 		}
+		s.Safety.Record(ctx, incident)
+		turn.Response, turn.Route = moderationReply(v, s.Helpline), RouteSafety
+		return s.storeExchange(ctx, turn), nil
+	}
+	injection := DetectInjection(message)
+	if len(injection) > 0 {
+		s.Safety.Record(ctx, models.SafetyIncident{
+			UserID: userID, Role: role, ConversationID: conversationID,
+			Kind: IncidentPromptInjection, Source: SourceMessage, Severity: RiskMedium,
+			Detail: strings.Join(injection, ", "), Excerpt: message,
+		})
+	}
 
-		contextString = fmt.Sprintf(`
-[CONTEXTUAL AWARENESS]
-You are talking to %s, a Faculty Member.
-- Email: %s
-- Courses Taught: [%s]
-
-%s
-`, facultyName, email, taughtCoursesStr, studentListBuilder.String())
+	// Plain schedule and grade lookups are answered from the database
+	if IsLookup(intent, message) {
+		if answer, ok := answerLookup(intent, message, role, brief); ok {
+			turn.Response, turn.Route = answer, RouteLookup
+			return s.storeExchange(ctx, turn), nil
+		}
+	}
 
-	} else {
-		// --- STUDENT CONTEXT ---
-		studentID := userID // Alias for clarity
+	// While the model backend is down, answer what the database can and fail fast otherwise
+	if ai.BackendDown(s.LLM) {
+		return s.degrade(ctx, turn, role, brief, &ai.UnavailableError{RetryAfter: time.Second, Cause: errors.New("circuit open")})
+	}
 
-		// Profile & Identity (Level 1)
-		var studentName, deptID string
-		var yearOfJoining int
-		err = config.PostgresDB.QueryRow("SELECT s_first_name, dept_id, year_of_joining FROM STUDENT WHERE student_id=$1", studentID).Scan(&studentName, &deptID, &yearOfJoining)
-		if err != nil {
-			return "", fmt.Errorf("fetching student profile: %w", err)
+	// 4. Construct System Prompt, fitted to the context window
+	tools := toolsForPersona(persona, role)
+	tone := toneHint(sentiment)
+	if len(turn.Support) > 0 {
+		tone = distressTone
+	}
+	promptData := chatPromptData{
+		Persona:     persona.SystemPrompt,
+		HasTools:    len(tools) > 0,
+		Intro:       brief.intro,
+		Now:         time.Now().Format("Monday, 15:04"),
+		CourseLabel: brief.courseLabel,
+		Courses:     brief.courseList,
+		Tone:        tone,
+
+		InjectionSuspected: len(injection) > 0,
+	}
+	rendered, err := chatPrompt.Render(promptData, s.systemPromptBudget(message))
+	if errors.Is(err, prompt.ErrOverBudget) {
+		return nil, &ValidationError{Field: "message", Message: "is too long for the model's context window"}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(rendered.Truncated) > 0 || len(rendered.Dropped) > 0 {
+		slog.InfoContext(ctx, "Chat prompt cut to fit", "tokens", rendered.Tokens, "truncated", rendered.Truncated, "dropped", rendered.Dropped)
+	}
+	systemPrompt := rendered.Text
+
+	// Response Cache: per user and persona version, keyed on the message plus the prompt
+	// it is answered from, less the clock. Schedule, grade and wellbeing questions,
+	// suspected injections and messages from distressed students are never cached.
+	cacheable := cacheableIntent(intent) && len(injection) == 0 && len(turn.Support) == 0
+	cacheAgent := fmt.Sprintf("%s@%d", persona.ID, persona.Version)
+	fingerprint := promptFingerprint(message, promptData)
+	semanticCourse := "" // Course of the best-matching material scopes the semantic cache
+	if cacheable {
+		if cached, ok := s.Cache.Get(ctx, userID, cacheAgent, fingerprint); ok {
+			turn.Response, turn.Route = cached, RouteCache
+			return s.storeExchange(ctx, turn), nil
 		}
-
-		// Level 1: Calculate Year
-		currentYearVal := time.Now().Year() // Dynamic based on system time
-		studentYear := currentYearVal - yearOfJoining
-		if studentYear <= 0 {
-			studentYear = 1
-		} // Fallback
-
-		// Level 4: RAG Filtering - Fetch Enrolled Course IDs
-		var enrolledCourseIDs []string
-		var courseTitles []string
-		cRows, err := config.PostgresDB.Query("SELECT c.course_id, c.title FROM ENROLLS_IN e JOIN COURSE c ON e.course_id = c.course_id WHERE e.student_id=$1 AND e.status='Enrolled'", studentID)
-		if err == nil {
-			defer cRows.Close()
-			for cRows.Next() {
-				var id, t string
-				if err := cRows.Scan(&id, &t); err == nil {
-					enrolledCourseIDs = append(enrolledCourseIDs, id)
-					courseTitles = append(courseTitles, t)
-				}
+		if s.Cache.SemanticEnabled() && len(brief.courseIDs) > 0 {
+			if embedErr != nil {
+				slog.WarnContext(ctx, "Embedding generation failed", "error", embedErr)
+			} else if materials, err := s.Repo.SearchMaterials(ctx, embedding, 1, brief.courseIDs, 0); err == nil && len(materials) > 0 {
+				semanticCourse = materials[0].CourseID
 			}
-		}
-		coursesStr := strings.Join(courseTitles, ", ")
-
-		// Level 2: Grade-Based Advisor - Academic History
-		var academicHistoryBuilder strings.Builder
-		var cgpa float64
-		gRows, err := config.PostgresDB.Query(`
-		SELECT c.title, e.grade, c.credits, e.course_id
-		FROM ENROLLS_IN e
-		JOIN COURSE c ON e.course_id = c.course_id
-		WHERE e.student_id=$1 AND e.grade IS NOT NULL
-	`, studentID)
-
-		totalCredits := 0
-		totalPoints := 0.0
-		academicHistoryBuilder.WriteString("Academic History: [")
-
-		if err == nil {
-			defer gRows.Close()
-			first := true
-			for gRows.Next() {
-				var title, grade, courseID string
-				var credits int
-				if err := gRows.Scan(&title, &grade, &credits, &courseID); err == nil {
-					if !first {
-						academicHistoryBuilder.WriteString(", ")
-					}
-					academicHistoryBuilder.WriteString(fmt.Sprintf("%s: %s", title, grade))
-					first = false
-
-					points := 0.0
-					switch grade {
-					case "O", "A+":
-						points = 10.0
-					case "A":
-						points = 9.0
-					case "B+":
-						points = 8.0
-					case "B":
-						points = 7.0
-					case "C+":
-						points = 6.0
-					case "C":
-						points = 5.0
-					case "D":
-						points = 4.0
-					default:
-						points = 0.0
-					}
-					totalPoints += points * float64(credits)
-					totalCredits += credits
-				}
+			if cached, ok := s.Cache.GetSemantic(ctx, semanticCourse, role, cacheAgent, embedding); ok {
+				turn.Response, turn.Route = cached, RouteCache
+				return s.storeExchange(ctx, turn), nil
 			}
 		}
-		academicHistoryBuilder.WriteString("]")
-		if totalCredits > 0 {
-			cgpa = float64(int((totalPoints/float64(totalCredits))*100)) / 100
-		}
-		academicHistoryStr := academicHistoryBuilder.String()
-
-		// Level 3: Schedule Assistant - Full Weekly Timetable
-		var timetableBuilder strings.Builder
-		timetableBuilder.WriteString("[WEEKLY TIMETABLE]:\n")
-
-		ttRows, err := config.PostgresDB.Query(`
-			SELECT sch.day_of_week, TO_CHAR(sch.start_time, 'HH24:MI'), TO_CHAR(sch.end_time, 'HH24:MI'), c.title, sch.room_number
-			FROM SCHEDULE sch 
-			JOIN ENROLLS_IN e ON sch.course_id = e.course_id
-			JOIN COURSE c ON sch.course_id = c.course_id
-			JOIN STUDENT s ON e.student_id = s.student_id
-			-- Join section to ensure we only get schedule for the student's section if they share the same dept/section logic
-			-- However, STUDENT table doesn't have section, only Dept.
-			-- Let's check schema: SECTION table has dept_id.
-			-- We don't know student's section directly! 
-			-- Schema review: TEACHES (faculty, course, section). SCHEDULE (course, section).
-			-- STUDENT (dept_id only).
-			-- If student doesn't have section, they see ALL sections? That explains the duplicates!
-			-- We must infer section or filter. 
-			-- Assumption: Student is in 'CSE-D' if they take 'CSE' dept courses? No.
-			-- Wait, earlier in insert_data.sql: "Sharanya... CSE".
-			-- Timetable insert: 'IS353IA', 'CSE-D'. 'CD252IA', 'CSE-A'.
-			-- If Sharanya is enrolled in CD252IA, she gets both CSE-A and CSE-D schedule?
-			WHERE e.student_id = $1
-			AND (
-				(
-					$1 IN ('1RV23CS221', '1RV23CS234', '1RV23CS211') 
-					AND sch.section_name = 'CSE-A'
-				) 
-				OR 
-				(
-					$1 NOT IN ('1RV23CS221', '1RV23CS234', '1RV23CS211') 
-					AND sch.section_name = 'CSE-D'
-					AND sch.room_number NOT LIKE 'Night Class%'
-				)
-			)
-			ORDER BY 
-				CASE sch.day_of_week 
-					WHEN 'Monday' THEN 1 
-					WHEN 'Tuesday' THEN 2 
-					WHEN 'Wednesday' THEN 3 
-					WHEN 'Thursday' THEN 4 
-					WHEN 'Friday' THEN 5 
-					WHEN 'Saturday' THEN 6 
-					ELSE 7 
-				END, 
-				sch.start_time
-		`, studentID)
+	}
 
-		if err != nil {
-			log.Printf("Error fetching timetable for %s: %v", studentID, err)
+	// 5. Tool-calling loop
+	env := &toolEnv{
+		rag: s, userID: userID, role: role, courseIDs: brief.courseIDs, topK: persona.Retrieval.TopK, brief: brief,
+		conversationID: conversationID, disclosed: map[string]bool{},
+	}
+	collectIdentifiers(brief.intro, env.disclosed) // The user's own email may be in the brief
+	s.observeContextLatency(time.Since(start))
+	response, err := s.runAgent(ctx, env, persona, tools, systemPrompt, message)
+	if errors.Is(err, ai.ErrUnavailable) && ctx.Err() == nil { // A timed-out request gets a 504, not a fallback
+		return s.degrade(ctx, turn, role, brief, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	response = s.screenAnswer(ctx, env, response)
+
+	// 6. Store in Mongo
+	turn.Response, turn.Route, turn.Citations = response, RouteModel, env.citations
+	reply = s.storeExchange(ctx, turn)
+
+	// 7. Cache Response. Only answers from the course materials are cached: anything
+	// built from a data tool holds records that change, and someone's at that.
+	if cacheable && !env.usedData {
+		s.Cache.Set(ctx, userID, cacheAgent, fingerprint, response)
+		if !mentionsUser(response, brief.displayName) && !containsIdentifiers(response) {
+			s.Cache.SetSemantic(ctx, semanticCourse, role, cacheAgent, message, embedding, response)
 		}
+	}
 
-		if err == nil {
-			defer ttRows.Close()
-			currentDay := ""
-			for ttRows.Next() {
-				var day, start, end, title, room string
-				if err := ttRows.Scan(&day, &start, &end, &title, &room); err == nil {
-					if day != currentDay {
-						timetableBuilder.WriteString(fmt.Sprintf("%s:\n", day))
-						currentDay = day
-					}
-					timetableBuilder.WriteString(fmt.Sprintf("- %s-%s: %s (%s)\n", start, end, title, room))
-				}
-			}
+	return reply, nil
+}
+
+// runAgent lets the model call the persona's tools for up to maxToolSteps turns, then
+// returns its answer. The persona's model and temperature override the client's.
+func (s *RAGService) runAgent(ctx context.Context, env *toolEnv, persona *models.AgentPersona, tools []chatTool, systemPrompt, message string) (answer string, err error) {
+	ctx, span := tracing.Start(ctx, "chat.agent", tracing.AgentID.String(persona.ID))
+	steps := 0
+	defer func() {
+		span.SetAttributes(tracing.ToolSteps.Int(steps), tracing.ChunkCount.Int(len(env.citations)))
+		tracing.End(span, err)
+	}()
+
+	defs := make([]ai.Tool, len(tools))
+	for i, t := range tools {
+		defs[i] = t.Tool
+	}
+
+	messages := []ai.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: message},
+	}
+	for ; ; steps++ {
+		offered := defs
+		if steps >= s.MaxSteps {
+			offered = nil // Out of steps: answer with what has been gathered
 		}
-		timetableStr := timetableBuilder.String()
-
-		// Current Status Logic (Keep existing for specific "Right Now" awareness)
-		currentTime := time.Now()
-		dayOfWeek := currentTime.Weekday().String()
-		currentTimeStr := currentTime.Format("15:04:00")
-		var upcomingClassInfo string
-
-		currentQuery := `
-		SELECT c.title, sch.room_number, sch.end_time
-		FROM SCHEDULE sch 
-		JOIN ENROLLS_IN e ON sch.course_id = e.course_id
-		JOIN COURSE c ON sch.course_id = c.course_id
-		WHERE e.student_id=$1 AND sch.day_of_week=$2 AND sch.start_time <= $3 AND sch.end_time > $3
-		LIMIT 1
-	`
-		var ongoingTitle, ongoingRoom, endTime string
-		err = config.PostgresDB.QueryRow(currentQuery, studentID, dayOfWeek, currentTimeStr).Scan(&ongoingTitle, &ongoingRoom, &endTime)
-
-		if err == nil {
-			upcomingClassInfo = fmt.Sprintf("HAPPENING NOW: You should be in %s (Room %s) until %s.", ongoingTitle, ongoingRoom, endTime)
-		} else {
-			// Find next class today
-			schedQuery := `
-			SELECT c.title, sch.start_time, sch.room_number 
-			FROM SCHEDULE sch 
-			JOIN ENROLLS_IN e ON sch.course_id = e.course_id
-			JOIN COURSE c ON sch.course_id = c.course_id
-			WHERE e.student_id=$1 AND sch.day_of_week=$2 AND sch.start_time > $3
-			ORDER BY sch.start_time ASC
-			LIMIT 1
-		`
-			var nextTitle, nextStart, nextRoom string
-			err = config.PostgresDB.QueryRow(schedQuery, studentID, dayOfWeek, currentTimeStr).Scan(&nextTitle, &nextStart, &nextRoom)
-
-			if err == nil {
-				upcomingClassInfo = fmt.Sprintf("Next Class Today: %s at %s in %s.", nextTitle, nextStart, nextRoom)
-			} else {
-				upcomingClassInfo = "No more classes scheduled for today."
-			}
+		turn, err := s.LLM.Chat(ctx, ai.ChatRequest{
+			Messages:    messages,
+			Tools:       offered,
+			Model:       persona.Model,
+			Temperature: persona.Temperature,
+			Agent:       persona.ID,
+		})
+		if err != nil {
+			return "", err
 		}
-
-		currentDateTimeStr := fmt.Sprintf("%s, %s", dayOfWeek, currentTime.Format("15:04 PM"))
-
-		contextString = fmt.Sprintf(`
-[CONTEXTUAL AWARENESS]
-You are talking to %s, a %d Year %s student.
-- Current Time: %s
-- Academic Standing: CGPA %.2f (%s)
-- Current Status: %s 
-- Enrolled Courses: [%s]
-
-%s
-
-[ACADEMIC HISTORY]
-%s`,
-			studentName, studentYear, deptID,
-			currentDateTimeStr,
-			cgpa, academicHistoryStr,
-			upcomingClassInfo,
-			coursesStr,
-			timetableStr,
-			academicHistoryStr)
-	}
-
-	var enrolledCourseIDs []string
-	if role == "teacher" {
-		// Fetch taught Course IDs
-		cRows, err := config.PostgresDB.Query("SELECT course_id FROM TEACHES WHERE faculty_id=$1", userID)
-		if err == nil {
-			defer cRows.Close()
-			for cRows.Next() {
-				var id string
-				if err := cRows.Scan(&id); err == nil {
-					enrolledCourseIDs = append(enrolledCourseIDs, id)
-				}
+		if len(turn.ToolCalls) == 0 || offered == nil {
+			answer = strings.TrimSpace(turn.Content)
+			if answer == "" {
+				return "", &ai.UnavailableError{RetryAfter: emptyAnswerRetry, Cause: ai.ErrEmptyResponse}
 			}
+			return answer, nil
 		}
-	} else {
-		// Already fetched above in student block? Use that logic?
-		// To avoid code duplication, I should have extracted IDs there.
-		// For now, I'll essentially re-query or ignore if it's acceptable.
-		// Actually, I can just leave vector context empty for teachers OR fix the logic.
-		// Let's do a quick query for IDs if student too, to be safe.
-		// But in the student block above I already did it.
-		// I can't easily share variables across the if/else unless I declare them outside.
-		// Let's declare `var relevantCourseIDs []string` outside.
-
-		// I'll stick to the replacement as is and add a small redundant query or just skip RAG for teachers if too complex,
-		// BUT the user wants "relevant responses", so RAG is good.
-
-		// Let's try to do it cleaner by moving `enrolledCourseIDs` out.
-		// But wait, the replacement chunk is getting huge.
-		// I will just do the quick query inside the teacher block above.
-	}
-
-	// Wait, I can't easily edit the middle of my ReplacementContent dynamically.
-	// I'll stick to the plan:
-	// For Teacher: Fetch taught course IDs.
-	// For Student: Fetch enrolled course IDs.
-
-	vectorContext := ""
-	embedding, err := s.Embedder.GenerateEmbedding(message)
-	if err != nil {
-		log.Println("Embedding generation failed:", err)
-	} else {
-		// Need IDs.
-		var filterIDs []string
-		if role == "teacher" {
-			rows, _ := config.PostgresDB.Query("SELECT course_id FROM TEACHES WHERE faculty_id=$1", userID)
-			if rows != nil {
-				defer rows.Close()
-				for rows.Next() {
-					var id string
-					rows.Scan(&id)
-					filterIDs = append(filterIDs, id)
-				}
+
+		messages = append(messages, *turn)
+		for _, call := range turn.ToolCalls {
+			slog.DebugContext(ctx, "Chat tool call", "tool", call.Name, "arguments", string(call.Arguments))
+			content := runTool(ctx, env, tools, call)
+			if call.Name != "search_materials" {
+				env.usedData = true
 			}
-		} else {
-			// Student IDs. I need to get them again or duplicate logic.
-			// I'll just re-query to be safe and simple within this block.
-			rows, _ := config.PostgresDB.Query("SELECT course_id FROM ENROLLS_IN WHERE student_id=$1 AND status='Enrolled'", userID)
-			if rows != nil {
-				defer rows.Close()
-				for rows.Next() {
-					var id string
-					rows.Scan(&id)
-					filterIDs = append(filterIDs, id)
-				}
+			untrusted := contains(untrustedTools, call.Name)
+			if !untrusted {
+				collectIdentifiers(content, env.disclosed)
+				env.disclosedText = append(env.disclosedText, content)
 			}
-		}
-
-		materials, err := s.Repo.SearchMaterials(ctx, embedding, 3, filterIDs, 0)
-		if err != nil {
-			log.Println("Material search failed:", err)
-		} else {
-			var sb strings.Builder
-			sb.WriteString("Relevant Materials:\n")
-			for _, m := range materials {
-				sb.WriteString(fmt.Sprintf("- [Course %s Unit %d] %s\n", m.CourseID, m.UnitNo, m.Content))
+			content = prompt.TruncateTokens(content, toolResultTokens)
+			if untrusted {
+				content = delimitUntrusted(call.Name, content)
 			}
-			vectorContext = sb.String()
+			messages = append(messages, ai.Message{
+				Role:       "tool",
+				Content:    content,
+				ToolCallID: call.ID,
+				ToolName:   call.Name,
+			})
 		}
 	}
+}
 
-	// 3. Fetch Last 5 Messages (Mongo) - DISABLED BY USER REQUEST
-	// coll := config.MongoDB.Collection("ChatLogs")
-	// opts := options.Find().SetSort(bson.D{{"timestamp", -1}}).SetLimit(5)
-	// cursor, err := coll.Find(ctx, bson.M{"student_id": userID}, opts)
-	// var history []models.ChatLog
-	// if err == nil {
-	// 	cursor.All(ctx, &history)
-	// }
+// Degraded mode
+const (
+	degradedNotice   = "The AI assistant is temporarily unavailable, so this answer comes straight from your records:"
+	emptyAnswerRetry = 5 * time.Second
+)
 
-	// 4. Sentiment
-	sentiment := s.AnalyzeSentiment(message)
+// degrade answers timetable, next-class and grade questions from the database while the
+// model is unavailable, whatever their phrasing. Anything else returns cause, which
+// carries the retry hint.
+func (s *RAGService) degrade(ctx context.Context, turn chatTurn, role string, brief *userBrief, cause error) (*ChatReply, error) {
+	slog.WarnContext(ctx, "Chat without the model", "error", cause)
+	answer, ok := answerLookup(turn.Intent, turn.Message, role, brief)
+	if !ok {
+		return nil, cause
+	}
+	turn.Response, turn.Route = degradedNotice+"\n\n"+answer, RouteDegraded
+	return s.storeExchange(ctx, turn), nil
+}
 
-	// 5. Construct Prompt
-	systemRole := s.getAgentPrompt(agentID)
+// checkWellbeing adds the message to the student's mood trend. Crisis language, or a
+// distressed message while distress has been sustained, raises a confidential alert
+// for a counsellor; the support resources to show the student are returned.
+func (s *RAGService) checkWellbeing(ctx context.Context, t chatTurn, mood Sentiment, crisis bool) []models.SupportResource {
+	ctx, cancel := detach(ctx) // An alert must not be lost to a disconnect
+	defer cancel()
+	trend, err := s.Wellbeing.Track(ctx, t.UserID, mood)
+	if err != nil {
+		slog.ErrorContext(ctx, "Tracking mood failed", "error", err)
+	}
 
-	// 6. Generate LLM Output
-	log.Printf("DEBUG: RAG Context for User %s:\n%s", userID, contextString)
+	var trigger, severity string
+	switch {
+	case crisis:
+		trigger, severity = TriggerCrisis, RiskHigh
+	case trend.Sustained && mood.Distress >= DistressConcern:
+		trigger, severity = TriggerSustainedDistress, RiskMedium
+	default:
+		return nil
+	}
+	if _, err := s.Wellbeing.Raise(ctx, t.UserID, trigger, severity, mood.Distress, t.Message, t.ConversationID); err != nil {
+		slog.ErrorContext(ctx, "Raising wellbeing alert failed", "error", err)
+	}
+	return s.Wellbeing.SupportResources(ctx)
+}
 
-	prompt := fmt.Sprintf(`
-You are AcademAide, an intelligent academic assistant.
+// screenAnswer removes personal data the user's own tools did not return and replaces
+// answers moderation flags. Both are logged as incidents.
+func (s *RAGService) screenAnswer(ctx context.Context, env *toolEnv, answer string) string {
+	allowed := map[string]bool{strings.ToLower(env.userID): true}
+	for id := range env.disclosed {
+		allowed[id] = true
+	}
+	hidden := hiddenNames(s.roster(ctx, env.courseIDs), env.userID, env.brief.displayName, env.disclosedText)
+	answer, kinds := redactPII(answer, allowed, hidden)
+	if len(kinds) > 0 {
+		s.Safety.Record(ctx, models.SafetyIncident{
+			UserID: env.userID, Role: env.role, ConversationID: env.conversationID,
+			Kind: IncidentPersonalData, Source: SourceResponse, Severity: RiskMedium, Detail: strings.Join(kinds, ", "),
+		})
+	}
 
-[SYSTEM INSTRUCTION]
-1. Your goal is to answer the USER'S NEW MESSAGE directly.
-2. Context is provided below for reference (Student Profile, Timetable, etc.).
+	v, err := s.Moderator.Moderate(ctx, answer)
+	if err != nil {
+		slog.WarnContext(ctx, "Moderation failed", "error", err)
+		return answer
+	}
+	if !v.Flagged {
+		return answer
+	}
+	s.Safety.Record(ctx, models.SafetyIncident{
+		UserID: env.userID, Role: env.role, ConversationID: env.conversationID,
+		Kind: v.Category, Source: SourceResponse, Severity: RiskHigh, Detail: v.Reason, Excerpt: answer,
+	})
+	if v.Category == ModerationSelfHarm {
+		return selfHarmReply(s.Helpline)
+	}
+	return unsafeAnswerReply
+}
 
-CONTEXT:
-%s
-%s
-%s
-%s
+// roster returns the students of the given courses. A failed lookup is logged and
+// leaves the names unchecked.
+func (s *RAGService) roster(ctx context.Context, courseIDs []string) []models.StudentContact {
+	seen := make(map[string]bool)
+	var students []models.StudentContact
+	for _, courseID := range courseIDs {
+		list, err := s.Data.Enrollments.Students(ctx, courseID)
+		if err != nil {
+			slog.WarnContext(ctx, "Course roster lookup failed", "course_id", courseID, "error", err)
+			continue
+		}
+		for _, st := range list {
+			if !seen[st.StudentID] {
+				seen[st.StudentID] = true
+				students = append(students, st)
+			}
+		}
+	}
+	return students
+}
 
-USER: %s
-ASSISTANT:`,
-		systemRole,
-		contextString,
-		vectorContext,
-		sentiment,
-		message)
+// How an answer was produced (ChatLogs.route on bot messages)
+const (
+	RouteLookup   = "lookup" // Deterministic database answer
+	RouteCache    = "cache"
+	RouteModel    = "model"
+	RouteSafety   = "safety"   // Fixed reply to a message moderation flagged
+	RouteDegraded = "degraded" // Database answer while the model is unavailable
+)
 
-	// 6. Call Ollama
-	response, err := s.callOllama(prompt)
-	if err != nil {
-		return "", err
-	}
+// chatTurn is one user message and the reply to it.
+type chatTurn struct {
+	UserID         string
+	ConversationID string
+	AgentID        string
+	Message        string
+	Sentiment      string
+	Intent         Classification
+	Response       string
+	Route          string
+	Citations      []models.Citation
+	Support        []models.SupportResource // Listed under the reply
+}
 
-	// 7. Store in Mongo
-	coll := config.MongoDB.Collection("ChatLogs")
+// storeExchange logs the user's message and the reply to ChatLogs under the thread and
+// refreshes the user's ChatContext.
+func (s *RAGService) storeExchange(ctx context.Context, t chatTurn) *ChatReply {
+	trace.SpanFromContext(ctx).SetAttributes(tracing.Route.String(t.Route))
+	ctx, cancel := detach(ctx)
+	defer cancel()
+	coll := s.Threads.logs
 	// User Msg
 	userLog := models.ChatLog{
-		StudentID: userID,
-		Message:   message,
-		Intent:    "chat",
-		Sentiment: sentiment,
-		Timestamp: time.Now(),
-		IsBot:     false,
+		StudentID:      t.UserID,
+		ConversationID: t.ConversationID,
+		AgentID:        t.AgentID,
+		Message:        t.Message,
+		Intent:         t.Intent.Intent,
+		Topic:          t.Intent.Topic,
+		IntentSource:   t.Intent.Source,
+		Sentiment:      t.Sentiment,
+		Timestamp:      time.Now(),
+		IsBot:          false,
+	}
+	if _, err := coll.InsertOne(ctx, userLog); err != nil {
+		slog.ErrorContext(ctx, "Storing chat message failed", "error", err)
 	}
-	coll.InsertOne(ctx, userLog)
 
 	// Bot Msg
-	// Bot Msg
+	if len(t.Support) > 0 {
+		t.Response += "\n\n" + supportText(t.Support)
+	}
 	botLog := models.ChatLog{
-		StudentID: userID,
-		Message:   response,
-		Intent:    "reply",
-		Timestamp: time.Now(),
-		IsBot:     true,
+		StudentID:      t.UserID,
+		ConversationID: t.ConversationID,
+		AgentID:        t.AgentID,
+		Message:        t.Response,
+		Intent:         "reply",
+		Topic:          t.Intent.Topic,
+		Route:          t.Route,
+		Citations:      t.Citations,
+		Timestamp:      time.Now(),
+		IsBot:          true,
+	}
+	reply := &ChatReply{Response: t.Response, Citations: t.Citations, Intent: t.Intent, Support: t.Support}
+	if res, err := coll.InsertOne(ctx, botLog); err != nil {
+		slog.ErrorContext(ctx, "Storing chat reply failed", "error", err)
+	} else if oid, ok := res.InsertedID.(primitive.ObjectID); ok {
+		reply.MessageID = oid.Hex()
+	}
+
+	if t.ConversationID != "" {
+		s.Threads.touch(ctx, t.ConversationID, 2)
 	}
-	coll.InsertOne(ctx, botLog)
 
 	// Update Context (Simple upsert)
-	contextColl := config.MongoDB.Collection("ChatContext")
-	contextColl.UpdateOne(ctx, bson.M{"student_id": userID}, bson.M{
+	_, err := s.contexts.UpdateOne(ctx, bson.M{"student_id": t.UserID}, bson.M{
 		"$set": bson.M{
-			"last_topic":       "general", // would need logic to extract topic
-			"emotion":          sentiment,
+			"last_topic":       t.Intent.Topic,
+			"last_intent":      t.Intent.Intent,
+			"emotion":          t.Sentiment,
 			"last_interaction": time.Now(),
 		},
 	}, options.Update().SetUpsert(true))
+	if err != nil {
+		slog.WarnContext(ctx, "Updating chat context failed", "error", err)
+	}
 
-	// 8. Cache Response
-	config.RedisClient.Set(ctx, cacheKey, response, 5*time.Minute)
-
-	return response, nil
+	return reply
 }
 
-func (s *RAGService) ClearChatHistory(studentID string) error {
-	ctx := context.Background()
-
+func (s *RAGService) ClearChatHistory(ctx context.Context, studentID string) error {
 	// 1. Delete Chat Logs
-	logsColl := config.MongoDB.Collection("ChatLogs")
-	_, err := logsColl.DeleteMany(ctx, bson.M{"student_id": studentID})
+	_, err := s.Threads.logs.DeleteMany(ctx, bson.M{"student_id": studentID})
 	if err != nil {
 		return fmt.Errorf("failed to delete logs: %w", err)
 	}
 
-	// 2. Delete/Reset Context
-	contextColl := config.MongoDB.Collection("ChatContext")
-	_, err = contextColl.DeleteMany(ctx, bson.M{"student_id": studentID})
+	// 2. Delete Threads
+	_, err = s.Threads.threads.DeleteMany(ctx, bson.M{"user_id": studentID})
 	if err != nil {
-		return fmt.Errorf("failed to delete context: %w", err)
+		return fmt.Errorf("failed to delete threads: %w", err)
 	}
 
-	return nil
-}
-
-type OllamaRequest struct {
-	Model   string                 `json:"model"`
-	Prompt  string                 `json:"prompt"`
-	Stream  bool                   `json:"stream"`
-	Options map[string]interface{} `json:"options,omitempty"`
-}
-
-type OllamaResponse struct {
-	Response string `json:"response"`
-}
-
-func (s *RAGService) callOllama(prompt string) (string, error) {
-	url := "http://localhost:11434/api/generate"
-	reqBody := OllamaRequest{
-		Model:  "llama3.2",
-		Prompt: prompt,
-		Stream: false,
-		Options: map[string]interface{}{
-			"stop": []string{"User:", "System:", "Assistant:"},
-		},
-	}
-	jsonData, _ := json.Marshal(reqBody)
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	// 3. Delete/Reset Context
+	_, err = s.contexts.DeleteMany(ctx, bson.M{"student_id": studentID})
 	if err != nil {
-		// Fallback for dev/demo if Ollama not running
-		log.Println("Ollama Unreachable:", err)
-		return "Simulated AI Response: " + prompt, nil
+		return fmt.Errorf("failed to delete context: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, _ := io.ReadAll(resp.Body)
-	var oResp OllamaResponse
-	json.Unmarshal(body, &oResp)
-
-	if oResp.Response == "" {
-		return "I'm having trouble thinking right now.", nil
-	}
-	return oResp.Response, nil
+	return nil
 }
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"academ_aide/internal/cache"
+	"academ_aide/internal/config"
+	"academ_aide/internal/metrics"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	responseTTL        = 5 * time.Minute
+	semanticTTL        = time.Hour
+	semanticMaxEntries = 200 // Per course and agent; older answers are trimmed
+)
+
+// uncachedIntents are questions about the user's own timetable, grades or wellbeing.
+// Their answers go stale as soon as a grade, enrollment or timetable changes.
+var uncachedIntents = map[string]bool{IntentSchedule: true, IntentGrades: true, IntentWellbeing: true}
+
+// cacheableIntent reports whether answers to a message classified as c may be cached.
+func cacheableIntent(c Classification) bool {
+	return !uncachedIntents[c.Intent]
+}
+
+// ResponseCache stores chat answers per user and agent, keyed on a fingerprint of the
+// context they were generated from. An optional semantic layer (cfg.SemanticCache)
+// reuses answers to near-duplicate general questions within a course.
+type ResponseCache struct {
+	rdb        *redis.Client
+	semantic   bool
+	similarity float64
+}
+
+// NewResponseCache reuses semantic answers at cfg.SemanticCacheThreshold cosine
+// similarity or more.
+func NewResponseCache(rdb *redis.Client, cfg config.Chat) *ResponseCache {
+	return &ResponseCache{
+		rdb:        rdb,
+		semantic:   cfg.SemanticCache,
+		similarity: cfg.SemanticCacheThreshold,
+	}
+}
+
+// ContextFingerprint hashes the message together with everything the answer depends
+// on (agent prompt, user context, retrieved materials).
+func ContextFingerprint(message string, contextParts ...string) string {
+	h := sha256.New()
+	h.Write([]byte(message))
+	for _, part := range contextParts {
+		h.Write([]byte{0})
+		h.Write([]byte(part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// promptFingerprint is the ContextFingerprint of the message and the inputs its system
+// prompt is rendered from. The clock is left out, or answers would only be reused
+// within the minute they were generated in.
+func promptFingerprint(message string, data chatPromptData) string {
+	data.Now = ""
+	return ContextFingerprint(message, fmt.Sprintf("%+v", data))
+}
+
+// Get returns the cached answer for this user, agent and fingerprint.
+func (c *ResponseCache) Get(ctx context.Context, userID, agentID, fingerprint string) (string, bool) {
+	resp, err := c.rdb.Get(ctx, cache.ResponseKey(userID, agentID, fingerprint)).Result()
+	metrics.CacheLookup(metrics.CacheResponse, err == nil)
+	if err != nil {
+		return "", false
+	}
+	return resp, true
+}
+
+func (c *ResponseCache) Set(ctx context.Context, userID, agentID, fingerprint, response string) {
+	if err := c.rdb.Set(ctx, cache.ResponseKey(userID, agentID, fingerprint), response, responseTTL).Err(); err != nil {
+		slog.WarnContext(ctx, "Response cache write failed", "error", err)
+	}
+}
+
+// SemanticEnabled reports whether near-duplicate answers may be shared.
+func (c *ResponseCache) SemanticEnabled() bool {
+	return c.semantic
+}
+
+type semanticEntry struct {
+	Message   string    `json:"message"`
+	Embedding []float32 `json:"embedding"`
+	Response  string    `json:"response"`
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// GetSemantic returns the most similar earlier answer given to the role in the course
+// if it clears the similarity threshold.
+func (c *ResponseCache) GetSemantic(ctx context.Context, courseID, role, agentID string, embedding []float32) (string, bool) {
+	if !c.semantic || courseID == "" || len(embedding) == 0 {
+		return "", false
+	}
+	raw, err := c.rdb.LRange(ctx, cache.SemanticKey(courseID, role, agentID), 0, -1).Result()
+	if err != nil {
+		metrics.CacheLookup(metrics.CacheSemantic, false)
+		return "", false
+	}
+
+	best, bestScore := "", 0.0
+	for _, item := range raw {
+		var e semanticEntry
+		if err := json.Unmarshal([]byte(item), &e); err != nil {
+			continue
+		}
+		if score := cosineSimilarity(embedding, e.Embedding); score > bestScore {
+			best, bestScore = e.Response, score
+		}
+	}
+	hit := bestScore >= c.similarity
+	metrics.CacheLookup(metrics.CacheSemantic, hit)
+	if !hit {
+		return "", false
+	}
+	return best, true
+}
+
+// SetSemantic records a general answer for reuse by the role within the course.
+func (c *ResponseCache) SetSemantic(ctx context.Context, courseID, role, agentID, message string, embedding []float32, response string) {
+	if !c.semantic || courseID == "" || len(embedding) == 0 {
+		return
+	}
+	data, err := json.Marshal(semanticEntry{Message: message, Embedding: embedding, Response: response})
+	if err != nil {
+		return
+	}
+	key := cache.SemanticKey(courseID, role, agentID)
+	pipe := c.rdb.TxPipeline()
+	pipe.LPush(ctx, key, data)
+	pipe.LTrim(ctx, key, 0, semanticMaxEntries-1)
+	pipe.Expire(ctx, key, semanticTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.WarnContext(ctx, "Semantic cache write failed", "error", err)
+	}
+}
+
+// mentionsUser reports whether an answer addresses the user by name, which makes it
+// unsafe to share with anyone else.
+func mentionsUser(response, name string) bool {
+	name = strings.TrimSpace(name)
+	return name != "" && strings.Contains(strings.ToLower(response), strings.ToLower(name))
+}
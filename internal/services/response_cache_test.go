@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPromptFingerprintIgnoresTheClock(t *testing.T) {
+	data := chatPromptData{
+		Persona:     "You are a tutor.",
+		HasTools:    true,
+		Intro:       "The user is Asha.",
+		Now:         "Monday, 10:00",
+		CourseLabel: "Enrolled Courses",
+		Courses:     []string{"Operating Systems (CS301)"},
+	}
+	first := promptFingerprint("Explain deadlock", data)
+
+	data.Now = "Monday, 10:01" // The same question a minute later
+	if got := promptFingerprint("Explain deadlock", data); got != first {
+		t.Error("fingerprint changed when only the clock did; the cache would miss")
+	}
+
+	data.Courses = append(data.Courses, "Databases (CS302)")
+	if got := promptFingerprint("Explain deadlock", data); got == first {
+		t.Error("fingerprint unchanged after the user's courses changed")
+	}
+	if got := promptFingerprint("Explain paging", data); got == promptFingerprint("Explain deadlock", data) {
+		t.Error("different messages share a fingerprint")
+	}
+}
+
+func TestCacheableIntent(t *testing.T) {
+	classifier := &IntentClassifier{} // Rules only
+	for _, tt := range []struct {
+		message   string
+		cacheable bool
+	}{
+		{"Explain deadlock in operating systems", true},
+		{"Explain how I derive a conflict serializable schedule", true},
+		{"What is my CGPA?", false},
+		{"When is my next class?", false},
+		{"What marks did I get in CS301?", false},
+		{"I'm so stressed about the exams", false},
+	} {
+		c := classifier.Classify(context.Background(), tt.message, nil)
+		if got := cacheableIntent(c); got != tt.cacheable {
+			t.Errorf("%q (%s): cacheable = %v, want %v", tt.message, c.Intent, got, tt.cacheable)
+		}
+	}
+}
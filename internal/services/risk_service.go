@@ -0,0 +1,564 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Risk levels (STUDENT_RISK.level)
+const (
+	RiskHigh   = "high"
+	RiskMedium = "medium"
+	RiskLow    = "low"
+)
+
+// Score thresholds on the 0-100 scale
+const (
+	RiskHighThreshold   = 60.0
+	RiskMediumThreshold = 35.0
+)
+
+// Risk factors
+const (
+	FactorGrades          = "grades"
+	FactorAssessmentTrend = "assessment_trend"
+	FactorQuiz            = "quiz"
+	FactorSentiment       = "sentiment"
+	FactorEngagement      = "engagement"
+)
+
+// riskWeights is the relative importance of each factor. Factors without data for a
+// student are dropped and the remaining weights renormalised to sum to 1.
+var riskWeights = map[string]float64{
+	FactorGrades:          0.35,
+	FactorAssessmentTrend: 0.25,
+	FactorQuiz:            0.15,
+	FactorSentiment:       0.10,
+	FactorEngagement:      0.15,
+}
+
+// gradeConcern maps a published letter grade onto a 0-1 concern score.
+var gradeConcern = map[string]float64{
+	"O": 0, "A+": 0, "A": 0.1, "B+": 0.2, "B": 0.3,
+	"C+": 0.45, "C": 0.55, "D": 0.75, "E": 0.85, "F": 1,
+}
+
+const (
+	activityWindow   = 14 * 24 * time.Hour // Chat sentiment and engagement
+	quizWindow       = 30 * 24 * time.Hour
+	minSentimentMsgs = 3 // Fewer messages than this say nothing about mood
+)
+
+type RiskService struct {
+	db     *sql.DB
+	mongo  *mongo.Database
+	alerts *AlertService
+}
+
+func NewRiskService(db *sql.DB, mdb *mongo.Database, alerts *AlertService) *RiskService {
+	return &RiskService{
+		db:     db,
+		mongo:  mdb,
+		alerts: alerts,
+	}
+}
+
+// --- Factors ---
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func gradeFactor(grade string) (models.RiskFactor, bool) {
+	concern, ok := gradeConcern[grade]
+	if !ok {
+		return models.RiskFactor{}, false
+	}
+	return models.RiskFactor{
+		Factor: FactorGrades,
+		Score:  concern,
+		Detail: fmt.Sprintf("Published grade %s", grade),
+	}, true
+}
+
+// assessmentFactor scores component percentages (in assessment order) on both their
+// level and whether the latest result dropped below the earlier average.
+func assessmentFactor(percents []float64) (models.RiskFactor, bool) {
+	if len(percents) == 0 {
+		return models.RiskFactor{}, false
+	}
+	sum := 0.0
+	for _, p := range percents {
+		sum += p
+	}
+	mean := sum / float64(len(percents))
+	score := clamp01((70 - mean) / 40) // >= 70% no concern, <= 30% maximum
+	detail := fmt.Sprintf("Average %.0f%% across %d assessment(s)", mean, len(percents))
+
+	if len(percents) >= 2 {
+		latest := percents[len(percents)-1]
+		earlier := (sum - latest) / float64(len(percents)-1)
+		if delta := latest - earlier; delta < 0 {
+			score = clamp01(score + clamp01(-delta/30)*0.5)
+			detail += fmt.Sprintf("; latest %.0f%% is %.0f points below earlier results", latest, -delta)
+		}
+	}
+	return models.RiskFactor{Factor: FactorAssessmentTrend, Score: score, Detail: detail}, true
+}
+
+func quizFactor(ratios []float64) (models.RiskFactor, bool) {
+	if len(ratios) == 0 {
+		return models.RiskFactor{}, false
+	}
+	sum := 0.0
+	for _, r := range ratios {
+		sum += r
+	}
+	avg := sum / float64(len(ratios))
+	return models.RiskFactor{
+		Factor: FactorQuiz,
+		Score:  clamp01((0.75 - avg) / 0.5), // >= 75% no concern, <= 25% maximum
+		Detail: fmt.Sprintf("Average quiz score %.0f%% over %d recent attempt(s)", avg*100, len(ratios)),
+	}, true
+}
+
+// sentimentFactor's detail is deliberately generic: the factor reaches teachers, and
+// how a student's chats read is for counsellors only.
+func sentimentFactor(negative, total int64) (models.RiskFactor, bool) {
+	if total < minSentimentMsgs {
+		return models.RiskFactor{}, false
+	}
+	ratio := float64(negative) / float64(total)
+	return models.RiskFactor{
+		Factor: FactorSentiment,
+		Score:  clamp01(ratio / 0.5), // Half the messages negative is maximum concern
+		Detail: "Wellbeing",
+	}, true
+}
+
+func engagementFactor(messages, quizzes int64) models.RiskFactor {
+	activity := float64(messages) + 3*float64(quizzes)
+	f := models.RiskFactor{Factor: FactorEngagement, Score: clamp01(1 - activity/10)}
+	if activity == 0 {
+		f.Detail = "No chat or quiz activity in the last 14 days"
+	} else {
+		f.Detail = fmt.Sprintf("%d chat message(s) and %d quiz attempt(s) in the last 14 days", messages, quizzes)
+	}
+	return f
+}
+
+// academicFactors measure coursework. Sentiment and engagement only qualify them:
+// without at least one academic factor a student has too little data to score.
+var academicFactors = []string{FactorGrades, FactorAssessmentTrend, FactorQuiz}
+
+// combineFactors renormalises the weights of the available factors and returns the
+// 0-100 score with factors ordered by contribution. ok is false when no academic factor
+// is present.
+func combineFactors(factors []models.RiskFactor) (score float64, ordered []models.RiskFactor, ok bool) {
+	totalWeight := 0.0
+	for _, f := range factors {
+		totalWeight += riskWeights[f.Factor]
+		ok = ok || contains(academicFactors, f.Factor)
+	}
+	if !ok || totalWeight == 0 {
+		return 0, factors, false
+	}
+	for i := range factors {
+		factors[i].Weight = math.Round(riskWeights[factors[i].Factor]/totalWeight*1000) / 1000
+		factors[i].Score = math.Round(factors[i].Score*1000) / 1000
+		score += factors[i].Weight * factors[i].Score
+	}
+	sort.SliceStable(factors, func(i, j int) bool {
+		return factors[i].Weight*factors[i].Score > factors[j].Weight*factors[j].Score
+	})
+	return math.Round(score*1000) / 10, factors, true
+}
+
+// reportedFactor returns the largest contributor other than sentiment, which is never
+// named to teachers or students. Every scored enrollment has an academic factor, so
+// one is always found.
+func reportedFactor(factors []models.RiskFactor) models.RiskFactor {
+	for _, f := range factors {
+		if f.Factor != FactorSentiment {
+			return f
+		}
+	}
+	return models.RiskFactor{}
+}
+
+// riskRank orders levels so threshold crossings can be detected.
+var riskRank = map[string]int{RiskLow: 0, RiskMedium: 1, RiskHigh: 2}
+
+func riskLevel(score float64) string {
+	switch {
+	case score >= RiskHighThreshold:
+		return RiskHigh
+	case score >= RiskMediumThreshold:
+		return RiskMedium
+	default:
+		return RiskLow
+	}
+}
+
+// --- Job ---
+
+// chatActivity is the per-student Mongo signal shared by all their courses.
+type chatActivity struct {
+	messages int64
+	negative int64
+}
+
+func (s *RiskService) chatActivity(ctx context.Context, studentID string, since time.Time) chatActivity {
+	var a chatActivity
+	if s.mongo == nil {
+		return a
+	}
+	coll := s.mongo.Collection("ChatLogs")
+	filter := bson.M{"student_id": studentID, "is_bot": false, "timestamp": bson.M{"$gte": since}}
+	a.messages, _ = coll.CountDocuments(ctx, filter)
+	filter["sentiment"] = "negative"
+	a.negative, _ = coll.CountDocuments(ctx, filter)
+	return a
+}
+
+func (s *RiskService) assessmentPercents(ctx context.Context, studentID, courseID string) ([]float64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT m.marks / ac.max_marks * 100
+		FROM ASSESSMENT_MARK m
+		JOIN ASSESSMENT_COMPONENT ac ON ac.component_id = m.component_id
+		WHERE ac.course_id=$1 AND m.student_id=$2
+		ORDER BY ac.component_id
+	`, courseID, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var percents []float64
+	for rows.Next() {
+		var p float64
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		percents = append(percents, p)
+	}
+	return percents, rows.Err()
+}
+
+func (s *RiskService) quizRatios(ctx context.Context, studentID, courseID string, since time.Time) ([]float64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT score::float / total_questions
+		FROM QUIZ_ATTEMPT
+		WHERE student_id=$1 AND course_id=$2 AND taken_at >= $3
+		ORDER BY taken_at DESC
+		LIMIT 5
+	`, studentID, courseID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratios []float64
+	for rows.Next() {
+		var r float64
+		if err := rows.Scan(&r); err != nil {
+			return nil, err
+		}
+		ratios = append(ratios, r)
+	}
+	return ratios, rows.Err()
+}
+
+// scoreEnrollment gathers every factor for one enrollment and stores the result. An
+// enrollment with insufficient data is left unscored: any earlier score is removed and
+// scored is false.
+func (s *RiskService) scoreEnrollment(ctx context.Context, studentID, courseID string, grade sql.NullString, chat chatActivity, now time.Time) (scored bool, err error) {
+	var factors []models.RiskFactor
+
+	if grade.Valid {
+		if f, ok := gradeFactor(grade.String); ok {
+			factors = append(factors, f)
+		}
+	}
+
+	percents, err := s.assessmentPercents(ctx, studentID, courseID)
+	if err != nil {
+		return false, err
+	}
+	if f, ok := assessmentFactor(percents); ok {
+		factors = append(factors, f)
+	}
+
+	ratios, err := s.quizRatios(ctx, studentID, courseID, now.Add(-quizWindow))
+	if err != nil {
+		return false, err
+	}
+	if f, ok := quizFactor(ratios); ok {
+		factors = append(factors, f)
+	}
+
+	if f, ok := sentimentFactor(chat.negative, chat.messages); ok {
+		factors = append(factors, f)
+	}
+
+	var recentQuizzes int64
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM QUIZ_ATTEMPT WHERE student_id=$1 AND taken_at >= $2",
+		studentID, now.Add(-activityWindow)).Scan(&recentQuizzes); err != nil {
+		return false, err
+	}
+	factors = append(factors, engagementFactor(chat.messages, recentQuizzes))
+
+	score, factors, ok := combineFactors(factors)
+	if !ok {
+		_, err := s.db.ExecContext(ctx, "DELETE FROM STUDENT_RISK WHERE student_id=$1 AND course_id=$2", studentID, courseID)
+		return false, err
+	}
+	level := riskLevel(score)
+	factorJSON, err := json.Marshal(factors)
+	if err != nil {
+		return false, err
+	}
+
+	var previous sql.NullString
+	if err := s.db.QueryRowContext(ctx,
+		"SELECT level FROM STUDENT_RISK WHERE student_id=$1 AND course_id=$2",
+		studentID, courseID).Scan(&previous); err != nil && err != sql.ErrNoRows {
+		return false, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO STUDENT_RISK (student_id, course_id, score, level, factors, computed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (student_id, course_id) DO UPDATE SET
+			score = EXCLUDED.score, level = EXCLUDED.level,
+			factors = EXCLUDED.factors, computed_at = EXCLUDED.computed_at
+	`, studentID, courseID, score, level, string(factorJSON), now)
+	if err != nil {
+		return false, err
+	}
+
+	// Alert the course's teachers when the student crosses into a higher level. An
+	// enrollment scored for the first time counts as coming from low.
+	if riskRank[level] > riskRank[previous.String] {
+		name, courseTitle := studentID, courseID
+		s.db.QueryRowContext(ctx, `
+			SELECT s.s_first_name || ' ' || s.s_last_name, c.title
+			FROM STUDENT s, COURSE c WHERE s.student_id=$1 AND c.course_id=$2
+		`, studentID, courseID).Scan(&name, &courseTitle)
+		title := fmt.Sprintf("%s is now %s risk in %s", name, level, courseTitle)
+		message := fmt.Sprintf("Risk score %.0f/100. Main factor: %s.", score, reportedFactor(factors).Detail)
+		key := fmt.Sprintf("risk:%s:%s:%s:%s", studentID, courseID, level, now.Format("2006-01-02"))
+		if _, err := s.alerts.Raise(ctx, courseID, studentID, AlertRiskThreshold, level, title, message, key); err != nil {
+			slog.ErrorContext(ctx, "Raising risk alert failed", "student_id", studentID, "course_id", courseID, "error", err)
+		}
+	}
+	return true, nil
+}
+
+// ComputeAll rescores every active enrollment and returns the number scored.
+// A failure on one enrollment is logged and does not stop the run.
+func (s *RiskService) ComputeAll(ctx context.Context) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT student_id, course_id, grade FROM ENROLLS_IN
+		WHERE status = 'Enrolled'
+		ORDER BY student_id
+	`)
+	if err != nil {
+		return 0, err
+	}
+	type enrollment struct {
+		studentID, courseID string
+		grade               sql.NullString
+	}
+	var enrollments []enrollment
+	for rows.Next() {
+		var e enrollment
+		if err := rows.Scan(&e.studentID, &e.courseID, &e.grade); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		enrollments = append(enrollments, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	scored := 0
+	var chat chatActivity
+	lastStudent := ""
+	for _, e := range enrollments {
+		if err := ctx.Err(); err != nil {
+			return scored, err
+		}
+		if e.studentID != lastStudent {
+			chat = s.chatActivity(ctx, e.studentID, now.Add(-activityWindow))
+			lastStudent = e.studentID
+		}
+		ok, err := s.scoreEnrollment(ctx, e.studentID, e.courseID, e.grade, chat, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "Risk scoring failed", "student_id", e.studentID, "course_id", e.courseID, "error", err)
+			continue
+		}
+		if ok {
+			scored++
+		}
+	}
+
+	// Completed or dropped courses are no longer at risk
+	if _, err := s.db.ExecContext(ctx, `
+		DELETE FROM STUDENT_RISK r USING ENROLLS_IN e
+		WHERE e.student_id = r.student_id AND e.course_id = r.course_id AND e.status <> 'Enrolled'
+	`); err != nil {
+		return scored, err
+	}
+	return scored, nil
+}
+
+// StartScheduler scores immediately and then every interval until ctx is cancelled.
+// The returned channel is closed when it has stopped.
+func (s *RiskService) StartScheduler(ctx context.Context, interval time.Duration) <-chan struct{} {
+	return schedule(ctx, "Risk scoring", interval, s.ComputeAll)
+}
+
+// RecordQuizAttempt stores a self-assessment result for the engagement and quiz factors.
+func (s *RiskService) RecordQuizAttempt(ctx context.Context, studentID, courseID string, score, total int) error {
+	if total <= 0 || score < 0 || score > total {
+		return &ValidationError{Field: "score", Message: "must be between 0 and total_questions"}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO QUIZ_ATTEMPT (student_id, course_id, score, total_questions)
+		VALUES ($1, $2, $3, $4)
+	`, studentID, courseID, score, total)
+	return translatePgError(err)
+}
+
+// --- Readers ---
+
+func scanRiskScores(rows *sql.Rows) ([]models.RiskScore, error) {
+	defer rows.Close()
+	scores := make([]models.RiskScore, 0)
+	for rows.Next() {
+		var r models.RiskScore
+		var factors []byte
+		if err := rows.Scan(&r.StudentID, &r.Name, &r.CourseID, &r.Title, &r.Score, &r.Level, &factors, &r.ComputedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(factors, &r.Factors); err != nil {
+			return nil, err
+		}
+		scores = append(scores, r)
+	}
+	return scores, rows.Err()
+}
+
+const riskSelect = `
+	SELECT r.student_id, s.s_first_name || ' ' || s.s_last_name, r.course_id, c.title,
+		r.score, r.level, r.factors, r.computed_at
+	FROM STUDENT_RISK r
+	JOIN STUDENT s ON s.student_id = r.student_id
+	JOIN COURSE c ON c.course_id = r.course_id
+`
+
+// CourseRisks returns a course's scored students, riskiest first. levels filters by
+// level; empty means all. The faculty member must teach the course.
+func (s *RiskService) CourseRisks(ctx context.Context, facultyID, courseID string, levels ...string) ([]models.RiskScore, error) {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	for i := range levels {
+		levels[i] = strings.ToLower(levels[i])
+	}
+	rows, err := s.db.QueryContext(ctx, riskSelect+`
+		WHERE r.course_id=$1 AND (COALESCE(cardinality($2::text[]), 0) = 0 OR r.level = ANY($2::text[]))
+		ORDER BY r.score DESC
+	`, courseID, levels)
+	if err != nil {
+		return nil, err
+	}
+	return scanRiskScores(rows)
+}
+
+// LevelCounts returns the number of students at each risk level in a course the
+// faculty member teaches.
+func (s *RiskService) LevelCounts(ctx context.Context, facultyID, courseID string) (map[string]int, error) {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	counts := map[string]int{RiskHigh: 0, RiskMedium: 0, RiskLow: 0}
+	rows, err := s.db.QueryContext(ctx, "SELECT level, COUNT(*) FROM STUDENT_RISK WHERE course_id=$1 GROUP BY level", courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var level string
+		var n int
+		if err := rows.Scan(&level, &n); err != nil {
+			return nil, err
+		}
+		counts[level] = n
+	}
+	return counts, rows.Err()
+}
+
+// StudentRisk returns the stored score for one enrollment in a course the faculty
+// member teaches, or ErrNotFound if the job has not scored it yet.
+func (s *RiskService) StudentRisk(ctx context.Context, facultyID, studentID, courseID string) (*models.RiskScore, error) {
+	if err := teachesCourse(ctx, s.db, facultyID, courseID); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, riskSelect+"WHERE r.student_id=$1 AND r.course_id=$2", studentID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	scores, err := scanRiskScores(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(scores) == 0 {
+		return nil, ErrNotFound
+	}
+	return &scores[0], nil
+}
+
+// StudentRisks returns the stored scores of all a student's active courses, riskiest first.
+func (s *RiskService) StudentRisks(ctx context.Context, studentID string) ([]models.RiskScore, error) {
+	rows, err := s.db.QueryContext(ctx, riskSelect+"WHERE r.student_id=$1 ORDER BY r.score DESC", studentID)
+	if err != nil {
+		return nil, err
+	}
+	return scanRiskScores(rows)
+}
+
+// teachesStudent returns ErrForbidden unless the faculty member teaches a course the
+// student is enrolled in.
+func (s *RiskService) teachesStudent(ctx context.Context, facultyID, studentID string) error {
+	var ok bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM TEACHES t JOIN ENROLLS_IN e ON e.course_id = t.course_id
+			WHERE t.faculty_id=$1 AND e.student_id=$2
+		)
+	`, facultyID, studentID).Scan(&ok)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
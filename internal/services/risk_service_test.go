@@ -0,0 +1,83 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"strings"
+	"testing"
+)
+
+func TestCombineFactors(t *testing.T) {
+	grade := func(g string) models.RiskFactor { f, _ := gradeFactor(g); return f }
+	quiz := func(ratios ...float64) models.RiskFactor { f, _ := quizFactor(ratios); return f }
+	sentiment := func(negative, total int64) models.RiskFactor { f, _ := sentimentFactor(negative, total); return f }
+
+	tests := []struct {
+		name    string
+		factors []models.RiskFactor
+		ok      bool
+		score   float64
+		level   string
+		top     string
+	}{
+		{"no data", nil, false, 0, "", ""},
+		{"new student", []models.RiskFactor{engagementFactor(0, 0)}, false, 0, "", ""},
+		{"two chat messages", []models.RiskFactor{engagementFactor(2, 0)}, false, 0, "", ""},
+		{"sentiment and engagement only", []models.RiskFactor{sentiment(3, 3), engagementFactor(3, 0)}, false, 0, "", ""},
+		// 0.35/0.5*0.1 + 0.15/0.5*1
+		{"good grade, idle", []models.RiskFactor{grade("A"), engagementFactor(0, 0)}, true, 37, RiskMedium, FactorEngagement},
+		{"failing grade", []models.RiskFactor{grade("F"), engagementFactor(10, 0)}, true, 70, RiskHigh, FactorGrades},
+		{"quizzes only", []models.RiskFactor{quiz(0.8, 0.9)}, true, 0, RiskLow, FactorQuiz},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, factors, ok := combineFactors(tt.factors)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if score != tt.score || riskLevel(score) != tt.level {
+				t.Errorf("score = %v (%s), want %v (%s)", score, riskLevel(score), tt.score, tt.level)
+			}
+			if factors[0].Factor != tt.top {
+				t.Errorf("top factor = %s, want %s", factors[0].Factor, tt.top)
+			}
+		})
+	}
+}
+
+func TestFactorsWithoutData(t *testing.T) {
+	if _, ok := gradeFactor("N/A"); ok {
+		t.Error("gradeFactor accepted an unknown grade")
+	}
+	if _, ok := assessmentFactor(nil); ok {
+		t.Error("assessmentFactor scored no assessments")
+	}
+	if _, ok := sentimentFactor(2, minSentimentMsgs-1); ok {
+		t.Error("sentimentFactor scored too few messages")
+	}
+}
+
+func TestAssessmentFactorTrend(t *testing.T) {
+	steady, _ := assessmentFactor([]float64{80, 80})
+	dropped, _ := assessmentFactor([]float64{90, 70}) // Same 80% mean, latest 20 points down
+	if steady.Score != 0 {
+		t.Errorf("steady score = %v, want 0", steady.Score)
+	}
+	if dropped.Score <= steady.Score {
+		t.Errorf("drop score = %v, want above %v", dropped.Score, steady.Score)
+	}
+}
+
+func TestReportedFactorSkipsSentiment(t *testing.T) {
+	f, _ := sentimentFactor(3, 3)
+	if strings.ContainsAny(f.Detail, "0123456789") {
+		t.Errorf("sentiment detail %q gives message counts", f.Detail)
+	}
+	grade, _ := gradeFactor("D")
+	factors := []models.RiskFactor{f, grade, engagementFactor(10, 0)} // Ordered by contribution
+	if got := reportedFactor(factors); got.Factor != FactorGrades {
+		t.Errorf("reportedFactor = %s, want %s", got.Factor, FactorGrades)
+	}
+}
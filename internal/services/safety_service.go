@@ -0,0 +1,112 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Incident kinds (SafetyIncidents.kind); moderation flags use the moderation category.
+// Severities share the risk level names.
+const (
+	IncidentPromptInjection   = "prompt_injection"
+	IncidentMaterialInjection = "material_injection"
+	IncidentPersonalData      = "personal_data_redacted"
+)
+
+// Incident sources
+const (
+	SourceMessage  = "message"
+	SourceMaterial = "material"
+	SourceResponse = "response"
+)
+
+const maxIncidentExcerpt = 200
+
+// SafetyService stores and lists guardrail incidents.
+type SafetyService struct {
+	incidents *mongo.Collection
+}
+
+func NewSafetyService(mdb *mongo.Database) *SafetyService {
+	return &SafetyService{
+		incidents: mdb.Collection("SafetyIncidents"),
+	}
+}
+
+// EnsureIndexes creates the indexes behind the incident list.
+func (s *SafetyService) EnsureIndexes(ctx context.Context) error {
+	if _, err := s.incidents.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "kind", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	}); err != nil {
+		return fmt.Errorf("indexing SafetyIncidents: %w", err)
+	}
+	return nil
+}
+
+// Record stores an incident. It never fails the chat: errors are only logged.
+func (s *SafetyService) Record(ctx context.Context, inc models.SafetyIncident) {
+	if utf8.RuneCountInString(inc.Excerpt) > maxIncidentExcerpt {
+		inc.Excerpt = string([]rune(inc.Excerpt)[:maxIncidentExcerpt]) + "..."
+	}
+	inc.CreatedAt = time.Now()
+	slog.WarnContext(ctx, "Safety incident", "kind", inc.Kind, "source", inc.Source, "severity", inc.Severity, "detail", inc.Detail)
+	ctx, cancel := detach(ctx)
+	defer cancel()
+	if _, err := s.incidents.InsertOne(ctx, inc); err != nil {
+		slog.ErrorContext(ctx, "Recording safety incident failed", "error", err)
+	}
+}
+
+// IncidentFilter narrows the incident list. From and To are inclusive YYYY-MM-DD dates.
+type IncidentFilter struct {
+	Kind   string
+	UserID string
+	From   string
+	To     string
+}
+
+// List returns incidents, newest first.
+func (s *SafetyService) List(ctx context.Context, f IncidentFilter, limit, offset int) ([]models.SafetyIncident, int64, error) {
+	filter := bson.M{}
+	if f.Kind != "" {
+		filter["kind"] = f.Kind
+	}
+	if f.UserID != "" {
+		filter["user_id"] = f.UserID
+	}
+	created, err := dateRange(f.From, f.To)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	total, err := s.incidents.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := s.incidents.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	incidents := make([]models.SafetyIncident, 0)
+	if err := cursor.All(ctx, &incidents); err != nil {
+		return nil, 0, err
+	}
+	return incidents, total, nil
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// schedule runs job immediately and then every interval until ctx is cancelled.
+// job returns the number of items it processed, which is logged with its duration.
+// A run may take at most one interval, so a hung run cannot pile up behind the next.
+// The returned channel is closed once ctx is cancelled and the current run has ended.
+func schedule(ctx context.Context, name string, interval time.Duration, job func(context.Context) (int, error)) <-chan struct{} {
+	run := func() {
+		start := time.Now()
+		runCtx, cancel := context.WithTimeout(ctx, interval)
+		defer cancel()
+		n, err := job(runCtx)
+		if err != nil && ctx.Err() != nil {
+			slog.InfoContext(ctx, "Scheduled job stopped for shutdown", "job", name)
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Scheduled job failed", "job", name, "error", err)
+			return
+		}
+		slog.InfoContext(ctx, "Scheduled job finished", "job", name, "items", n, "duration", time.Since(start).Round(time.Millisecond))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+	return done
+}
@@ -0,0 +1,256 @@
+package services
+
+import (
+	"academ_aide/internal/ai"
+	"academ_aide/internal/config"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentiment labels (ChatLogs.sentiment, ChatContext.emotion)
+const (
+	SentimentPositive = "positive"
+	SentimentNeutral  = "neutral"
+	SentimentNegative = "negative"
+)
+
+// Sentiment is the scorer's reading of one message.
+type Sentiment struct {
+	Label    string   `json:"label"`
+	Score    float64  `json:"score"`    // -1 (very negative) to 1 (very positive)
+	Distress float64  `json:"distress"` // 0 to 1
+	Crisis   bool     `json:"crisis"`   // Self-harm or suicide language
+	Signals  []string `json:"signals,omitempty"`
+	Source   string   `json:"source"` // "rules" or "model"
+}
+
+// sentimentLexicon weighs words by polarity. Words that also signal distress are in
+// distressLexicon.
+var sentimentLexicon = map[string]float64{
+	"good": 1.5, "great": 2, "awesome": 2.5, "amazing": 2.5, "excellent": 2.5, "love": 2.5, "like": 1,
+	"happy": 2, "glad": 1.5, "excited": 2, "confident": 1.5, "proud": 2, "relieved": 1.5, "calm": 1,
+	"thanks": 1.5, "thank": 1.5, "helpful": 1.5, "nice": 1.5, "enjoy": 1.5, "enjoyed": 1.5, "fun": 1.5,
+	"interesting": 1, "clear": 1, "easy": 1, "better": 1, "best": 2, "passed": 1.5, "cool": 1,
+	"motivated": 1.5, "ready": 1, "fine": 0.5, "okay": 0.3, "ok": 0.3,
+
+	"bad": -1.5, "hate": -2.5, "terrible": -2.5, "awful": -2.5, "horrible": -2.5, "worst": -2.5,
+	"fail": -1.5, "failed": -2, "failing": -2, "fails": -1.5, "boring": -1, "confused": -1, "confusing": -1,
+	"difficult": -1, "hard": -0.5, "annoyed": -1.5, "angry": -2, "frustrated": -2, "frustrating": -2,
+	"upset": -2, "disappointed": -2, "useless": -2, "stupid": -2, "wrong": -1, "problem": -0.5,
+	"unfair": -1.5, "tired": -1, "worse": -1.5, "sick": -1, "lost": -1, "stuck": -1, "behind": -0.5,
+	"sad": -2, "scared": -2, "afraid": -2, "worried": -1.5, "nervous": -1.5,
+}
+
+// distressLexicon weighs words that signal emotional distress. They count as negative
+// sentiment too.
+var distressLexicon = map[string]float64{
+	"stressed": 1, "stress": 0.8, "stressful": 0.8, "anxious": 1.2, "anxiety": 1.2, "panic": 1.5,
+	"panicking": 1.5, "overwhelmed": 1.5, "overwhelming": 1.2, "depressed": 2, "depression": 2,
+	"hopeless": 2.5, "helpless": 2, "worthless": 2.5, "useless": 1, "lonely": 1.5, "alone": 1,
+	"isolated": 1.5, "miserable": 2, "exhausted": 1, "burnout": 1.5, "crying": 1.5, "cry": 1.2,
+	"scared": 0.8, "afraid": 0.8, "worried": 0.6, "sad": 0.8, "numb": 1.5, "empty": 1.2,
+	"failure": 1.5, "trapped": 2, "suffering": 2, "breakdown": 2, "insomnia": 1, "sleepless": 1,
+}
+
+// distressPhrases are multi-word distress signals. Like single words, they do not count
+// after a negator ("I won't give up").
+var distressPhrases = []struct {
+	pattern *regexp.Regexp
+	weight  float64
+}{
+	{regexp.MustCompile(`(?i)\bcan'?t (cope|take (it|this)( anymore)?|handle (it|this)|do this anymore|go on|sleep|stop crying)\b`), 2},
+	{regexp.MustCompile(`(?i)\b((give|giving) up|drop(ping)? out)\b`), 1.5},
+	{regexp.MustCompile(`(?i)\bno one (cares|understands|to talk to)\b`), 2},
+	{regexp.MustCompile(`(?i)\b(falling apart|breaking down|burn(ed|t) out|losing (it|my mind))\b`), 2},
+	{regexp.MustCompile(`(?i)\b(what'?s the point|nothing matters|i'?m a (failure|burden)|i hate myself)\b`), 2.5},
+}
+
+var (
+	negators     = map[string]bool{"not": true, "no": true, "never": true, "hardly": true, "barely": true, "without": true, "nothing": true, "nobody": true, "neither": true, "nor": true}
+	intensifiers = map[string]float64{"very": 1.5, "so": 1.4, "really": 1.4, "extremely": 1.8, "too": 1.3, "totally": 1.5, "completely": 1.5, "super": 1.4, "incredibly": 1.8, "absolutely": 1.6}
+	wordPattern  = regexp.MustCompile(`[a-z]+(?:'[a-z]+)?`)
+)
+
+// Score thresholds
+const (
+	negationWindow    = 3    // Words a negator reaches forward
+	negatedWeight     = -0.5 // "not good" is mildly negative, not very negative
+	sentimentNeutral  = 0.05 // |score| below this is neutral
+	distressSaturates = 4.0  // Weighted distress at which Distress reaches 1
+)
+
+// SentimentScorer rates messages with a lexicon, negation and intensifiers. When
+// a sentiment model is configured, a small model re-rates messages the lexicon finds
+// ambiguous (some distress, but below the concern threshold).
+type SentimentScorer struct {
+	llm ai.ChatClient // nil: lexicon only
+}
+
+// NewSentimentScorer uses cfg.SentimentModel; empty or "off" keeps the scorer
+// lexicon-only.
+func NewSentimentScorer(cfg config.LLM) *SentimentScorer {
+	s := &SentimentScorer{}
+	if model := cfg.SentimentModel; model != "" && model != "off" {
+		s.llm = ai.NewChatClientWithModel(cfg, model)
+	}
+	return s
+}
+
+// Score rates one message.
+func (s *SentimentScorer) Score(ctx context.Context, message string) Sentiment {
+	result := scoreLexicon(message)
+	if s.llm != nil && !result.Crisis && result.Distress > 0 && result.Distress < DistressConcern {
+		if m, ok := s.scoreWithModel(ctx, message); ok {
+			m.Crisis = m.Crisis || result.Crisis
+			m.Signals = result.Signals
+			return m
+		}
+	}
+	return result
+}
+
+// scoreLexicon is the rule-based score: word weights, flipped and damped after a
+// negator ("not happy"), scaled by a preceding intensifier ("so tired"), normalised
+// to -1..1.
+func scoreLexicon(message string) Sentiment {
+	lower := strings.ToLower(strings.ReplaceAll(message, "’", "'"))
+	words := wordPattern.FindAllString(lower, -1)
+
+	var sum, distress float64
+	var signals []string
+	for i, w := range words {
+		polarity, hasPolarity := sentimentLexicon[w]
+		weight, hasDistress := distressLexicon[w]
+		if !hasPolarity && !hasDistress {
+			continue
+		}
+		if hasDistress && !hasPolarity {
+			polarity = -weight
+		}
+
+		scale, negated := 1.0, false
+		for j := i - 1; j >= 0 && j >= i-negationWindow; j-- {
+			if isNegator(words[j]) {
+				negated = true
+				break
+			}
+			if f, ok := intensifiers[words[j]]; ok && j == i-1 {
+				scale = f
+			}
+		}
+		if negated {
+			sum += polarity * negatedWeight
+			continue // "not stressed" is no distress
+		}
+		sum += polarity * scale
+		if hasDistress {
+			distress += weight * scale
+			signals = append(signals, w)
+		}
+	}
+	for _, p := range distressPhrases {
+		for _, loc := range p.pattern.FindAllStringIndex(lower, -1) {
+			if negatedBefore(wordPattern.FindAllString(lower[:loc[0]], -1)) {
+				continue
+			}
+			distress += p.weight
+			sum -= p.weight
+			signals = append(signals, lower[loc[0]:loc[1]])
+			break // Each phrase counts once
+		}
+	}
+
+	result := Sentiment{
+		Score:    round2(sum / math.Sqrt(sum*sum+15)),
+		Distress: round2(math.Min(distress/distressSaturates, 1)),
+		Source:   IntentByRules,
+		Signals:  signals,
+	}
+	if v, _ := (KeywordModerator{}).Moderate(context.Background(), message); v.Flagged && v.Category == ModerationSelfHarm {
+		result.Crisis = true
+		result.Distress = 1
+		result.Score = math.Min(result.Score, -0.8)
+		result.Signals = append(result.Signals, v.Reason)
+	}
+	result.Label = sentimentLabel(result.Score)
+	return result
+}
+
+func isNegator(word string) bool {
+	return negators[word] || strings.HasSuffix(word, "n't")
+}
+
+// negatedBefore reports whether a negator is among the last negationWindow words.
+func negatedBefore(words []string) bool {
+	for j := len(words) - 1; j >= 0 && j >= len(words)-negationWindow; j-- {
+		if isNegator(words[j]) {
+			return true
+		}
+	}
+	return false
+}
+
+func sentimentLabel(score float64) string {
+	switch {
+	case score >= sentimentNeutral:
+		return SentimentPositive
+	case score <= -sentimentNeutral:
+		return SentimentNegative
+	default:
+		return SentimentNeutral
+	}
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+const sentimentPrompt = `Rate the emotional state in a student's message to an academic assistant.
+Reply with JSON only: {"score": <-1 to 1, negative to positive>, "distress": <0 to 1>, "crisis": <true if it mentions self-harm or suicide>}
+Distress is emotional strain (stress, hopelessness, isolation), not frustration with a topic.`
+
+func (s *SentimentScorer) scoreWithModel(ctx context.Context, message string) (Sentiment, bool) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	turn, err := s.llm.Chat(ctx, ai.ChatRequest{Agent: "sentiment", Messages: []ai.Message{
+		{Role: "system", Content: sentimentPrompt},
+		{Role: "user", Content: message},
+	}})
+	if err != nil {
+		slog.WarnContext(ctx, "Sentiment model failed", "error", err)
+		return Sentiment{}, false
+	}
+
+	content := turn.Content
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return Sentiment{}, false
+	}
+	var out struct {
+		Score    json.Number `json:"score"`
+		Distress json.Number `json:"distress"`
+		Crisis   bool        `json:"crisis"`
+	}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &out); err != nil {
+		return Sentiment{}, false
+	}
+	score, err1 := strconv.ParseFloat(out.Score.String(), 64)
+	distress, err2 := strconv.ParseFloat(out.Distress.String(), 64)
+	if err1 != nil || err2 != nil || score < -1 || score > 1 || distress < 0 || distress > 1 {
+		return Sentiment{}, false
+	}
+	return Sentiment{
+		Label:    sentimentLabel(score),
+		Score:    round2(score),
+		Distress: round2(distress),
+		Crisis:   out.Crisis,
+		Source:   IntentByModel,
+	}, true
+}
@@ -0,0 +1,75 @@
+package services
+
+import "testing"
+
+func TestScoreLexiconLabels(t *testing.T) {
+	tests := []struct {
+		message  string
+		label    string
+		distress bool
+	}{
+		{"I am happy with this course", SentimentPositive, false},
+		{"I am not happy with this course", SentimentNegative, false},
+		{"I don't like recursion", SentimentNegative, false},
+		{"I'm not stressed about the exam", SentimentPositive, false},
+		{"I am stressed about the exam", SentimentNegative, true},
+		{"I can't cope with this", SentimentNegative, true},
+		{"I won't give up", SentimentNeutral, false},
+		{"I'm not going to drop out", SentimentNeutral, false},
+		{"I never said I can't cope", SentimentNeutral, false},
+		{"I want to give up", SentimentNegative, true},
+		{"I'm thinking of dropping out", SentimentNegative, true},
+		{"I won't lie, I want to give up", SentimentNegative, true},
+		{"What is a B-tree?", SentimentNeutral, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			s := scoreLexicon(tt.message)
+			if s.Label != tt.label {
+				t.Errorf("label = %s (score %v), want %s", s.Label, s.Score, tt.label)
+			}
+			if (s.Distress > 0) != tt.distress {
+				t.Errorf("distress = %v, want distress %v", s.Distress, tt.distress)
+			}
+		})
+	}
+}
+
+func TestScoreLexiconNegationAndIntensifiers(t *testing.T) {
+	score := func(message string) float64 { return scoreLexicon(message).Score }
+
+	// A negator flips and damps: "not happy" is mildly negative, less so than "sad"
+	if notHappy, sad := score("I am not happy"), score("I am sad"); !(notHappy < 0 && notHappy > sad) {
+		t.Errorf(`"not happy" = %v, want between "sad" (%v) and 0`, notHappy, sad)
+	}
+	// The negator reaches three words forward and no further
+	if s := score("not at all happy"); s >= 0 {
+		t.Errorf(`"not at all happy" = %v, want negative`, s)
+	}
+	if s := score("not that this was ever happy"); s <= 0 {
+		t.Errorf(`"not that this was ever happy" = %v, want positive`, s)
+	}
+	// An intensifier scales the word right after it
+	if so, plain := score("so tired"), score("tired"); so >= plain {
+		t.Errorf(`"so tired" = %v, want below "tired" (%v)`, so, plain)
+	}
+	if very, plain := score("very happy"), score("happy"); very <= plain {
+		t.Errorf(`"very happy" = %v, want above "happy" (%v)`, very, plain)
+	}
+	if so, plain := scoreLexicon("so stressed").Distress, scoreLexicon("stressed").Distress; so <= plain {
+		t.Errorf(`"so stressed" distress = %v, want above "stressed" (%v)`, so, plain)
+	}
+	if s := score("so I am tired"); s != score("tired") {
+		t.Errorf(`"so I am tired" = %v, want the unscaled %v`, s, score("tired"))
+	}
+}
+
+func TestScoreLexiconCrisis(t *testing.T) {
+	s := scoreLexicon("I want to die")
+	if !s.Crisis || s.Distress != 1 || s.Score > -0.8 {
+		t.Errorf("crisis message = %+v", s)
+	}
+	if s := scoreLexicon("This assignment is killing me"); s.Crisis {
+		t.Errorf("figure of speech flagged as crisis: %+v", s)
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"academ_aide/internal/cache"
+	"academ_aide/internal/metrics"
+	"academ_aide/internal/models"
+	"academ_aide/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StudentService backs the student dashboard. Profiles and timetables are cached in
+// Redis; the services that change them invalidate the keys.
+type StudentService struct {
+	db   *sql.DB
+	rdb  *redis.Client
+	repo repository.Repositories
+}
+
+func NewStudentService(db *sql.DB, rdb *redis.Client, repo repository.Repositories) *StudentService {
+	return &StudentService{
+		db:   db,
+		rdb:  rdb,
+		repo: repo,
+	}
+}
+
+// Cache lifetimes
+const (
+	profileTTL   = 5 * time.Minute
+	timetableTTL = time.Hour
+)
+
+// Profile returns the student with their enrolled course count, CGPA and next class today.
+// ErrNotFound if they do not exist.
+func (s *StudentService) Profile(ctx context.Context, studentID string) (*models.Student, error) {
+	cacheKey := cache.StudentProfileKey(studentID)
+
+	// 1. Check Redis Cache
+	cached, err := s.rdb.Get(ctx, cacheKey).Result()
+	if err == nil {
+		var st models.Student
+		if err := json.Unmarshal([]byte(cached), &st); err == nil {
+			metrics.CacheLookup(metrics.CacheProfile, true)
+			return &st, nil
+		}
+	} else if err != redis.Nil {
+		slog.WarnContext(ctx, "Redis read failed", "key", cacheKey, "error", err)
+	}
+	metrics.CacheLookup(metrics.CacheProfile, false)
+
+	// 2. Cache Miss - Query Postgres
+	st, err := s.repo.Students.Get(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enrolled course count and CGPA
+	if history, err := s.repo.Enrollments.History(ctx, studentID); err == nil {
+		for _, e := range history {
+			if e.Status == "Enrolled" {
+				st.CoursesEnrolled++
+			}
+		}
+		st.CGPA = cgpa(history)
+	} else {
+		slog.ErrorContext(ctx, "Fetching enrollments failed", "error", err)
+	}
+
+	// Next Class (Dashboard Feature): the first class strictly after now, today
+	now := time.Now()
+	if next, err := s.repo.Schedules.NextForStudent(ctx, studentID, now.Weekday().String(), now.Format("15:04")); err == nil {
+		st.NextClass, st.NextClassTime = next.Title, next.StartTime
+	} else {
+		st.NextClass = "No Upcoming Classes"
+		st.NextClassTime = "Today"
+	}
+
+	// 3. Store result in Redis
+	if jsonBytes, err := json.Marshal(st); err == nil {
+		if err := s.rdb.Set(ctx, cacheKey, jsonBytes, profileTTL).Err(); err != nil {
+			slog.WarnContext(ctx, "Redis write failed", "key", cacheKey, "error", err)
+		}
+	}
+	return st, nil
+}
+
+// cgpa is the credit-weighted grade point average of the graded enrollments on a
+// 10-point scale.
+func cgpa(history []models.Enrollment) float64 {
+	totalCredits := 0
+	totalPoints := 0.0
+	for _, e := range history {
+		if e.Grade == "" {
+			continue
+		}
+		totalPoints += gradePoints(e.Grade) * float64(e.Credits)
+		totalCredits += e.Credits
+	}
+	if totalCredits == 0 {
+		return 0
+	}
+	return float64(int((totalPoints/float64(totalCredits))*100)) / 100 // Round to 2 decimal places
+}
+
+// Timetable returns every scheduled slot of the student's courses. ENROLLS_IN has no
+// section, so all sections' slots are listed. cached reports a Redis hit.
+func (s *StudentService) Timetable(ctx context.Context, studentID string) (schedule []models.ScheduleItem, cached bool, err error) {
+	cacheKey := cache.TimetableKey(studentID)
+
+	val, err := s.rdb.Get(ctx, cacheKey).Result()
+	if err == nil {
+		json.Unmarshal([]byte(val), &schedule)
+		metrics.CacheLookup(metrics.CacheTimetable, true)
+		return schedule, true, nil
+	} else if err != redis.Nil {
+		// Redis error, log it but continue to DB
+		slog.WarnContext(ctx, "Redis read failed", "key", cacheKey, "error", err)
+	}
+	metrics.CacheLookup(metrics.CacheTimetable, false)
+
+	schedule, err = s.repo.Schedules.ForStudent(ctx, studentID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	jsonBytes, _ := json.Marshal(schedule)
+	if err := s.rdb.Set(ctx, cacheKey, jsonBytes, timetableTTL).Err(); err != nil {
+		slog.WarnContext(ctx, "Redis write failed", "key", cacheKey, "error", err)
+	}
+	return schedule, false, nil
+}
+
+// Resources lists the materials of the student's courses.
+func (s *StudentService) Resources(ctx context.Context, studentID string) ([]models.Resource, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.resource_id, r.title, r.description, r.type, r.course_id, r.link
+		FROM RESOURCE r
+		JOIN ENROLLS_IN e ON r.course_id = e.course_id
+		WHERE e.student_id = $1
+	`, studentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var resources []models.Resource
+	for rows.Next() {
+		var r models.Resource
+		var desc, link sql.NullString
+		if err := rows.Scan(&r.ResourceID, &r.Title, &desc, &r.Type, &r.CourseID, &link); err != nil {
+			continue
+		}
+		r.Description = desc.String
+		r.Link = link.String
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+// Announcements lists the announcements of the student's courses, newest first.
+func (s *StudentService) Announcements(ctx context.Context, studentID string) ([]models.CourseAnnouncement, error) {
+	return s.repo.Announcements.ForStudent(ctx, studentID)
+}
+
+// Courses lists the courses the student is currently enrolled in.
+func (s *StudentService) Courses(ctx context.Context, studentID string) ([]models.EnrolledCourse, error) {
+	return s.repo.Enrollments.Current(ctx, studentID)
+}
+
+// Teachers lists who teaches each of the student's current courses.
+func (s *StudentService) Teachers(ctx context.Context, studentID string) ([]models.CourseTeacher, error) {
+	return s.repo.Faculty.TeachersOf(ctx, studentID)
+}
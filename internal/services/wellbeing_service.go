@@ -0,0 +1,426 @@
+package services
+
+import (
+	"academ_aide/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Wellbeing alert triggers (WELLBEING_ALERT.trigger_kind)
+const (
+	TriggerCrisis            = "crisis_language"
+	TriggerSustainedDistress = "sustained_distress"
+)
+
+// Wellbeing alert statuses
+const (
+	WellbeingOpen         = "open"
+	WellbeingAcknowledged = "acknowledged"
+	WellbeingResolved     = "resolved"
+)
+
+// Mood trend settings
+const (
+	DistressConcern    = 0.5 // A message scoring at least this counts as distressed
+	moodHistory        = 20  // Scores kept per student
+	moodWindow         = 7 * 24 * time.Hour
+	sustainedMessages  = 3   // Distressed messages in the window that make distress sustained
+	sustainedAverage   = 0.4 // ... provided the window's average distress is at least this
+	moodDirectionDelta = 0.15
+)
+
+// Actor for alerts raised by chat in the access log
+const wellbeingSystemActor = "system"
+
+// Fallback when SUPPORT_RESOURCE is empty or unreadable
+var defaultSupportResources = []models.SupportResource{
+	{Name: "Student Counselling Centre", Description: "Free, confidential counselling for any student", Contact: "Ask the department office or your mentor"},
+	{Name: "Tele-MANAS", Description: "National mental health helpline", Contact: "14416 or 1800-891-4416", Hours: "24x7"},
+	{Name: "KIRAN", Description: "Mental health rehabilitation helpline", Contact: "1800-599-0019", Hours: "24x7"},
+}
+
+// WellbeingService tracks students' chat mood and manages the confidential alerts
+// only counsellors can read. Every counsellor access is written to
+// WELLBEING_ACCESS_LOG.
+type WellbeingService struct {
+	db       *sql.DB
+	contexts *mongo.Collection
+}
+
+func NewWellbeingService(db *sql.DB, mdb *mongo.Database) *WellbeingService {
+	return &WellbeingService{
+		db:       db,
+		contexts: mdb.Collection("ChatContext"),
+	}
+}
+
+// --- Mood trend ---
+
+// Track appends a message score to the student's mood history and returns the
+// updated trend.
+func (s *WellbeingService) Track(ctx context.Context, studentID string, score Sentiment) (models.MoodTrend, error) {
+	now := time.Now()
+	entry := models.MoodEntry{At: now, Score: score.Score, Distress: score.Distress}
+	var doc models.ChatContext
+	err := s.contexts.FindOneAndUpdate(ctx,
+		bson.M{"student_id": studentID},
+		bson.M{"$push": bson.M{"mood": bson.M{"$each": []models.MoodEntry{entry}, "$slice": -moodHistory}}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After).SetProjection(bson.M{"mood": 1}),
+	).Decode(&doc)
+	if err != nil {
+		return moodTrend(studentID, []models.MoodEntry{entry}, now), err
+	}
+	return moodTrend(studentID, doc.Mood, now), nil
+}
+
+// moodTrend summarises the entries of the last moodWindow. Direction compares the
+// older and newer halves of the window.
+func moodTrend(studentID string, entries []models.MoodEntry, now time.Time) models.MoodTrend {
+	t := models.MoodTrend{StudentID: studentID, Direction: "steady", Entries: make([]models.MoodEntry, 0, len(entries))}
+	for _, e := range entries {
+		if now.Sub(e.At) <= moodWindow {
+			t.Entries = append(t.Entries, e)
+		}
+	}
+	t.Messages = len(t.Entries)
+	if t.Messages == 0 {
+		return t
+	}
+
+	var score, distress float64
+	for _, e := range t.Entries {
+		score += e.Score
+		distress += e.Distress
+		if e.Distress >= DistressConcern {
+			t.Distressed++
+		}
+	}
+	t.AvgScore = round2(score / float64(t.Messages))
+	t.AvgDistress = round2(distress / float64(t.Messages))
+	t.Sustained = t.Distressed >= sustainedMessages && t.AvgDistress >= sustainedAverage
+
+	if t.Messages >= 4 {
+		half := t.Messages / 2
+		older, newer := 0.0, 0.0
+		for i, e := range t.Entries {
+			if i < half {
+				older += e.Score
+			} else {
+				newer += e.Score
+			}
+		}
+		switch delta := newer/float64(t.Messages-half) - older/float64(half); {
+		case delta >= moodDirectionDelta:
+			t.Direction = "improving"
+		case delta <= -moodDirectionDelta:
+			t.Direction = "declining"
+		}
+	}
+	return t
+}
+
+// --- Escalation ---
+
+// Raise stores a confidential alert for the counsellor of the student's department
+// (or a campus-wide counsellor, the least loaded first). While the student has an
+// unresolved alert for the same trigger, it is updated instead: occurrences go up,
+// the excerpt is replaced and severity only rises.
+func (s *WellbeingService) Raise(ctx context.Context, studentID, trigger, severity string, distress float64, excerpt, conversationID string) (int, error) {
+	var alertID int
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		var counsellorID sql.NullString
+		err := tx.QueryRowContext(ctx, `
+			SELECT c.counsellor_id
+			FROM COUNSELLOR c
+			JOIN STUDENT st ON st.student_id=$1
+			WHERE c.active AND (c.dept_id = st.dept_id OR c.dept_id IS NULL)
+			ORDER BY (c.dept_id IS NULL),
+				(SELECT COUNT(*) FROM WELLBEING_ALERT a WHERE a.counsellor_id=c.counsellor_id AND a.status <> 'resolved'),
+				c.counsellor_id
+			LIMIT 1
+		`, studentID).Scan(&counsellorID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if !counsellorID.Valid {
+			slog.WarnContext(ctx, "No counsellor available; wellbeing alert left unassigned", "student_id", studentID)
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO WELLBEING_ALERT (student_id, counsellor_id, trigger_kind, severity, distress, excerpt, conversation_id)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+			ON CONFLICT (student_id, trigger_kind) WHERE status <> 'resolved' DO UPDATE SET
+				occurrences = WELLBEING_ALERT.occurrences + 1,
+				last_seen_at = CURRENT_TIMESTAMP,
+				excerpt = EXCLUDED.excerpt,
+				conversation_id = COALESCE(EXCLUDED.conversation_id, WELLBEING_ALERT.conversation_id),
+				distress = GREATEST(WELLBEING_ALERT.distress, EXCLUDED.distress),
+				severity = CASE WHEN EXCLUDED.severity = 'high' THEN 'high' ELSE WELLBEING_ALERT.severity END,
+				counsellor_id = COALESCE(WELLBEING_ALERT.counsellor_id, EXCLUDED.counsellor_id)
+			RETURNING alert_id
+		`, studentID, counsellorID, trigger, severity, distress, excerpt, conversationID).Scan(&alertID); err != nil {
+			return err
+		}
+		return logWellbeingAccess(ctx, tx, wellbeingSystemActor, "raise", alertID, studentID)
+	})
+	return alertID, err
+}
+
+// SupportResources lists the support services offered to distressed students.
+func (s *WellbeingService) SupportResources(ctx context.Context) []models.SupportResource {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT name, description, contact, COALESCE(hours, '')
+		FROM SUPPORT_RESOURCE ORDER BY sort_order, resource_id
+	`)
+	if err != nil {
+		slog.ErrorContext(ctx, "Reading support resources failed", "error", err)
+		return defaultSupportResources
+	}
+	defer rows.Close()
+
+	resources := make([]models.SupportResource, 0)
+	for rows.Next() {
+		var r models.SupportResource
+		if err := rows.Scan(&r.Name, &r.Description, &r.Contact, &r.Hours); err != nil {
+			slog.ErrorContext(ctx, "Reading support resources failed", "error", err)
+			return defaultSupportResources
+		}
+		resources = append(resources, r)
+	}
+	if len(resources) == 0 {
+		return defaultSupportResources
+	}
+	return resources
+}
+
+// supportText renders resources as lines appended to a chat reply.
+func supportText(resources []models.SupportResource) string {
+	var sb strings.Builder
+	sb.WriteString("Support is available:")
+	for _, r := range resources {
+		fmt.Fprintf(&sb, "\n- %s: %s", r.Name, r.Contact)
+		if r.Hours != "" {
+			fmt.Fprintf(&sb, " (%s)", r.Hours)
+		}
+	}
+	return sb.String()
+}
+
+// --- Counsellor access ---
+
+// logWellbeingAccess writes an access log row. alertID 0 and studentID "" are stored
+// as NULL.
+func logWellbeingAccess(ctx context.Context, q interface {
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}, actorID, action string, alertID int, studentID string) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO WELLBEING_ACCESS_LOG (actor_id, action, alert_id, student_id)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''))
+	`, actorID, action, alertID, studentID)
+	return err
+}
+
+func wellbeingStatusClause(status string) (string, error) {
+	switch status {
+	case "", "active":
+		return "a.status <> 'resolved'", nil
+	case WellbeingOpen, WellbeingAcknowledged, WellbeingResolved:
+		return "a.status = '" + status + "'", nil
+	case "all":
+		return "TRUE", nil
+	}
+	return "", &ValidationError{Field: "status", Message: "must be one of active, open, acknowledged, resolved, all"}
+}
+
+// Alerts a counsellor may see: their own and unassigned ones.
+const wellbeingVisible = "(a.counsellor_id = $1 OR a.counsellor_id IS NULL)"
+
+const wellbeingAlertColumns = `a.alert_id, a.student_id, st.s_first_name || ' ' || st.s_last_name, st.s_email,
+	COALESCE(st.s_phone_no, ''), st.dept_id, a.counsellor_id, a.trigger_kind, a.severity, a.distress,
+	a.excerpt, COALESCE(a.conversation_id, ''), a.occurrences, a.status, COALESCE(a.note, ''),
+	a.created_at, a.last_seen_at, a.acknowledged_at, a.resolved_at`
+
+func scanWellbeingAlert(row interface{ Scan(...interface{}) error }) (models.WellbeingAlert, error) {
+	var a models.WellbeingAlert
+	var counsellorID sql.NullString
+	var acknowledgedAt, resolvedAt sql.NullTime
+	if err := row.Scan(&a.AlertID, &a.StudentID, &a.StudentName, &a.StudentEmail, &a.StudentPhone, &a.DeptID,
+		&counsellorID, &a.Trigger, &a.Severity, &a.Distress, &a.Excerpt, &a.ConversationID, &a.Occurrences,
+		&a.Status, &a.Note, &a.CreatedAt, &a.LastSeenAt, &acknowledgedAt, &resolvedAt); err != nil {
+		return a, err
+	}
+	if counsellorID.Valid {
+		a.CounsellorID = &counsellorID.String
+	}
+	if acknowledgedAt.Valid {
+		a.AcknowledgedAt = &acknowledgedAt.Time
+	}
+	if resolvedAt.Valid {
+		a.ResolvedAt = &resolvedAt.Time
+	}
+	return a, nil
+}
+
+// ListAlerts returns a page of the alerts the counsellor may see, high severity and
+// newest first, with the total matching. Excerpts are left out of the list.
+func (s *WellbeingService) ListAlerts(ctx context.Context, counsellorID, status string, limit, offset int) ([]models.WellbeingAlert, int, error) {
+	statusClause, err := wellbeingStatusClause(status)
+	if err != nil {
+		return nil, 0, err
+	}
+	where := wellbeingVisible + " AND " + statusClause
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM WELLBEING_ALERT a WHERE `+where, counsellorID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+wellbeingAlertColumns+`
+		FROM WELLBEING_ALERT a JOIN STUDENT st ON st.student_id = a.student_id
+		WHERE `+where+`
+		ORDER BY (a.severity = 'high') DESC, a.last_seen_at DESC, a.alert_id DESC
+		LIMIT $2 OFFSET $3
+	`, counsellorID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	alerts := make([]models.WellbeingAlert, 0)
+	for rows.Next() {
+		a, err := scanWellbeingAlert(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		a.Excerpt = ""
+		alerts = append(alerts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := logWellbeingAccess(ctx, s.db, counsellorID, "list", 0, ""); err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
+
+// GetAlert returns one alert with its excerpt. Alerts of other counsellors are
+// ErrNotFound.
+func (s *WellbeingService) GetAlert(ctx context.Context, counsellorID string, alertID int) (*models.WellbeingAlert, error) {
+	a, err := scanWellbeingAlert(s.db.QueryRowContext(ctx, `
+		SELECT `+wellbeingAlertColumns+`
+		FROM WELLBEING_ALERT a JOIN STUDENT st ON st.student_id = a.student_id
+		WHERE `+wellbeingVisible+` AND a.alert_id = $2
+	`, counsellorID, alertID))
+	if err != nil {
+		return nil, translatePgError(err)
+	}
+	if err := logWellbeingAccess(ctx, s.db, counsellorID, "view", alertID, a.StudentID); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// UpdateAlert moves an alert to status ("acknowledged", "resolved" or "open" to
+// reopen) and stores the counsellor's note if one is given. Acting on an unassigned
+// alert assigns it to the counsellor.
+func (s *WellbeingService) UpdateAlert(ctx context.Context, counsellorID string, alertID int, status, note string) (*models.WellbeingAlert, error) {
+	var action string
+	switch status {
+	case WellbeingAcknowledged:
+		action = "acknowledge"
+	case WellbeingResolved:
+		action = "resolve"
+	case WellbeingOpen:
+		action = "reopen"
+	default:
+		return nil, &ValidationError{Field: "status", Message: "must be one of open, acknowledged, resolved"}
+	}
+
+	var studentID string
+	err := withTx(ctx, s.db, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE WELLBEING_ALERT a SET
+				status = $3,
+				counsellor_id = COALESCE(a.counsellor_id, $1),
+				note = COALESCE(NULLIF($4, ''), a.note),
+				acknowledged_at = CASE WHEN $3 = 'open' THEN NULL ELSE COALESCE(a.acknowledged_at, CURRENT_TIMESTAMP) END,
+				resolved_at = CASE WHEN $3 = 'resolved' THEN COALESCE(a.resolved_at, CURRENT_TIMESTAMP) ELSE NULL END
+			WHERE `+wellbeingVisible+` AND a.alert_id = $2
+			RETURNING a.student_id
+		`, counsellorID, alertID, status, strings.TrimSpace(note)).Scan(&studentID); err != nil {
+			return err
+		}
+		return logWellbeingAccess(ctx, tx, counsellorID, action, alertID, studentID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetAlert(ctx, counsellorID, alertID)
+}
+
+// StudentTrend returns the mood trend of a student the counsellor has an alert for.
+func (s *WellbeingService) StudentTrend(ctx context.Context, counsellorID, studentID string) (models.MoodTrend, error) {
+	var allowed bool
+	if err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM WELLBEING_ALERT a WHERE `+wellbeingVisible+` AND a.student_id = $2)
+	`, counsellorID, studentID).Scan(&allowed); err != nil {
+		return models.MoodTrend{}, err
+	}
+	if !allowed {
+		return models.MoodTrend{}, ErrNotFound
+	}
+	if err := logWellbeingAccess(ctx, s.db, counsellorID, "view_trend", 0, studentID); err != nil {
+		return models.MoodTrend{}, err
+	}
+
+	var doc models.ChatContext
+	err := s.contexts.FindOne(ctx, bson.M{"student_id": studentID}, options.FindOne().SetProjection(bson.M{"mood": 1})).Decode(&doc)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return models.MoodTrend{}, err
+	}
+	return moodTrend(studentID, doc.Mood, time.Now()), nil
+}
+
+// ListAccessLog returns the access log, newest first, for admins. It shows who
+// touched which alert or student, never the content.
+func (s *WellbeingService) ListAccessLog(ctx context.Context, actorID, studentID string, limit, offset int) ([]models.WellbeingAccessEntry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT log_id, actor_id, action, alert_id, student_id, created_at
+		FROM WELLBEING_ACCESS_LOG
+		WHERE ($1 = '' OR actor_id = $1) AND ($2 = '' OR student_id = $2)
+		ORDER BY created_at DESC, log_id DESC
+		LIMIT $3 OFFSET $4
+	`, actorID, studentID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.WellbeingAccessEntry, 0)
+	for rows.Next() {
+		var e models.WellbeingAccessEntry
+		var alertID sql.NullInt64
+		var student sql.NullString
+		if err := rows.Scan(&e.LogID, &e.ActorID, &e.Action, &alertID, &student, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if alertID.Valid {
+			id := int(alertID.Int64)
+			e.AlertID = &id
+		}
+		if student.Valid {
+			e.StudentID = &student.String
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
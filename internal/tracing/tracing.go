@@ -0,0 +1,98 @@
+// Package tracing sets up OpenTelemetry traces. Requests, SQL queries, MongoDB and
+// Redis commands and outgoing model calls are traced by their libraries' instrumentation;
+// code adds its own spans for the steps in between:
+//
+//	ctx, span := tracing.Start(ctx, "vector_search", tracing.ChunkCount.Int(len(chunks)))
+//	defer func() { tracing.End(span, err) }()
+//
+// Spans carry IDs, counts and timings only, never message text or personal data.
+package tracing
+
+import (
+	"academ_aide/internal/config"
+	"academ_aide/internal/logging"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentation names the tracer of this module's own spans.
+const instrumentation = "academ_aide"
+
+// Attributes of this module's spans.
+const (
+	AgentID          = attribute.Key("agent_id")
+	Intent           = attribute.Key("chat.intent")
+	Route            = attribute.Key("chat.route")
+	ToolName         = attribute.Key("chat.tool")
+	ToolSteps        = attribute.Key("chat.tool_steps")
+	ChunkCount       = attribute.Key("rag.chunk_count")
+	Provider         = attribute.Key("llm.provider")
+	Model            = attribute.Key("llm.model")
+	PromptTokens     = attribute.Key("llm.prompt_tokens")
+	CompletionTokens = attribute.Key("llm.completion_tokens")
+	ToolCalls        = attribute.Key("llm.tool_calls")
+)
+
+// Setup installs the tracer provider for cfg.Exporter and the W3C trace context
+// propagator, so traces continue across services. It returns a function that flushes
+// buffered spans; with the exporter off, spans are dropped and it does nothing.
+func Setup(ctx context.Context, cfg config.Tracing) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "otlp":
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("creating %s trace exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(attribute.String("service.name", cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("describing the service: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start opens a span of this module as a child of the one in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End marks span failed if err is set, then ends it. The error is redacted like a log
+// line.
+func End(span trace.Span, err error) {
+	if err != nil {
+		msg := logging.Redact(err.Error())
+		span.RecordError(errors.New(msg))
+		span.SetStatus(codes.Error, msg)
+	}
+	span.End()
+}